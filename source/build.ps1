@@ -4,37 +4,63 @@ $env:GOOS = "linux"
 
 gofmt -s -w .
 
-go build -ldflags="-s -w" -o bin/getUser rest/user/getUser/main.go
-go build -ldflags="-s -w" -o bin/updateUser rest/user/updateUser/main.go
-go build -ldflags="-s -w" -o bin/getAvatarURL rest/user/getAvatarURL/main.go
-
-go build -ldflags="-s -w" -o bin/registerDevice rest/device/registerDevice/main.go
-go build -ldflags="-s -w" -o bin/deregisterDevice rest/device/deregisterDevice/main.go
-
-go build -ldflags="-s -w" -o bin/createGroup rest/group/createGroup/main.go
-go build -ldflags="-s -w" -o bin/getGroup rest/group/getGroup/main.go
-go build -ldflags="-s -w" -o bin/getGroupMembers rest/group/getGroupMembers/main.go
-go build -ldflags="-s -w" -o bin/updateGroup rest/group/updateGroup/main.go
-go build -ldflags="-s -w" -o bin/joinGroup rest/group/joinGroup/main.go
-go build -ldflags="-s -w" -o bin/leaveGroup rest/group/leaveGroup/main.go
-go build -ldflags="-s -w" -o bin/getGroupQR rest/group/getGroupQR/main.go
-go build -ldflags="-s -w" -o bin/createGame rest/group/createGame/main.go
-go build -ldflags="-s -w" -o bin/deleteGame rest/group/deleteGame/main.go
-go build -ldflags="-s -w" -o bin/updateGame rest/group/updateGame/main.go
-go build -ldflags="-s -w" -o bin/getGames rest/group/getGames/main.go
-
-go build -ldflags="-s -w" -o bin/songSearch rest/song/songSearch/main.go
-go build -ldflags="-s -w" -o bin/createVote rest/votes/createVote/main.go
-go build -ldflags="-s -w" -o bin/deleteVote rest/votes/deleteVote/main.go
-
-go build -ldflags="-s -w" -o bin/signup rest/account/signup/main.go
-go build -ldflags="-s -w" -o bin/signin rest/account/signin/main.go
-go build -ldflags="-s -w" -o bin/validateJwt rest/account/validateJwt/main.go
-go build -ldflags="-s -w" -o bin/oauthAuthenticate rest/oauth/authenticate/main.go
-go build -ldflags="-s -w" -o bin/oauthCallback rest/oauth/callback/main.go
-
-go build -ldflags="-s -w" -o bin/chuneMachine lambda/chune-machine/main.go
-go build -ldflags="-s -w" -o bin/beanCounter lambda/bean-counter/main.go
-go build -ldflags="-s -w" -o bin/scoreTaker lambda/score-taker/main.go
+go build -ldflags="-s -w" -o bin/signup rest/account/signup/lambda/main.go
+go build -ldflags="-s -w" -o bin/signin rest/account/signin/lambda/main.go
+go build -ldflags="-s -w" -o bin/validateJwt rest/account/validateJwt/lambda/main.go
+go build -ldflags="-s -w" -o bin/oauthAuthenticate rest/oauth/authenticate/lambda/main.go
+go build -ldflags="-s -w" -o bin/oauthCallback rest/oauth/callback/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/registerDevice rest/device/registerDevice/lambda/main.go
+go build -ldflags="-s -w" -o bin/deregisterDevice rest/device/deregisterDevice/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/getUser rest/user/getUser/lambda/main.go
+go build -ldflags="-s -w" -o bin/getUserPoints rest/user/getUserPoints/lambda/main.go
+go build -ldflags="-s -w" -o bin/getUsersVotes rest/user/getUsersVotes/lambda/main.go
+go build -ldflags="-s -w" -o bin/updateUser rest/user/updateUser/lambda/main.go
+go build -ldflags="-s -w" -o bin/getAvatarURL rest/user/getAvatarURL/lambda/main.go
+go build -ldflags="-s -w" -o bin/getNotifications rest/user/getNotifications/lambda/main.go
+go build -ldflags="-s -w" -o bin/updateNotifications rest/user/updateNotifications/lambda/main.go
+go build -ldflags="-s -w" -o bin/getInbox rest/user/getInbox/lambda/main.go
+go build -ldflags="-s -w" -o bin/markInboxRead rest/user/markInboxRead/lambda/main.go
+go build -ldflags="-s -w" -o bin/markAllInboxRead rest/user/markAllInboxRead/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/createGroup rest/group/createGroup/lambda/main.go
+go build -ldflags="-s -w" -o bin/getGroup rest/group/getGroup/lambda/main.go
+go build -ldflags="-s -w" -o bin/deleteGroup rest/group/deleteGroup/lambda/main.go
+go build -ldflags="-s -w" -o bin/getGroupMembers rest/group/getGroupMembers/lambda/main.go
+go build -ldflags="-s -w" -o bin/getGroupLeaderboard rest/group/getGroupLeaderboard/lambda/main.go
+go build -ldflags="-s -w" -o bin/getGroupHistory rest/group/getGroupHistory/lambda/main.go
+go build -ldflags="-s -w" -o bin/getLeaderboard rest/leaderboard/getLeaderboard/lambda/main.go
+go build -ldflags="-s -w" -o bin/updateGroup rest/group/updateGroup/lambda/main.go
+go build -ldflags="-s -w" -o bin/updateGroupOwner rest/group/updateGroupOwner/lambda/main.go
+go build -ldflags="-s -w" -o bin/joinGroup rest/group/joinGroup/lambda/main.go
+go build -ldflags="-s -w" -o bin/leaveGroup rest/group/leaveGroup/lambda/main.go
+go build -ldflags="-s -w" -o bin/getGroupQR rest/group/getGroupQR/lambda/main.go
+go build -ldflags="-s -w" -o bin/createGame rest/group/createGame/lambda/main.go
+go build -ldflags="-s -w" -o bin/deleteGame rest/group/deleteGame/lambda/main.go
+go build -ldflags="-s -w" -o bin/updateGame rest/group/updateGame/lambda/main.go
+go build -ldflags="-s -w" -o bin/getGames rest/group/getGames/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/songSearch rest/song/songSearch/lambda/main.go
+go build -ldflags="-s -w" -o bin/getPlayedSongs rest/song/getPlayedSongs/lambda/main.go
+go build -ldflags="-s -w" -o bin/purgeSongs rest/song/purgeSongs/lambda/main.go
+go build -ldflags="-s -w" -o bin/mergeSongs rest/song/mergeSongs/lambda/main.go
+go build -ldflags="-s -w" -o bin/getPlayReviews rest/song/getPlayReviews/lambda/main.go
+go build -ldflags="-s -w" -o bin/resolvePlayReview rest/song/resolvePlayReview/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/createCountdown rest/countdown/createCountdown/lambda/main.go
+go build -ldflags="-s -w" -o bin/getCountdowns rest/countdown/getCountdowns/lambda/main.go
+go build -ldflags="-s -w" -o bin/setCurrentCountdown rest/countdown/setCurrentCountdown/lambda/main.go
+go build -ldflags="-s -w" -o bin/transitionCountdown rest/countdown/transitionCountdown/lambda/main.go
+go build -ldflags="-s -w" -o bin/scheduleCountdown rest/countdown/scheduleCountdown/lambda/main.go
+go build -ldflags="-s -w" -o bin/correctPlayPosition rest/countdown/correctPlayPosition/lambda/main.go
+go build -ldflags="-s -w" -o bin/setCountdownScoring rest/countdown/setCountdownScoring/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/createVote rest/votes/createVote/lambda/main.go
+go build -ldflags="-s -w" -o bin/deleteVote rest/votes/deleteVote/lambda/main.go
+
+go build -ldflags="-s -w" -o bin/chuneMachine lambda/chune-machine/lambda/main.go
+go build -ldflags="-s -w" -o bin/beanCounter lambda/bean-counter/lambda/main.go
+go build -ldflags="-s -w" -o bin/scoreTaker lambda/score-taker/lambda/main.go
 go build -ldflags="-s -w" -o bin/authorizer lambda/authorizer/main.go
-go build -ldflags="-s -w" -o bin/townCrier lambda/town-crier/main.go
+go build -ldflags="-s -w" -o bin/townCrier lambda/town-crier/lambda/main.go
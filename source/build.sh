@@ -4,79 +4,118 @@ rm -rf ./bin/*
 
 gofmt -s -w .
 
-go build -ldflags="-s -w" -o bin/signup             rest/account/signup/main.go
+go build -ldflags="-s -w" -o bin/signup             rest/account/signup/lambda/main.go
 echo "Built signup"
-go build -ldflags="-s -w" -o bin/signin             rest/account/signin/main.go
+go build -ldflags="-s -w" -o bin/signin             rest/account/signin/lambda/main.go
 echo "Built signin"
-go build -ldflags="-s -w" -o bin/validateJwt        rest/account/validateJwt/main.go
+go build -ldflags="-s -w" -o bin/validateJwt        rest/account/validateJwt/lambda/main.go
 echo "Built validateJwt"
-go build -ldflags="-s -w" -o bin/oauthAuthenticate  rest/oauth/authenticate/main.go
+go build -ldflags="-s -w" -o bin/oauthAuthenticate  rest/oauth/authenticate/lambda/main.go
 echo "Built oauthAuthenticate"
-go build -ldflags="-s -w" -o bin/oauthCallback      rest/oauth/callback/main.go
+go build -ldflags="-s -w" -o bin/oauthCallback      rest/oauth/callback/lambda/main.go
 echo "Built oauthCallback"
 
-go build -ldflags="-s -w" -o bin/registerDevice     rest/device/registerDevice/main.go
+go build -ldflags="-s -w" -o bin/registerDevice     rest/device/registerDevice/lambda/main.go
 echo "Built registerDevice"
-go build -ldflags="-s -w" -o bin/deregisterDevice   rest/device/deregisterDevice/main.go
+go build -ldflags="-s -w" -o bin/deregisterDevice   rest/device/deregisterDevice/lambda/main.go
 echo "Built deregisterDevice"
 
-go build -ldflags="-s -w" -o bin/getUser            rest/user/getUser/main.go
+go build -ldflags="-s -w" -o bin/getUser            rest/user/getUser/lambda/main.go
 echo "Built getUser"
-go build -ldflags="-s -w" -o bin/getUsersVotes      rest/user/getUsersVotes/main.go
+go build -ldflags="-s -w" -o bin/getUserPoints      rest/user/getUserPoints/lambda/main.go
+echo "Built getUserPoints"
+go build -ldflags="-s -w" -o bin/getUsersVotes      rest/user/getUsersVotes/lambda/main.go
 echo "Built getUsersVotes"
-go build -ldflags="-s -w" -o bin/updateUser         rest/user/updateUser/main.go
+go build -ldflags="-s -w" -o bin/updateUser         rest/user/updateUser/lambda/main.go
 echo "Built updateUser"
-go build -ldflags="-s -w" -o bin/getAvatarURL       rest/user/getAvatarURL/main.go
+go build -ldflags="-s -w" -o bin/getAvatarURL       rest/user/getAvatarURL/lambda/main.go
 echo "Built getAvatarURL"
+go build -ldflags="-s -w" -o bin/getNotifications   rest/user/getNotifications/lambda/main.go
+echo "Built getNotifications"
+go build -ldflags="-s -w" -o bin/updateNotifications rest/user/updateNotifications/lambda/main.go
+echo "Built updateNotifications"
+go build -ldflags="-s -w" -o bin/getInbox           rest/user/getInbox/lambda/main.go
+echo "Built getInbox"
+go build -ldflags="-s -w" -o bin/markInboxRead      rest/user/markInboxRead/lambda/main.go
+echo "Built markInboxRead"
+go build -ldflags="-s -w" -o bin/markAllInboxRead   rest/user/markAllInboxRead/lambda/main.go
+echo "Built markAllInboxRead"
 
-go build -ldflags="-s -w" -o bin/createGroup        rest/group/createGroup/main.go
+go build -ldflags="-s -w" -o bin/createGroup        rest/group/createGroup/lambda/main.go
 echo "Built createGroup"
-go build -ldflags="-s -w" -o bin/getGroup           rest/group/getGroup/main.go
+go build -ldflags="-s -w" -o bin/getGroup           rest/group/getGroup/lambda/main.go
 echo "Built getGroup"
-go build -ldflags="-s -w" -o bin/deleteGroup        rest/group/deleteGroup/main.go
+go build -ldflags="-s -w" -o bin/deleteGroup        rest/group/deleteGroup/lambda/main.go
 echo "Built deleteGroup"
-go build -ldflags="-s -w" -o bin/getGroupMembers    rest/group/getGroupMembers/main.go
+go build -ldflags="-s -w" -o bin/getGroupMembers    rest/group/getGroupMembers/lambda/main.go
 echo "Built getGroupMembers"
-go build -ldflags="-s -w" -o bin/updateGroup        rest/group/updateGroup/main.go
+go build -ldflags="-s -w" -o bin/getGroupLeaderboard rest/group/getGroupLeaderboard/lambda/main.go
+echo "Built getGroupLeaderboard"
+go build -ldflags="-s -w" -o bin/getGroupHistory    rest/group/getGroupHistory/lambda/main.go
+echo "Built getGroupHistory"
+go build -ldflags="-s -w" -o bin/getLeaderboard     rest/leaderboard/getLeaderboard/lambda/main.go
+echo "Built getLeaderboard"
+go build -ldflags="-s -w" -o bin/updateGroup        rest/group/updateGroup/lambda/main.go
 echo "Built updateGroup"
-go build -ldflags="-s -w" -o bin/updateGroupOwner   rest/group/updateGroupOwner/main.go
+go build -ldflags="-s -w" -o bin/updateGroupOwner   rest/group/updateGroupOwner/lambda/main.go
 echo "Built updateGroupOwner"
-go build -ldflags="-s -w" -o bin/joinGroup          rest/group/joinGroup/main.go
+go build -ldflags="-s -w" -o bin/joinGroup          rest/group/joinGroup/lambda/main.go
 echo "Built joinGroup"
-go build -ldflags="-s -w" -o bin/leaveGroup         rest/group/leaveGroup/main.go
+go build -ldflags="-s -w" -o bin/leaveGroup         rest/group/leaveGroup/lambda/main.go
 echo "Built leaveGroup"
-go build -ldflags="-s -w" -o bin/getGroupQR         rest/group/getGroupQR/main.go
+go build -ldflags="-s -w" -o bin/getGroupQR         rest/group/getGroupQR/lambda/main.go
 echo "Built getGroupQR"
-go build -ldflags="-s -w" -o bin/createGame         rest/group/createGame/main.go
+go build -ldflags="-s -w" -o bin/createGame         rest/group/createGame/lambda/main.go
 echo "Built createGame"
-go build -ldflags="-s -w" -o bin/deleteGame         rest/group/deleteGame/main.go
+go build -ldflags="-s -w" -o bin/deleteGame         rest/group/deleteGame/lambda/main.go
 echo "Built deleteGame"
-go build -ldflags="-s -w" -o bin/updateGame         rest/group/updateGame/main.go
+go build -ldflags="-s -w" -o bin/updateGame         rest/group/updateGame/lambda/main.go
 echo "Built updateGame"
-go build -ldflags="-s -w" -o bin/getGames           rest/group/getGames/main.go
+go build -ldflags="-s -w" -o bin/getGames           rest/group/getGames/lambda/main.go
 echo "Built getGames"
 
-go build -ldflags="-s -w" -o bin/songSearch         rest/song/songSearch/main.go
+go build -ldflags="-s -w" -o bin/songSearch         rest/song/songSearch/lambda/main.go
 echo "Built songSearch"
-go build -ldflags="-s -w" -o bin/getPlayedSongs     rest/song/getPlayedSongs/main.go
+go build -ldflags="-s -w" -o bin/getPlayedSongs     rest/song/getPlayedSongs/lambda/main.go
 echo "Built getPlayedSongs"
-go build -ldflags="-s -w" -o bin/purgeSongs         rest/song/purgeSongs/main.go
+go build -ldflags="-s -w" -o bin/purgeSongs         rest/song/purgeSongs/lambda/main.go
 echo "Built purgeSongs"
+go build -ldflags="-s -w" -o bin/mergeSongs         rest/song/mergeSongs/lambda/main.go
+echo "Built mergeSongs"
+go build -ldflags="-s -w" -o bin/getPlayReviews     rest/song/getPlayReviews/lambda/main.go
+echo "Built getPlayReviews"
+go build -ldflags="-s -w" -o bin/resolvePlayReview  rest/song/resolvePlayReview/lambda/main.go
+echo "Built resolvePlayReview"
 
-go build -ldflags="-s -w" -o bin/createVote         rest/votes/createVote/main.go
+go build -ldflags="-s -w" -o bin/createCountdown    rest/countdown/createCountdown/lambda/main.go
+echo "Built createCountdown"
+go build -ldflags="-s -w" -o bin/getCountdowns      rest/countdown/getCountdowns/lambda/main.go
+echo "Built getCountdowns"
+go build -ldflags="-s -w" -o bin/setCurrentCountdown rest/countdown/setCurrentCountdown/lambda/main.go
+echo "Built setCurrentCountdown"
+go build -ldflags="-s -w" -o bin/transitionCountdown rest/countdown/transitionCountdown/lambda/main.go
+echo "Built transitionCountdown"
+go build -ldflags="-s -w" -o bin/scheduleCountdown  rest/countdown/scheduleCountdown/lambda/main.go
+echo "Built scheduleCountdown"
+go build -ldflags="-s -w" -o bin/correctPlayPosition rest/countdown/correctPlayPosition/lambda/main.go
+echo "Built correctPlayPosition"
+go build -ldflags="-s -w" -o bin/setCountdownScoring rest/countdown/setCountdownScoring/lambda/main.go
+echo "Built setCountdownScoring"
+
+go build -ldflags="-s -w" -o bin/createVote         rest/votes/createVote/lambda/main.go
 echo "Built createVote"
-go build -ldflags="-s -w" -o bin/deleteVote         rest/votes/deleteVote/main.go
+go build -ldflags="-s -w" -o bin/deleteVote         rest/votes/deleteVote/lambda/main.go
 echo "Built deleteVote"
 
-go build -ldflags="-s -w" -o bin/chuneMachine       lambda/chune-machine/main.go
+go build -ldflags="-s -w" -o bin/chuneMachine       lambda/chune-machine/lambda/main.go
 echo "Built chuneMachine"
-go build -ldflags="-s -w" -o bin/beanCounter        lambda/bean-counter/main.go
+go build -ldflags="-s -w" -o bin/beanCounter        lambda/bean-counter/lambda/main.go
 echo "Built beanCounter"
-go build -ldflags="-s -w" -o bin/scoreTaker         lambda/score-taker/main.go
+go build -ldflags="-s -w" -o bin/scoreTaker         lambda/score-taker/lambda/main.go
 echo "Built scoreTaker"
 go build -ldflags="-s -w" -o bin/authorizer         lambda/authorizer/main.go
 echo "Built authorizer"
-go build -ldflags="-s -w" -o bin/townCrier          lambda/town-crier/main.go
+go build -ldflags="-s -w" -o bin/townCrier          lambda/town-crier/lambda/main.go
 echo "Built townCrier"
 
 echo "Done"
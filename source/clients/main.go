@@ -2,19 +2,42 @@ package clients
 
 import (
 	"context"
-	"github.com/aws/aws-sdk-go-v2/config"
+	sdkConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"jjj.rflett.com/jjj-api/config"
 )
 
 var (
-	awsConfig, _  = config.LoadDefaultConfig(context.TODO(), config.WithRegion("ap-southeast-2"))
-	S3Client      = s3.NewFromConfig(awsConfig)
-	SNSClient     = sns.NewFromConfig(awsConfig)
-	SQSClient     = sqs.NewFromConfig(awsConfig)
-	DynamoClient  = dynamodb.NewFromConfig(awsConfig)
-	SecretsClient = secretsmanager.NewFromConfig(awsConfig)
+	endpoints    = config.Values.Endpoints
+	awsConfig, _ = sdkConfig.LoadDefaultConfig(context.TODO(), sdkConfig.WithRegion(config.Values.Region))
+	S3Client     = s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if endpoints.S3 != "" {
+			o.EndpointResolver = s3.EndpointResolverFromURL(endpoints.S3)
+			o.UsePathStyle = true
+		}
+	})
+	SNSClient = sns.NewFromConfig(awsConfig, func(o *sns.Options) {
+		if endpoints.SNS != "" {
+			o.EndpointResolver = sns.EndpointResolverFromURL(endpoints.SNS)
+		}
+	})
+	SQSClient = sqs.NewFromConfig(awsConfig, func(o *sqs.Options) {
+		if endpoints.SQS != "" {
+			o.EndpointResolver = sqs.EndpointResolverFromURL(endpoints.SQS)
+		}
+	})
+	DynamoClient = dynamodb.NewFromConfig(awsConfig, func(o *dynamodb.Options) {
+		if endpoints.DynamoDB != "" {
+			o.EndpointResolver = dynamodb.EndpointResolverFromURL(endpoints.DynamoDB)
+		}
+	})
+	SecretsClient = secretsmanager.NewFromConfig(awsConfig, func(o *secretsmanager.Options) {
+		if endpoints.SecretsManager != "" {
+			o.EndpointResolver = secretsmanager.EndpointResolverFromURL(endpoints.SecretsManager)
+		}
+	})
 )
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/recompute"
+	"jjj.rflett.com/jjj-api/types"
+	"os"
+)
+
+// output is what the migration did, Scores is only there once it's applied
+type output struct {
+	Import *types.LegacyImport `json:"import"`
+	Scores *recompute.Report   `json:"scores,omitempty"`
+}
+
+func main() {
+	countdownID := flag.String("countdown", "", "the countdownID to import the votes and plays from before countdowns into")
+	apply := flag.Bool("apply", false, "import them and rebuild the points instead of only counting them")
+	flag.Parse()
+
+	if *countdownID == "" {
+		logger.Log.Fatal().Msg("The countdown to import into is required, create it first with POST countdown")
+	}
+	countdown := types.Countdown{CountdownID: *countdownID}
+	if _, err := countdown.Get(); err != nil {
+		logger.Log.Fatal().Err(err).Str("countdownID", *countdownID).Msg("Unable to get the countdown")
+	}
+
+	out := output{}
+	var err error
+	out.Import, err = countdown.ImportLegacy(!*apply)
+	if err == nil && *apply {
+		out.Scores, err = recompute.Run(*countdownID, false, recompute.DefaultBatchSize)
+	}
+	if out.Import != nil {
+		data, _ := json.MarshalIndent(out, "", "  ")
+		_, _ = os.Stdout.Write(append(data, '\n'))
+	}
+	if err != nil {
+		logger.Log.Fatal().Err(err).Str("countdownID", *countdownID).Msg("Unable to import the votes and plays")
+	}
+}
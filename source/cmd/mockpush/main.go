@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/push"
+	"net/http"
+	"strings"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8090", "the address to listen on")
+	unregistered := flag.String("unregistered", "", "a comma separated list of device tokens to answer as unregistered")
+	flag.Parse()
+
+	mock := push.NewMock()
+	for _, token := range strings.Split(*unregistered, ",") {
+		if token != "" {
+			mock.Unregister(token)
+		}
+	}
+
+	logger.Log.Info().Str("addr", *addr).Msg("Serving the mock push server, GET /deliveries lists what it's been sent")
+	if err := http.ListenAndServe(*addr, mock); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Mock push server stopped")
+	}
+}
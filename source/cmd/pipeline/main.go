@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/pipeline"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/types"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	memory := flag.Bool("memory", false, "use in-memory storage seeded with the test user instead of DynamoDB")
+	songID := flag.String("song", "", "the songID of the song that's currently playing")
+	flag.Parse()
+
+	if *memory {
+		types.UseTestStorage()
+		logger.Log.Info().Str("userID", types.TestAuthProviderUserID).Msg("Using in-memory storage with the test user")
+	}
+	config.Require("SPOTIFY_CLIENT_ID", "SPOTIFY_SECRET_ID")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	broker := pipeline.New(queue.RealClock{})
+
+	// start the chune-machine polling JJJ, it keeps putting itself back on the queue
+	if err := queue.ChuneRefresh.Send(types.ChuneRefreshBody{SongID: *songID}, 0); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Unable to start the chune-machine")
+	}
+
+	logger.Log.Info().Msg("Running the pipeline, press ctrl+c to stop")
+	broker.Run(ctx)
+	logger.Log.Info().Int("pending", broker.Pending()).Msg("Pipeline stopped")
+}
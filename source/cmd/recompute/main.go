@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/recompute"
+	"jjj.rflett.com/jjj-api/types"
+	"os"
+)
+
+func main() {
+	countdownID := flag.String("countdown", "", "the countdownID to recompute, defaults to the current countdown")
+	apply := flag.Bool("apply", false, "apply the changes instead of only showing them")
+	batch := flag.Int("batch", recompute.DefaultBatchSize, "how many users' scores to apply in each transaction")
+	memory := flag.Bool("memory", false, "use in-memory storage seeded with the test user instead of DynamoDB")
+	flag.Parse()
+
+	if *memory {
+		types.UseTestStorage()
+	}
+
+	if *countdownID == "" {
+		current, err := types.CurrentCountdownID()
+		if err != nil {
+			logger.Log.Fatal().Err(err).Msg("Unable to get the current countdown")
+		}
+		*countdownID = current
+	}
+
+	report, err := recompute.Run(*countdownID, !*apply, *batch)
+	if report != nil {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		_, _ = os.Stdout.Write(append(data, '\n'))
+	}
+	if err != nil {
+		logger.Log.Fatal().Err(err).Str("countdownID", *countdownID).Msg("Unable to recompute the scores")
+	}
+}
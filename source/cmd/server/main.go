@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"flag"
+	"github.com/golang-jwt/jwt"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/rest/account/signin"
+	"jjj.rflett.com/jjj-api/rest/account/signup"
+	"jjj.rflett.com/jjj-api/rest/account/validateJwt"
+	"jjj.rflett.com/jjj-api/rest/countdown/correctPlayPosition"
+	"jjj.rflett.com/jjj-api/rest/countdown/createCountdown"
+	"jjj.rflett.com/jjj-api/rest/countdown/getCountdowns"
+	"jjj.rflett.com/jjj-api/rest/countdown/scheduleCountdown"
+	"jjj.rflett.com/jjj-api/rest/countdown/setCountdownScoring"
+	"jjj.rflett.com/jjj-api/rest/countdown/setCurrentCountdown"
+	"jjj.rflett.com/jjj-api/rest/countdown/transitionCountdown"
+	"jjj.rflett.com/jjj-api/rest/device/deregisterDevice"
+	"jjj.rflett.com/jjj-api/rest/device/registerDevice"
+	"jjj.rflett.com/jjj-api/rest/group/createGame"
+	"jjj.rflett.com/jjj-api/rest/group/createGroup"
+	"jjj.rflett.com/jjj-api/rest/group/deleteGame"
+	"jjj.rflett.com/jjj-api/rest/group/deleteGroup"
+	"jjj.rflett.com/jjj-api/rest/group/getGames"
+	"jjj.rflett.com/jjj-api/rest/group/getGroup"
+	"jjj.rflett.com/jjj-api/rest/group/getGroupHistory"
+	"jjj.rflett.com/jjj-api/rest/group/getGroupLeaderboard"
+	"jjj.rflett.com/jjj-api/rest/group/getGroupMembers"
+	"jjj.rflett.com/jjj-api/rest/group/getGroupQR"
+	"jjj.rflett.com/jjj-api/rest/group/joinGroup"
+	"jjj.rflett.com/jjj-api/rest/group/leaveGroup"
+	"jjj.rflett.com/jjj-api/rest/group/updateGame"
+	"jjj.rflett.com/jjj-api/rest/group/updateGroup"
+	"jjj.rflett.com/jjj-api/rest/group/updateGroupOwner"
+	"jjj.rflett.com/jjj-api/rest/leaderboard/getLeaderboard"
+	"jjj.rflett.com/jjj-api/rest/oauth/authenticate"
+	"jjj.rflett.com/jjj-api/rest/oauth/callback"
+	"jjj.rflett.com/jjj-api/rest/song/getPlayReviews"
+	"jjj.rflett.com/jjj-api/rest/song/getPlayedSongs"
+	"jjj.rflett.com/jjj-api/rest/song/mergeSongs"
+	"jjj.rflett.com/jjj-api/rest/song/purgeSongs"
+	"jjj.rflett.com/jjj-api/rest/song/resolvePlayReview"
+	"jjj.rflett.com/jjj-api/rest/song/songSearch"
+	"jjj.rflett.com/jjj-api/rest/user/getAvatarURL"
+	"jjj.rflett.com/jjj-api/rest/user/getInbox"
+	"jjj.rflett.com/jjj-api/rest/user/getNotifications"
+	"jjj.rflett.com/jjj-api/rest/user/getUser"
+	"jjj.rflett.com/jjj-api/rest/user/getUserPoints"
+	"jjj.rflett.com/jjj-api/rest/user/getUsersVotes"
+	"jjj.rflett.com/jjj-api/rest/user/markAllInboxRead"
+	"jjj.rflett.com/jjj-api/rest/user/markInboxRead"
+	"jjj.rflett.com/jjj-api/rest/user/updateNotifications"
+	"jjj.rflett.com/jjj-api/rest/user/updateUser"
+	"jjj.rflett.com/jjj-api/rest/votes/createVote"
+	"jjj.rflett.com/jjj-api/rest/votes/deleteVote"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// routes are every REST handler, these should match the http events in serverless.yml
+var routes = []route{
+	// account
+	{method: http.MethodPost, path: "account/signup", handler: signup.Handler},
+	{method: http.MethodPost, path: "account/signin", handler: signin.Handler},
+	{method: http.MethodGet, path: "account/validate-jwt", handler: validateJwt.Handler, authorized: true},
+	{method: http.MethodGet, path: "oauth/{provider}/login", handler: authenticate.Handler},
+	{method: http.MethodGet, path: "oauth/{provider}/redirect", handler: callback.Handler},
+
+	// device
+	{method: http.MethodPost, path: "user/device", handler: registerDevice.Handler, authorized: true},
+	{method: http.MethodDelete, path: "user/device", handler: deregisterDevice.Handler, authorized: true},
+
+	// user
+	{method: http.MethodGet, path: "user/{userId}", handler: getUser.Handler, authorized: true},
+	{method: http.MethodGet, path: "user/{userId}/votes", handler: getUsersVotes.Handler, authorized: true},
+	{method: http.MethodGet, path: "user/{userId}/points", handler: getUserPoints.Handler, authorized: true},
+	{method: http.MethodPut, path: "user", handler: updateUser.Handler, authorized: true},
+	{method: http.MethodGet, path: "user/avatar", handler: getAvatarURL.Handler, authorized: true},
+	{method: http.MethodGet, path: "user/notifications", handler: getNotifications.Handler, authorized: true},
+	{method: http.MethodPut, path: "user/notifications", handler: updateNotifications.Handler, authorized: true},
+	{method: http.MethodGet, path: "user/inbox", handler: getInbox.Handler, authorized: true},
+	{method: http.MethodPost, path: "user/inbox/read", handler: markAllInboxRead.Handler, authorized: true},
+	{method: http.MethodPost, path: "user/inbox/{notificationId}/read", handler: markInboxRead.Handler, authorized: true},
+
+	// group
+	{method: http.MethodPost, path: "group/nominate", handler: updateGroupOwner.Handler, authorized: true},
+	{method: http.MethodPost, path: "group", handler: createGroup.Handler, authorized: true},
+	{method: http.MethodGet, path: "group/{groupId}", handler: getGroup.Handler, authorized: true},
+	{method: http.MethodDelete, path: "group/{groupId}", handler: deleteGroup.Handler, authorized: true},
+	{method: http.MethodGet, path: "group/{groupId}/members", handler: getGroupMembers.Handler, authorized: true},
+	{method: http.MethodGet, path: "group/{groupId}/leaderboard", handler: getGroupLeaderboard.Handler, authorized: true},
+	{method: http.MethodGet, path: "group/{groupId}/history", handler: getGroupHistory.Handler, authorized: true},
+	{method: http.MethodGet, path: "leaderboard", handler: getLeaderboard.Handler, authorized: true},
+	{method: http.MethodPut, path: "group/{groupId}", handler: updateGroup.Handler, authorized: true},
+	{method: http.MethodPost, path: "group/members", handler: joinGroup.Handler, authorized: true},
+	{method: http.MethodDelete, path: "group/{groupId}/members/{userId}", handler: leaveGroup.Handler, authorized: true},
+	{method: http.MethodGet, path: "group/{groupId}/qr", handler: getGroupQR.Handler, authorized: true},
+	{method: http.MethodPost, path: "group/{groupId}/game", handler: createGame.Handler, authorized: true},
+	{method: http.MethodPut, path: "group/{groupId}/game/{gameId}", handler: updateGame.Handler, authorized: true},
+	{method: http.MethodDelete, path: "group/{groupId}/game/{gameId}", handler: deleteGame.Handler, authorized: true},
+	{method: http.MethodGet, path: "group/{groupId}/game", handler: getGames.Handler, authorized: true},
+
+	// votes
+	{method: http.MethodPost, path: "user/vote", handler: createVote.Handler, authorized: true},
+	{method: http.MethodDelete, path: "user/vote/{songId}", handler: deleteVote.Handler, authorized: true},
+
+	// songs
+	{method: http.MethodGet, path: "search", handler: songSearch.Handler, authorized: true},
+	{method: http.MethodGet, path: "songs/played", handler: getPlayedSongs.Handler, authorized: true},
+	{method: http.MethodDelete, path: "songs/purge", handler: purgeSongs.Handler, authorized: true},
+	{method: http.MethodPost, path: "songs/{songId}/merge", handler: mergeSongs.Handler, authorized: true},
+	{method: http.MethodGet, path: "songs/review", handler: getPlayReviews.Handler, authorized: true},
+	{method: http.MethodPost, path: "songs/review/{reviewId}", handler: resolvePlayReview.Handler, authorized: true},
+
+	// countdowns
+	{method: http.MethodPost, path: "countdown", handler: createCountdown.Handler, authorized: true},
+	{method: http.MethodGet, path: "countdown", handler: getCountdowns.Handler, authorized: true},
+	{method: http.MethodPut, path: "countdown/{countdownId}/current", handler: setCurrentCountdown.Handler, authorized: true},
+	{method: http.MethodPut, path: "countdown/{countdownId}/state", handler: transitionCountdown.Handler, authorized: true},
+	{method: http.MethodPut, path: "countdown/{countdownId}/schedule", handler: scheduleCountdown.Handler, authorized: true},
+	{method: http.MethodPut, path: "countdown/{countdownId}/plays/{songId}", handler: correctPlayPosition.Handler, authorized: true},
+	{method: http.MethodPut, path: "countdown/{countdownId}/scoring", handler: setCountdownScoring.Handler, authorized: true},
+}
+
+// verifyKeyFromSigningKey returns the base64 encoded public key for the JWTSigningKey, like JWT_VERIFY_KEY
+func verifyKeyFromSigningKey(signingKey string) (string, error) {
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(signingKey))
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	public := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	return base64.StdEncoding.EncodeToString(public), nil
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	memory := flag.Bool("memory", false, "use in-memory storage seeded with the test user instead of DynamoDB")
+	flag.Parse()
+
+	if *memory {
+		types.UseTestStorage()
+		logger.Log.Info().Str("userID", types.TestAuthProviderUserID).Msg("Using in-memory storage with the test user")
+	}
+
+	// sign in locally without secretsmanager by verifying tokens with the signing key
+	if config.Values.JWTVerifyKey == "" && config.Values.JWTSigningKey != "" {
+		verifyKey, err := verifyKeyFromSigningKey(config.Values.JWTSigningKey)
+		if err != nil {
+			logger.Log.Fatal().Err(err).Msg("Unable to derive the verify key from JWT_SIGNING_KEY")
+		}
+		config.Values.JWTVerifyKey = verifyKey
+	}
+	config.Require("JWT_VERIFY_KEY")
+
+	logger.Log.Info().Str("addr", *addr).Int("routes", len(routes)).Msg("Serving the REST handlers")
+	if err := http.ListenAndServe(*addr, &router{routes: routes}); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Server stopped")
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/services"
+	"net/http"
+	"strings"
+)
+
+// lambdaHandler is the signature of every REST Handler
+type lambdaHandler func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// route is an API Gateway resource, the same as an http event in serverless.yml
+type route struct {
+	method     string
+	path       string // path is the resource path, path parameters are wrapped in braces e.g. group/{groupId}
+	handler    lambdaHandler
+	authorized bool // authorized routes have the authorizer in front of them
+}
+
+// router serves lambda handlers over http the same way API Gateway does
+type router struct {
+	routes []route
+}
+
+// match finds the route for the method and path, preferring routes with the most static segments like API Gateway
+func (rt *router) match(method string, path string) (*route, map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	pathFound := false
+
+	var best *route
+	var bestParams map[string]string
+	bestStatic := -1
+
+	for i := range rt.routes {
+		r := &rt.routes[i]
+		params, static, ok := matchPath(r.path, segments)
+		if !ok {
+			continue
+		}
+		pathFound = true
+		if r.method == method && static > bestStatic {
+			best, bestParams, bestStatic = r, params, static
+		}
+	}
+	return best, bestParams, pathFound
+}
+
+// matchPath checks if the segments match the resource path and returns the path parameters and number of static segments
+func matchPath(path string, segments []string) (map[string]string, int, bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) != len(segments) {
+		return nil, 0, false
+	}
+
+	params := map[string]string{}
+	static := 0
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, 0, false
+			}
+			params[strings.Trim(part, "{}")] = segments[i]
+			continue
+		}
+		if part != segments[i] {
+			return nil, 0, false
+		}
+		static++
+	}
+	return params, static, true
+}
+
+func (rt *router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rte, params, pathFound := rt.match(r.Method, r.URL.Path)
+	if rte == nil {
+		status := http.StatusNotFound
+		if pathFound {
+			status = http.StatusMethodNotAllowed
+		}
+		writeResponse(w, gatewayError(status))
+		return
+	}
+
+	request, err := toRequest(r, rte, params)
+	if err != nil {
+		writeResponse(w, gatewayError(http.StatusBadRequest))
+		return
+	}
+
+	handler := rte.handler
+	if rte.authorized {
+		handler = withAuthorizer(handler)
+	}
+
+	response, err := handler(request)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("path", r.URL.Path).Msg("Handler returned an error")
+		writeResponse(w, gatewayError(http.StatusBadGateway))
+		return
+	}
+
+	logger.Log.Info().Str("method", r.Method).Str("path", r.URL.Path).Int("status", response.StatusCode).Msg("Served request")
+	writeResponse(w, response)
+}
+
+// withAuthorizer runs the authorizer before the handler and fills the RequestContext.Authorizer from the token claims
+func withAuthorizer(next lambdaHandler) lambdaHandler {
+	return func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		header := request.Headers["Authorization"]
+		if header == "" {
+			return gatewayError(http.StatusUnauthorized), nil
+		}
+
+		claims, err := services.ParseToken(strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
+			return gatewayError(http.StatusUnauthorized), nil
+		}
+
+		request.RequestContext.Authorizer = claims.AuthorizerContext()
+		return next(request)
+	}
+}
+
+// toRequest converts the http request to the request API Gateway would send to the lambda
+func toRequest(r *http.Request, rte *route, params map[string]string) (events.APIGatewayProxyRequest, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	headers := map[string]string{}
+	for k, v := range r.Header {
+		headers[k] = strings.Join(v, ",")
+	}
+
+	var query map[string]string
+	multiQuery := map[string][]string(r.URL.Query())
+	if len(multiQuery) > 0 {
+		query = map[string]string{}
+		for k, v := range multiQuery {
+			query[k] = v[len(v)-1]
+		}
+	} else {
+		multiQuery = nil
+	}
+
+	if len(params) == 0 {
+		params = nil
+	}
+
+	resource := "/" + rte.path
+	return events.APIGatewayProxyRequest{
+		Resource:                        resource,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               r.Header,
+		QueryStringParameters:           query,
+		MultiValueQueryStringParameters: multiQuery,
+		PathParameters:                  params,
+		Body:                            string(body),
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:    uuid.NewString(),
+			Stage:        "local",
+			ResourcePath: resource,
+			HTTPMethod:   r.Method,
+		},
+	}, nil
+}
+
+// writeResponse writes the lambda's response out like API Gateway would
+func writeResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	for k, v := range response.Headers {
+		w.Header().Set(k, v)
+	}
+	for k, vs := range response.MultiValueHeaders {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err == nil {
+			body = decoded
+		}
+	}
+
+	w.WriteHeader(response.StatusCode)
+	_, _ = w.Write(body)
+}
+
+// gatewayError is an error that API Gateway itself responds with
+func gatewayError(status int) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(map[string]string{"message": http.StatusText(status)})
+	return events.APIGatewayProxyResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	config.Values.JWTVerifyKey, _ = verifyKeyFromSigningKey(config.Values.JWTSigningKey)
+	os.Exit(m.Run())
+}
+
+func testToken(t *testing.T) string {
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	_, err := user.GetByUserID()
+	assert.Nil(t, err)
+
+	token, err := user.CreateToken()
+	assert.Nil(t, err)
+	return token
+}
+
+func TestMatchPrefersStaticSegments(t *testing.T) {
+	rt := router{routes: routes}
+
+	r, params, _ := rt.match(http.MethodGet, "/user/avatar")
+	if assert.NotNil(t, r) {
+		assert.Equal(t, "user/avatar", r.path)
+		assert.Empty(t, params)
+	}
+
+	r, params, _ = rt.match(http.MethodDelete, "/group/abc/members/def")
+	if assert.NotNil(t, r) {
+		assert.Equal(t, "group/{groupId}/members/{userId}", r.path)
+		assert.Equal(t, map[string]string{"groupId": "abc", "userId": "def"}, params)
+	}
+
+	r, _, pathFound := rt.match(http.MethodPatch, "/group/abc")
+	assert.Nil(t, r)
+	assert.True(t, pathFound)
+}
+
+func TestServeUnauthorized(t *testing.T) {
+	server := httptest.NewServer(&router{routes: routes})
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/group/" + types.TestAuthProviderGroupID)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestServeAuthorized(t *testing.T) {
+	server := httptest.NewServer(&router{routes: routes})
+	defer server.Close()
+
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/group/"+types.TestAuthProviderGroupID, nil)
+	request.Header.Set("Authorization", "Bearer "+testToken(t))
+
+	response, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+
+	if assert.Equal(t, http.StatusOK, response.StatusCode) {
+		group := types.Group{}
+		assert.Nil(t, json.NewDecoder(response.Body).Decode(&group))
+		assert.Equal(t, types.TestAuthProviderGroupID, group.GroupID)
+	}
+}
+
+func TestWithAuthorizerFillsContext(t *testing.T) {
+	var authorizer map[string]interface{}
+	handler := withAuthorizer(func(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		authorizer = request.RequestContext.Authorizer
+		return events.APIGatewayProxyResponse{StatusCode: http.StatusNoContent}, nil
+	})
+
+	response, err := handler(events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Authorization": "Bearer " + testToken(t)},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+	assert.Equal(t, types.TestAuthProviderUserID, authorizer["UserID"])
+	assert.Equal(t, types.TestAuthProvider, authorizer["AuthProvider"])
+}
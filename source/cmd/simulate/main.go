@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/nowplaying"
+	"jjj.rflett.com/jjj-api/simulator"
+	"jjj.rflett.com/jjj-api/types"
+	"os"
+)
+
+func main() {
+	recording := flag.String("recording", "", "a JSONL file or directory of recorded JJJ now playing responses")
+	votes := flag.String("votes", "", "a JSON file of userIDs and the songs they voted for")
+	out := flag.String("out", "", "write the report to this file instead of stdout")
+	memory := flag.Bool("memory", true, "use in-memory storage seeded with the test user instead of DynamoDB")
+	flag.Parse()
+
+	if *recording == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *memory {
+		types.UseTestStorage()
+	}
+
+	responses, err := nowplaying.Load(*recording)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Str("recording", *recording).Msg("Unable to load the recording")
+	}
+
+	if *votes != "" {
+		v, err := simulator.LoadVotes(*votes)
+		if err != nil {
+			logger.Log.Fatal().Err(err).Str("votes", *votes).Msg("Unable to load the votes")
+		}
+		if err = simulator.SeedVotes(v); err != nil {
+			logger.Log.Fatal().Err(err).Msg("Unable to add the votes")
+		}
+	}
+
+	report, err := simulator.Replay(context.Background(), responses)
+	if err != nil {
+		logger.Log.Fatal().Err(err).Msg("Unable to replay the recording")
+	}
+
+	data, _ := json.MarshalIndent(report, "", "  ")
+	if *out == "" {
+		_, _ = os.Stdout.Write(append(data, '\n'))
+		return
+	}
+	if err = ioutil.WriteFile(*out, data, 0644); err != nil {
+		logger.Log.Fatal().Err(err).Str("out", *out).Msg("Unable to write the report")
+	}
+}
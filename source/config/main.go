@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Endpoints override the default AWS service endpoints, e.g. to point at DynamoDB Local. Empty means the default.
+type Endpoints struct {
+	DynamoDB       string `env:"DYNAMODB_ENDPOINT"`
+	SQS            string `env:"SQS_ENDPOINT"`
+	SNS            string `env:"SNS_ENDPOINT"`
+	S3             string `env:"S3_ENDPOINT"`
+	SecretsManager string `env:"SECRETSMANAGER_ENDPOINT"`
+	APNs           string `env:"APNS_ENDPOINT"`
+	FCM            string `env:"FCM_ENDPOINT"`
+}
+
+// Config is the configuration for every lambda, it's loaded from the environment at startup
+type Config struct {
+	AppEnv    string `env:"APP_ENV"`
+	Region    string `env:"AWS_REGION"`
+	Endpoints Endpoints
+
+	// storage
+	DynamoTable  string `env:"JAYPI_TABLE"`
+	AssetsBucket string `env:"ASSETS_BUCKET"`
+	AssetsDomain string `env:"ASSETS_DOMAIN"`
+
+	// auth
+	JWTSigningSecret  string `env:"JWT_SIGNING_SECRET"`
+	JWTSigningKey     string `env:"JWT_SIGNING_KEY"` // a PEM private key that's used instead of the JWTSigningSecret if set
+	JWTVerifyKey      string `env:"JWT_VERIFY_KEY"`
+	OauthCallbackHost string `env:"OAUTH_CALLBACK_HOST"`
+
+	// queues
+	RefreshQueue string `env:"REFRESH_QUEUE"`
+	CounterQueue string `env:"COUNTER_QUEUE"`
+	ScorerQueue  string `env:"SCORER_QUEUE"`
+	CrierQueue   string `env:"CRIER_QUEUE"`
+
+	// now playing
+	NowPlayingSource  string `env:"NOW_PLAYING_SOURCE"`  // abc or file
+	NowPlayingService string `env:"NOW_PLAYING_SERVICE"` // the ABC station e.g. triplej, doublej or unearthed
+	NowPlayingFile    string `env:"NOW_PLAYING_FILE"`    // the JSONL file or directory of responses for the file source
+	NowPlayingTZ      string `env:"NOW_PLAYING_TZ"`
+	MatchThreshold    string `env:"MATCH_THRESHOLD"` // how confident a Spotify match needs to be, from 0 to 1
+
+	// notifications
+	GooglePlatformApp string `env:"GOOGLE_PLATFORM_APP"`
+	ApplePlatformApp  string `env:"APPLE_PLATFORM_APP"`
+	GroupAlerts       string `env:"GROUP_ALERTS"` // whether members hear when someone in their group has a song played, true or false
+
+	// push
+	PushProvider   string `env:"PUSH_PROVIDER"` // sns, or direct to push to APNs and FCM without SNS
+	APNsTopic      string `env:"APNS_TOPIC"`    // the app's bundle ID
+	APNsKeyID      string `env:"APNS_KEY_ID"`
+	APNsTeamID     string `env:"APNS_TEAM_ID"`
+	APNsKey        string `env:"APNS_KEY"` // the PEM of the .p8 key that signs APNs provider tokens
+	FCMProjectID   string `env:"FCM_PROJECT_ID"`
+	FCMCredentials string `env:"FCM_CREDENTIALS"` // the JSON key of a service account that can send FCM messages
+
+	// third parties
+	SpotifyClientID  string `env:"SPOTIFY_CLIENT_ID"`
+	SpotifySecretID  string `env:"SPOTIFY_SECRET_ID"`
+	GoogleClientID   string `env:"GOOGLE_CLIENT_ID"`
+	GoogleSecretID   string `env:"GOOGLE_SECRET_ID"`
+	FacebookClientID string `env:"FACEBOOK_CLIENT_ID"`
+	FacebookSecretID string `env:"FACEBOOK_SECRET_ID"`
+	GitHubClientID   string `env:"GITHUB_CLIENT_ID"`
+	GitHubSecretID   string `env:"GITHUB_SECRET_ID"`
+}
+
+// Values is the Config loaded from the environment
+var Values *Config
+
+func init() {
+	var err error
+	if Values, err = Load(os.LookupEnv); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+}
+
+// Load builds a Config using lookup to find each value, filling in the defaults for the app environment
+func Load(lookup func(string) (string, bool)) (*Config, error) {
+	c := &Config{}
+	eachField(c, func(name string, field reflect.Value) {
+		if v, ok := lookup(name); ok {
+			field.SetString(strings.TrimSpace(v))
+		}
+	})
+
+	// defaults
+	if c.AppEnv == "" {
+		c.AppEnv = "staging"
+	}
+	if c.Region == "" {
+		c.Region = "ap-southeast-2"
+	}
+	if c.DynamoTable == "" {
+		c.DynamoTable = fmt.Sprintf("jaypi-%s", c.AppEnv)
+	}
+	if c.AssetsBucket == "" {
+		c.AssetsBucket = fmt.Sprintf("jaypi-assets-%s", c.AppEnv)
+	}
+	if c.AssetsDomain == "" {
+		c.AssetsDomain = fmt.Sprintf("assets.%s.jaypi.online", c.AppEnv)
+	}
+	if c.JWTSigningSecret == "" {
+		c.JWTSigningSecret = fmt.Sprintf("jaypi-private-key-%s", c.AppEnv)
+	}
+	if c.NowPlayingSource == "" {
+		c.NowPlayingSource = "abc"
+	}
+	if c.NowPlayingService == "" {
+		c.NowPlayingService = "triplej"
+	}
+	if c.NowPlayingTZ == "" {
+		c.NowPlayingTZ = "Australia/Sydney"
+	}
+	if c.PushProvider == "" {
+		c.PushProvider = "sns"
+	}
+
+	// endpoint overrides must be absolute URLs
+	var invalid []string
+	eachField(&c.Endpoints, func(name string, field reflect.Value) {
+		if field.String() == "" {
+			return
+		}
+		if u, err := url.Parse(field.String()); err != nil || u.Scheme == "" || u.Host == "" {
+			invalid = append(invalid, name)
+		}
+	})
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid endpoint url for %s", strings.Join(invalid, ", "))
+	}
+
+	return c, nil
+}
+
+// Validate returns an error listing any of the required values that aren't set
+func (c *Config) Validate(required ...string) error {
+	values := map[string]string{}
+	eachField(c, func(name string, field reflect.Value) {
+		values[name] = field.String()
+	})
+
+	var missing []string
+	for _, name := range required {
+		v, known := values[name]
+		if !known {
+			return fmt.Errorf("unknown config value %s", name)
+		}
+		if v == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required config %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// PushRequired returns the values the push provider needs, SNS has the endpoints it pushes to but pushing directly
+// needs the APNs key and the FCM service account
+func (c *Config) PushRequired() []string {
+	if c.PushProvider != "direct" {
+		return nil
+	}
+	return []string{"APNS_TOPIC", "APNS_KEY_ID", "APNS_TEAM_ID", "APNS_KEY", "FCM_CREDENTIALS"}
+}
+
+// Require stops the lambda from starting if any of the required values aren't set
+func Require(required ...string) {
+	if err := Values.Validate(required...); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Invalid configuration")
+	}
+}
+
+// eachField calls fn with the env name and value of each tagged string field in v, including nested structs
+func eachField(v interface{}, fn func(name string, field reflect.Value)) {
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Field(i)
+		if field.Kind() == reflect.Struct {
+			eachField(field.Addr().Interface(), fn)
+			continue
+		}
+		if name, ok := rv.Type().Field(i).Tag.Lookup("env"); ok {
+			fn(name, field)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	c, err := Load(lookupFrom(map[string]string{"APP_ENV": "prod"}))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "ap-southeast-2", c.Region)
+	assert.Equal(t, "jaypi-prod", c.DynamoTable)
+	assert.Equal(t, "jaypi-private-key-prod", c.JWTSigningSecret)
+	assert.Equal(t, "jaypi-assets-prod", c.AssetsBucket)
+	assert.Equal(t, "assets.prod.jaypi.online", c.AssetsDomain)
+}
+
+func TestLoadEndpoints(t *testing.T) {
+	c, err := Load(lookupFrom(map[string]string{"DYNAMODB_ENDPOINT": "http://localhost:8000"}))
+	assert.Nil(t, err)
+	assert.Equal(t, "http://localhost:8000", c.Endpoints.DynamoDB)
+
+	_, err = Load(lookupFrom(map[string]string{"SQS_ENDPOINT": "localhost:9324"}))
+	assert.NotNil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	c, _ := Load(lookupFrom(map[string]string{"SCORER_QUEUE": "http://localhost:9324/queue/scorer"}))
+
+	assert.Nil(t, c.Validate("SCORER_QUEUE"))
+	assert.EqualError(t, c.Validate("SCORER_QUEUE", "COUNTER_QUEUE", "REFRESH_QUEUE"), "missing required config COUNTER_QUEUE, REFRESH_QUEUE")
+	assert.NotNil(t, c.Validate("NOT_A_THING"))
+}
+
+func TestPushRequired(t *testing.T) {
+	c, _ := Load(lookupFrom(map[string]string{}))
+	assert.Nil(t, c.Validate(c.PushRequired()...))
+
+	c, _ = Load(lookupFrom(map[string]string{"PUSH_PROVIDER": "direct", "APNS_TOPIC": "online.jaypi.app"}))
+	assert.EqualError(t, c.Validate(c.PushRequired()...), "missing required config APNS_KEY_ID, APNS_TEAM_ID, APNS_KEY, FCM_CREDENTIALS")
+}
@@ -5,6 +5,7 @@ go 1.14
 require (
 	github.com/aws/aws-lambda-go v1.27.0
 	github.com/aws/aws-sdk-go v1.40.49
+	github.com/aws/aws-sdk-go-v2 v1.9.2
 	github.com/aws/aws-sdk-go-v2/config v1.8.2
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.2.2
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression v1.2.5
@@ -13,6 +14,7 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/secretsmanager v1.7.0
 	github.com/aws/aws-sdk-go-v2/service/sns v1.8.2
 	github.com/aws/aws-sdk-go-v2/service/sqs v1.9.2
+	github.com/aws/smithy-go v1.8.0
 	github.com/dchest/uniuri v0.0.0-20200228104902-7aecb25e1fe5
 	github.com/getsentry/sentry-go v0.11.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
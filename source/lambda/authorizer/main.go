@@ -15,13 +15,10 @@ package main
 
 import (
 	"context"
-	"encoding/base64"
-	"errors"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/golang-jwt/jwt"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/logger"
-	"jjj.rflett.com/jjj-api/types"
-	"os"
+	"jjj.rflett.com/jjj-api/services"
 	"strings"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -34,22 +31,11 @@ func handleRequest(ctx context.Context, event events.APIGatewayCustomAuthorizerR
 	// get the token from the authorization header
 	token := strings.TrimPrefix(event.AuthorizationToken, "Bearer ")
 
-	// decode the verification public key
-	verifyKey, _ := base64.StdEncoding.DecodeString(os.Getenv("JWT_VERIFY_KEY"))
-
 	// validate and parse the token with our custom claims and key
-	parsedToken, err := jwt.ParseWithClaims(token, &types.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwt.ParseRSAPublicKeyFromPEM(verifyKey)
-	})
+	claims, err := services.ParseToken(token)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to parse JWT with claims")
 		return events.APIGatewayCustomAuthorizerResponse{}, err
 	}
-	if !parsedToken.Valid {
-		logger.Log.Info().Msg("JWT token is not valid")
-		return events.APIGatewayCustomAuthorizerResponse{}, errors.New("Unauthorized")
-	}
-	claims := parsedToken.Claims.(*types.UserClaims)
 	principalID := claims.Subject
 	logger.Log.Info().Str("userID", principalID).Msg("Successfully parsed and validated token for user")
 
@@ -78,17 +64,13 @@ func handleRequest(ctx context.Context, event events.APIGatewayCustomAuthorizerR
 	// new! -- add additional key-value pairs associated with the authenticated principal
 	// these are made available by APIGW like so: $context.authorizer.<key>
 	// additional context is cached
-	resp.Context = map[string]interface{}{
-		"AuthProvider":   claims.AuthProvider,
-		"AuthProviderId": claims.AuthProviderId,
-		"Name":           claims.Name,
-		"UserID":         claims.Subject,
-	}
+	resp.Context = claims.AuthorizerContext()
 
 	return resp.APIGatewayCustomAuthorizerResponse, nil
 }
 
 func main() {
+	config.Require("JWT_VERIFY_KEY")
 	lambda.Start(handleRequest)
 }
 
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	beanCounter "jjj.rflett.com/jjj-api/lambda/bean-counter"
+	"strconv"
+)
+
+func main() {
+	config.Require("SCORER_QUEUE")
+	// the crier only hears from the bean-counter when group alerts are on
+	if on, _ := strconv.ParseBool(config.Values.GroupAlerts); on {
+		config.Require("CRIER_QUEUE")
+	}
+	lambda.Start(beanCounter.HandleRequest)
+}
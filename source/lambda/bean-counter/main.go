@@ -1,123 +1,25 @@
-package main
+package beanCounter
 
 import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
-	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/dchest/uniuri"
-	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/queue"
 	"jjj.rflett.com/jjj-api/types"
-	"os"
+	"strconv"
 )
 
-const MessageBatch = 10
-
-var scorerQueue = os.Getenv("SCORER_QUEUE")
-
-// queueForScorer takes a slice of userIDs and the score to give them and batches them onto SQS
-func queueForScorer(points *int, userIDs []string) error {
-	voterCount := len(userIDs)
-
-	// loop through the userIDs and send them to SQS in batches of messageBatch
-	for i := 0; i < voterCount; i += MessageBatch {
-		j := i + MessageBatch
-		if j > voterCount {
-			j = voterCount
-		}
-
-		// create the batch of messageBatch entries
-		var entries []sqsTypes.SendMessageBatchRequestEntry
-		for _, userID := range userIDs[i:j] {
-			messageBody, _ := json.Marshal(types.ScoreTakerBody{UserID: userID, Points: *points})
-			entry := sqsTypes.SendMessageBatchRequestEntry{
-				Id:          aws.String(uniuri.NewLen(6)),
-				MessageBody: aws.String(string(messageBody)),
-			}
-			entries = append(entries, entry)
-		}
-
-		// send the batch to SQS
-		input := &sqs.SendMessageBatchInput{
-			QueueUrl: &scorerQueue,
-			Entries:  entries,
-		}
-		sendOutput, sendErr := clients.SQSClient.SendMessageBatch(context.TODO(), input)
-		if sendErr != nil {
-			logger.Log.Error().Err(sendErr).Msg("Unable to send message batch to SQS")
-			return sendErr
-		}
-
-		// check send results
-		logger.Log.Info().Msg(fmt.Sprintf("Successfully put %d messages on the queue", len(sendOutput.Successful)))
-
-		if len(sendOutput.Failed) > 0 {
-			logger.Log.Warn().Msg(fmt.Sprintf("Failed to put %d messages on the queue", len(sendOutput.Failed)))
-			for _, failedMessage := range sendOutput.Failed {
-				logger.Log.Warn().Str("id", *failedMessage.Id).Msg(*failedMessage.Message)
-			}
-		}
+// queueForScorer batches the awards onto the scorer queue
+func queueForScorer(awards []types.Award) error {
+	bodies := make([]interface{}, 0, len(awards))
+	for i := range awards {
+		a := awards[i]
+		bodies = append(bodies, types.ScoreTakerBody{CountdownID: a.CountdownID, UserID: a.UserID, Points: a.Points, Award: &a})
 	}
-	return nil
-}
-
-// getVoters returns the IDs of users who voted for a particular song
-func getVoters(songID string) (voters []string, err error) {
-	pkCondition := expression.Key(types.PartitionKey).BeginsWith(fmt.Sprintf("%s#", types.UserPartitionKey))
-	skCondition := expression.Key(types.SortKey).Equal(expression.Value(fmt.Sprintf("%s#%s", types.SongPartitionKey, songID)))
-	keyCondition := expression.KeyAnd(skCondition, pkCondition)
-
-	projExpr := expression.NamesList(expression.Name("UserID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for getVoters func")
-	}
-
-	// input
-	input := &dynamodb.QueryInput{
-		TableName:                 &types.DynamoTable,
-		IndexName:                 aws.String(types.GSI),
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
-	paginator := dynamodb.NewQueryPaginator(clients.DynamoClient, input)
-
-	for paginator.HasMorePages() {
-		page, pageErr := paginator.NextPage(context.TODO())
-		if pageErr != nil {
-			logger.Log.Error().Err(pageErr).Msg("error getting NextPage from getVoters paginator")
-			break
-		}
-
-		var theseVoters []types.User
-		marshalErr := attributevalue.UnmarshalListOfMaps(page.Items, &theseVoters)
-		if marshalErr != nil {
-			logger.Log.Error().Err(marshalErr).Msg("error unmarshalling voters to slice of users")
-			break
-		}
-
-		for _, voter := range theseVoters {
-			if voter.UserID != "" {
-				voters = append(voters, voter.UserID)
-			}
-		}
-	}
-
-	return voters, nil
+	return queue.Scorer.SendBatch(bodies)
 }
 
 func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
@@ -136,34 +38,73 @@ func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 		logger.Log.Error().Err(getSongErr).Str("songID", mb.SongID).Msg("Unable to get the song from the table")
 		return getSongErr
 	}
+	if getPlayErr := s.GetPlay(mb.CountdownID); getPlayErr != nil {
+		return getPlayErr
+	}
 
 	// calculate points for users who voted for this song
+	if s.PlayOrder == nil {
+		playOrderMissingErr := errors.New("playOrder is nil")
+		logger.Log.Error().Err(playOrderMissingErr).Str("songID", mb.SongID).Msg("Song hasn't been played yet")
+		return playOrderMissingErr
+	}
 	if s.PlayedPosition == nil {
-		playPosMissingErr := errors.New("playedPosition is nil")
-		logger.Log.Error().Err(playPosMissingErr).Str("songID", mb.SongID).Msg("Song hasn't been played yet")
-		return playPosMissingErr
+		logger.Log.Info().Str("songID", mb.SongID).Int("playOrder", *s.PlayOrder).Msg("Song was played outside of the countdown")
+		return nil
+	}
+	countdown := types.Countdown{CountdownID: mb.CountdownID}
+	if _, getCountdownErr := countdown.Get(); getCountdownErr != nil {
+		logger.Log.Error().Err(getCountdownErr).Str("countdownID", mb.CountdownID).Msg("Unable to get the countdown")
+		return getCountdownErr
 	}
-	points := s.PlayedPosition
 
-	// find the voters of this song
-	voters, getVotersErr := getVoters(s.SongID)
-	if getVotersErr != nil {
-		logger.Log.Error().Err(getVotersErr).Str("songID", mb.SongID).Msg("Unable to get voters for the song")
-		return getVotersErr
+	// score the song for each of its voters with the countdown's rules
+	awards, awardsErr := countdown.Awards(&s)
+	if awardsErr != nil {
+		logger.Log.Error().Err(awardsErr).Str("songID", mb.SongID).Msg("Unable to score the song for its voters")
+		return awardsErr
 	}
-	if len(voters) == 0 {
+	if len(awards) == 0 {
 		logger.Log.Info().Str("songID", mb.SongID).Msg("No-one voted for this song")
 		return nil
 	}
 
 	// queue the voters and their points for the scorer function to process
-	queueErr := queueForScorer(points, voters)
+	queueErr := queueForScorer(awards)
 	if queueErr != nil {
-		logger.Log.Error().Err(getVotersErr).Str("songID", mb.SongID).Msg("Unable to queue voters for scoring")
+		logger.Log.Error().Err(queueErr).Str("songID", mb.SongID).Msg("Unable to queue voters for scoring")
+		return queueErr
+	}
+
+	// the awards are already queued so nothing after them is retried, retrying would score and alert everyone again
+	snapshotGroups(&countdown, &s, awards)
+	alertGroups(&s, awards)
+	return nil
+}
+
+// snapshotGroups snapshots the standings of the voters' groups after the play. A snapshot that fails is skipped, the
+// group's next one has the points from every play before it.
+func snapshotGroups(c *types.Countdown, s *types.Song, awards []types.Award) {
+	if err := c.SnapshotGroups(s, awards); err != nil {
+		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Unable to snapshot the groups after the play")
 	}
-	return queueErr
 }
 
-func main() {
-	lambda.Start(HandleRequest)
+// alertGroups queues a message for everyone in the voters' groups when group alerts are on, the voters are told by the
+// scorer instead. The awards are already queued so it isn't retried if it fails.
+func alertGroups(s *types.Song, awards []types.Award) {
+	if on, _ := strconv.ParseBool(config.Values.GroupAlerts); !on {
+		return
+	}
+	alerts, err := types.GroupAlerts(s, awards)
+	if err != nil {
+		return
+	}
+	bodies := make([]interface{}, 0, len(alerts))
+	for _, alert := range alerts {
+		bodies = append(bodies, alert)
+	}
+	if err = queue.TownCrier.SendBatch(bodies); err != nil {
+		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Unable to queue the group alerts")
+	}
 }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	chuneMachine "jjj.rflett.com/jjj-api/lambda/chune-machine"
+)
+
+func main() {
+	config.Require("REFRESH_QUEUE", "COUNTER_QUEUE", "SPOTIFY_CLIENT_ID", "SPOTIFY_SECRET_ID")
+	lambda.Start(chuneMachine.HandleRequest)
+}
@@ -1,4 +1,4 @@
-package main
+package chuneMachine
 
 import (
 	"context"
@@ -6,65 +6,61 @@ import (
 	"errors"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/zmb3/spotify"
 	"golang.org/x/oauth2/clientcredentials"
-	"io/ioutil"
-	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/match"
+	"jjj.rflett.com/jjj-api/nowplaying"
+	"jjj.rflett.com/jjj-api/queue"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"jjj.rflett.com/jjj-api/types/jjj"
-	"net/http"
-	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
-const tzLocation = "Australia/Sydney"
-
 var (
-	chuneRefreshQueue = os.Getenv("REFRESH_QUEUE")
-	beanCounterQueue  = os.Getenv("COUNTER_QUEUE")
-	config            = &clientcredentials.Config{
-		ClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
-		ClientSecret: os.Getenv("SPOTIFY_SECRET_ID"),
+	spotifyConfig = &clientcredentials.Config{
+		ClientID:     config.Values.SpotifyClientID,
+		ClientSecret: config.Values.SpotifySecretID,
 		TokenURL:     spotify.TokenURL,
 	}
-	client = spotify.Client{}
+	client     = spotify.Client{}
+	clientOnce sync.Once
+
+	// LookupSong fills in the song's ID and details for the play from Spotify
+	LookupSong = lookupSpotify
+	// errInReview is returned by identify when the play is waiting for an admin to match it
+	errInReview = errors.New("the play is waiting for review")
+	// Clock is used to work out how long to wait before checking JJJ again
+	Clock queue.Clock = queue.RealClock{}
 )
 
-// queueForCounter puts the songID on a queue to trigger the counter lambdas
-func queueForCounter(songID *string) error {
-	// create message body
-	mb, _ := json.Marshal(types.BeanCounterBody{SongID: *songID})
-	input := &sqs.SendMessageInput{
-		DelaySeconds: 0,
-		MessageBody:  aws.String(string(mb)),
-		QueueUrl:     &beanCounterQueue,
-	}
-
-	// send the message to the queue
-	message, err := clients.SQSClient.SendMessage(context.TODO(), input)
-
+// queueForCounter puts the songID on a queue to trigger the counter lambdas for the countdown
+func queueForCounter(countdownID string, songID *string) error {
+	err := queue.BeanCounter.Send(types.BeanCounterBody{CountdownID: countdownID, SongID: *songID}, 0)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("songID", *songID).Msg("Unable to put the song onto the beanCounterQueue")
 		return err
 	}
 
-	logger.Log.Info().Str("songID", *songID).Str("messageID", *message.MessageId).Msg("Successfully put the song onto the beanCounterQueue")
+	logger.Log.Info().Str("songID", *songID).Msg("Successfully put the song onto the beanCounterQueue")
 	return nil
 }
 
 // queueForSelf puts the song information on the queue to re-trigger this lambda
 func queueForSelf(s *types.Song, nextUpdated *time.Time) error {
 	// now
-	location, _ := time.LoadLocation(tzLocation)
-	now := time.Now().In(location).UTC().Unix()
+	now := Clock.Now().Unix()
 
 	// queue delay
-	diff := nextUpdated.Unix() - now
+	var diff int64
+	if nextUpdated != nil {
+		diff = nextUpdated.Unix() - now
+	}
 	var delaySeconds int32
 	if diff <= 0 {
 		// don't set it to 0 or the lambda will trigger over and over rapidly
@@ -73,33 +69,34 @@ func queueForSelf(s *types.Song, nextUpdated *time.Time) error {
 		delaySeconds = int32(diff)
 	}
 
-	// create message body
-	mb, _ := json.Marshal(types.ChuneRefreshBody{SongID: s.SongID})
-	input := &sqs.SendMessageInput{
-		DelaySeconds: delaySeconds,
-		MessageBody:  aws.String(string(mb)),
-		QueueUrl:     &chuneRefreshQueue,
-	}
-
-	// send the message to the queue
-	message, err := clients.SQSClient.SendMessage(context.TODO(), input)
-
+	err := queue.ChuneRefresh.Send(types.ChuneRefreshBody{SongID: s.SongID}, time.Duration(delaySeconds)*time.Second)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Unable to put the song onto the refreshQueue")
 		return err
 	}
 
-	logger.Log.Info().Str("songID", s.SongID).Str("messageID", *message.MessageId).Msg("Successfully put the song onto the refreshQueue")
+	logger.Log.Info().Str("songID", s.SongID).Msg("Successfully put the song onto the refreshQueue")
 	logger.Log.Info().Msg(fmt.Sprintf("See you in %d seconds", delaySeconds))
 	return nil
 }
 
-// lookupSpotify queries Spotify for a song to obtain info like its ID and album art etc
-func lookupSpotify(s *types.Song) error {
+// LowConfidenceError is returned when none of the songs found for a play scored highly enough to be trusted
+type LowConfidenceError struct {
+	Candidates []types.ReviewCandidate
+}
+
+func (e *LowConfidenceError) Error() string {
+	return fmt.Sprintf("no match scored at least %.2f from %d candidates", match.Threshold, len(e.Candidates))
+}
+
+// lookupSpotify queries Spotify for a song to obtain info like its ID and album art etc, the results are scored against
+// the play and a LowConfidenceError is returned if none of them are close enough
+func lookupSpotify(s *types.Song, play *jjj.Play) error {
 	// search spotify for the track
+	clientOnce.Do(newSpotifyClient)
 	logger.Log.Info().Str("track", s.SearchString()).Msg("Searching spotify for track")
 	results, err := client.SearchOpt(s.SearchString(), spotify.SearchTypeTrack, &spotify.Options{
-		Limit: aws.Int(3),
+		Limit: aws.Int(10),
 	})
 	if err != nil {
 		logger.Log.Error().Err(err).Str("track", s.SearchString()).Msg("Unable to search spotify for track")
@@ -107,21 +104,56 @@ func lookupSpotify(s *types.Song) error {
 	}
 
 	// search returned no results
-	if results.Tracks.Total == 0 {
+	if results.Tracks.Total == 0 || len(results.Tracks.Tracks) == 0 {
 		msg := "spotify search returned no results"
 		logger.Log.Warn().Str("track", s.SearchString()).Msg(msg)
-		return errors.New(msg)
+		return &LowConfidenceError{}
+	}
+
+	// score each track against the play
+	tracks := map[string]spotify.FullTrack{}
+	var candidates []match.Candidate
+	for _, track := range results.Tracks.Tracks {
+		c := match.Candidate{
+			ID:       track.SimpleTrack.ID.String(),
+			Title:    track.SimpleTrack.Name,
+			Release:  track.Album.Name,
+			Duration: time.Duration(track.SimpleTrack.Duration) * time.Millisecond,
+		}
+		for _, artist := range track.SimpleTrack.Artists {
+			c.Artists = append(c.Artists, artist.Name)
+		}
+		tracks[c.ID] = track
+		candidates = append(candidates, c)
 	}
 
-	// search returned results, update song with spotify info
-	s.SongID = results.Tracks.Tracks[0].SimpleTrack.ID.String()
-	s.Name = results.Tracks.Tracks[0].SimpleTrack.Name
-	s.Album = results.Tracks.Tracks[0].Album.Name
-	s.Artist = results.Tracks.Tracks[0].Artists[0].Name
+	best, ok := match.Best(match.TargetFromPlay(play), candidates)
+	if !ok {
+		lowConfidence := &LowConfidenceError{}
+		for _, c := range match.Rank(match.TargetFromPlay(play), candidates) {
+			lowConfidence.Candidates = append(lowConfidence.Candidates, types.ReviewCandidate{
+				SongID: c.ID,
+				Name:   c.Title,
+				Artist: strings.Join(c.Artists, ", "),
+				Album:  c.Release,
+				Score:  c.Score,
+			})
+		}
+		logger.Log.Warn().Str("track", s.SearchString()).Float64("score", best.Score).Msg("No spotify track matched the play closely enough")
+		return lowConfidence
+	}
+
+	// update song with spotify info
+	track := tracks[best.ID]
+	s.SongID = track.SimpleTrack.ID.String()
+	s.Name = track.SimpleTrack.Name
+	s.Album = track.Album.Name
+	s.Artist = track.Artists[0].Name
+	s.AddAlias(types.AliasProviderISRC, track.ExternalIDs["isrc"])
 
 	// get the album artwork
 	var artwork []jjj.ArtworkSize
-	for _, art := range results.Tracks.Tracks[0].Album.Images {
+	for _, art := range track.Album.Images {
 		artwork = append(artwork, jjj.ArtworkSize{
 			Url:    art.URL,
 			Width:  art.Width,
@@ -129,63 +161,161 @@ func lookupSpotify(s *types.Song) error {
 		})
 	}
 	s.Artwork = &artwork
-	logger.Log.Info().Str("songID", s.SongID).Msg("Found song on spotify!")
+	logger.Log.Info().Str("songID", s.SongID).Float64("score", best.Score).Msg("Found song on spotify!")
 
 	return nil
 }
 
-// getNowPlaying queries JJJ to see what is getting played right now
-func getNowPlaying() (*types.Song, *time.Time) {
+// getNowPlaying gets what is getting played right now and when JJJ will next update
+func getNowPlaying() (*jjj.ResponseBody, *time.Time) {
 	logger.Log.Info().Msg("Checking JJJ for what's playing")
 
-	// query JJJ
-	nowPlaying, err := http.Get(
-		fmt.Sprintf("https://music.abcradio.net.au/api/v1/plays/triplej/now.json?tz=%s", tzLocation),
-	)
+	response, err := nowplaying.Current.NowPlaying()
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("Couldn't get latest song")
 		return nil, nil
 	}
 
-	defer nowPlaying.Body.Close()
-
-	// unmarshal response
-	response := jjj.ResponseBody{}
-	bodyBytes, _ := ioutil.ReadAll(nowPlaying.Body)
-	err = json.Unmarshal(bodyBytes, &response)
+	// parse the nextUpdated time
+	nextUpdated, err := time.Parse(time.RFC3339, response.NextUpdated)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to unmarshal JJJ response to jjjResponseBody")
-		return nil, nil
+		return response, nil
 	}
+	return response, &nextUpdated
+}
 
-	// parse the nextUpdated time
-	nextUpdated, _ := time.Parse(time.RFC3339, response.NextUpdated)
-
+// songFromPlay converts the JJJ play to a song, it returns nil when nothing is playing or the play is missing its details
+func songFromPlay(play *jjj.Play) *types.Song {
 	// the arid is an empty string when nothing is playing
-	if response.Now.Arid == "" {
-		logger.Log.Info().Msg("Nothing is currently playing")
-		return nil, &nextUpdated
+	if play == nil || play.Arid == "" {
+		return nil
 	}
 
-	title := response.Now.Recording.Title
-	logger.Log.Info().Str("song", title).Msg("There is a song currently playing")
+	title := play.Recording.Title
+	artist := play.ArtistName()
+	if title == "" || artist == "" {
+		logger.Log.Warn().Str("arid", play.Arid).Str("song", title).Str("artist", artist).Msg("Play is missing its title or artist")
+		return nil
+	}
 
 	// get when the song was played
 	var playedAt string
-	playedTime, timeParseErr := time.Parse(time.RFC3339, response.Now.PlayedTime)
+	playedTime, timeParseErr := time.Parse(time.RFC3339, play.PlayedTime)
 	if timeParseErr != nil {
 		logger.Log.Warn().Msg("Unable to parse JJJ PlayedTime to RFC3339, using current time instead")
-		playedAt = time.Now().UTC().Format(time.RFC3339)
+		playedAt = Clock.Now().UTC().Format(time.RFC3339)
 	} else {
 		playedAt = playedTime.Format(time.RFC3339)
 	}
 
-	return &types.Song{
+	song := &types.Song{
 		Name:     title,
-		Album:    response.Now.Release.Title,
-		Artist:   response.Now.Release.Artists[0].Name,
+		Album:    play.ReleaseTitle(),
+		Artist:   artist,
 		PlayedAt: &playedAt,
-	}, &nextUpdated
+	}
+	song.AddAlias(types.AliasProviderArid, play.Recording.Arid)
+	return song
+}
+
+// identify finds the canonical song for a play, by its recording if it's been played before or by searching Spotify.
+// Plays that can't be matched confidently are added to the review list instead.
+func identify(countdownID string, s *types.Song, play *jjj.Play) error {
+	for _, a := range s.Aliases {
+		if a.Provider != types.AliasProviderArid {
+			continue
+		}
+		songID, err := types.ResolveSongID(a.Provider, a.AliasID)
+		if err == nil && songID != "" {
+			s.SongID = songID
+			return nil
+		}
+	}
+
+	// don't search again for a play that's waiting for review
+	inReview, err := types.InReview(countdownID, play)
+	if err != nil {
+		return err
+	}
+	if inReview {
+		return errInReview
+	}
+
+	err = LookupSong(s, play)
+	var lowConfidence *LowConfidenceError
+	if errors.As(err, &lowConfidence) {
+		if reviewErr := types.NewPlayReview(countdownID, play, lowConfidence.Candidates).Create(); reviewErr != nil {
+			return reviewErr
+		}
+		return errInReview
+	}
+	if err != nil {
+		return err
+	}
+	return s.Resolve()
+}
+
+// missedPlays returns the plays since the last song that was played that aren't the song playing now. Prev is checked
+// first, and the recent plays are only fetched when Prev shows something was missed.
+func missedPlays(countdownID string, lastSongID string, response *jjj.ResponseBody) []jjj.Play {
+	if lastSongID == "" || response.Prev == nil {
+		return nil
+	}
+
+	// when the last song was played
+	last := types.Song{SongID: lastSongID}
+	if err := last.GetPlay(countdownID); err != nil || last.PlayedAt == nil {
+		return nil
+	}
+	lastPlayed, err := time.Parse(time.RFC3339, *last.PlayedAt)
+	if err != nil {
+		return nil
+	}
+
+	// nothing was missed if the previous song is the last one we saw
+	plays := nowplaying.PlaysSince([]jjj.Play{*response.Prev}, lastPlayed)
+	if len(plays) == 0 {
+		return nil
+	}
+
+	recent, err := nowplaying.Current.RecentPlays(lastPlayed)
+	if err != nil {
+		logger.Log.Warn().Err(err).Msg("Unable to get the recent plays, only backfilling the previous song")
+	}
+	plays = nowplaying.PlaysSince(append(recent, plays...), lastPlayed)
+	if response.Now == nil {
+		return plays
+	}
+
+	// leave out the song playing now and anything after it, it's handled after the backfill
+	nowPlayed, nowErr := time.Parse(time.RFC3339, response.Now.PlayedTime)
+	var missed []jjj.Play
+	for _, play := range plays {
+		playedTime, _ := time.Parse(time.RFC3339, play.PlayedTime)
+		if play.Arid == response.Now.Arid || (nowErr == nil && !playedTime.Before(nowPlayed)) {
+			continue
+		}
+		missed = append(missed, play)
+	}
+	return missed
+}
+
+// recordPlayed adds the song if it's new, marks it as played in the countdown and queues it for the bean-counter
+func recordPlayed(countdownID string, s *types.Song) {
+	// add the song to the table if it doesn't exist
+	exists, _ := s.Exists()
+	if !exists {
+		_ = s.Create()
+	}
+	_ = s.SaveAliases()
+
+	// get the play count
+	currentPlayCount, _ := services.GetCurrentPlayCount(countdownID)
+
+	// mark the song as played
+	_ = s.Played(countdownID, currentPlayCount)
+
+	// trigger scorer lambda
+	_ = queueForCounter(countdownID, &s.SongID)
 }
 
 func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
@@ -197,41 +327,73 @@ func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 		return err
 	}
 
+	// plays are only credited to the current countdown while it's live
+	countdown, _, err := types.GetCurrentCountdown(Clock.Now())
+	if err != nil {
+		logger.Log.Warn().Err(err).Str("songID", body.SongID).Msg("Putting song back on queue because there's no countdown to credit plays to")
+		return queueForSelf(&types.Song{SongID: body.SongID}, nil)
+	}
+	if countdown.State != types.CountdownLive {
+		logger.Log.Info().Str("countdownID", countdown.CountdownID).Str("state", countdown.State).Msg("Putting song back on queue because the countdown isn't live")
+		notLiveWait := Clock.Now().Add(time.Minute)
+		return queueForSelf(&types.Song{SongID: body.SongID}, &notLiveWait)
+	}
+	countdownID := countdown.CountdownID
+
 	// get what's now playing on JJJ
-	jjjSong, nextUpdated := getNowPlaying()
+	response, nextUpdated := getNowPlaying()
+	if response == nil {
+		logger.Log.Warn().Str("songID", body.SongID).Msg("Putting song back on queue because JJJ couldn't be reached")
+		return queueForSelf(&types.Song{SongID: body.SongID}, nextUpdated)
+	}
+
+	// catch up on any songs that were played since the last one we saw
+	lastSongID := body.SongID
+	for _, play := range missedPlays(countdownID, body.SongID, response) {
+		play := play
+		missed := songFromPlay(&play)
+		if missed == nil {
+			continue
+		}
+		if err = identify(countdownID, missed, &play); err == errInReview {
+			continue
+		} else if err != nil {
+			// stop before anything after it is recorded so it's backfilled again next time
+			logger.Log.Warn().Err(err).Str("arid", play.Arid).Str("songID", lastSongID).Msg("Putting song back on queue because a missed song couldn't be identified")
+			return queueForSelf(&types.Song{SongID: lastSongID}, nextUpdated)
+		}
+		logger.Log.Info().Str("songID", missed.SongID).Msg("Backfilling a song that was missed")
+		recordPlayed(countdownID, missed)
+		lastSongID = missed.SongID
+	}
+
+	jjjSong := songFromPlay(response.Now)
 
 	// if no song is playing just put the song back on the queue with the same ID
 	if jjjSong == nil {
-		logger.Log.Info().Str("songID", body.SongID).Msg("Putting song back on queue with updated delay as no new song is playing yet")
-		return queueForSelf(&types.Song{SongID: body.SongID}, nextUpdated)
+		logger.Log.Info().Str("songID", lastSongID).Msg("Putting song back on queue with updated delay as no new song is playing yet")
+		return queueForSelf(&types.Song{SongID: lastSongID}, nextUpdated)
 	}
 
+	logger.Log.Info().Str("song", jjjSong.Name).Msg("There is a song currently playing")
+
 	// lookup song on Spotify
-	if err = lookupSpotify(jjjSong); err != nil {
-		logger.Log.Warn().Str("songID", body.SongID).Msg("Putting song back on queue because we couldn't search for it on spotify")
-		return queueForSelf(&types.Song{SongID: body.SongID}, nextUpdated)
+	if err = identify(countdownID, jjjSong, response.Now); err == errInReview {
+		// the play has taken its position, so move on as if it was recorded
+		logger.Log.Info().Str("arid", response.Now.Arid).Msg("Play is waiting for review")
+		return queueForSelf(&types.Song{SongID: lastSongID}, nextUpdated)
+	} else if err != nil {
+		logger.Log.Warn().Str("songID", lastSongID).Msg("Putting song back on queue because we couldn't search for it on spotify")
+		return queueForSelf(&types.Song{SongID: lastSongID}, nextUpdated)
 	}
 
 	// if the same song is playing then come back later
-	if jjjSong.SongID == body.SongID {
-		logger.Log.Info().Str("songID", body.SongID).Msg("Putting song back on queue as it is still playing")
-		return queueForSelf(&types.Song{SongID: body.SongID}, nextUpdated)
+	if jjjSong.SongID == lastSongID {
+		logger.Log.Info().Str("songID", lastSongID).Msg("Putting song back on queue as it is still playing")
+		return queueForSelf(&types.Song{SongID: lastSongID}, nextUpdated)
 	}
 
-	// add the song to the table if it doesn't exist
-	exists, _ := jjjSong.Exists()
-	if !exists {
-		_ = jjjSong.Create()
-	}
-
-	// get the play count
-	currentPlayCount, _ := services.GetCurrentPlayCount()
-
-	// mark the song as played
-	_ = jjjSong.Played(currentPlayCount)
-
-	// trigger scorer lambda
-	_ = queueForCounter(&jjjSong.SongID)
+	recordPlayed(countdownID, jjjSong)
 
 	// queueForSelf self trigger
 	_ = queueForSelf(jjjSong, nextUpdated)
@@ -239,11 +401,8 @@ func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 	return nil
 }
 
-func init() {
-	token, _ := config.Token(context.Background())
+// newSpotifyClient authenticates with spotify the first time a song is looked up
+func newSpotifyClient() {
+	token, _ := spotifyConfig.Token(context.Background())
 	client = spotify.Authenticator{}.NewClient(token)
 }
-
-func main() {
-	lambda.Start(HandleRequest)
-}
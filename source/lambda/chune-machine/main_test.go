@@ -0,0 +1,190 @@
+package chuneMachine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/nowplaying"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/types"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubSource serves a single response and recent plays
+type stubSource struct {
+	response jjj.ResponseBody
+	recent   []jjj.Play
+}
+
+func (s *stubSource) NowPlaying() (*jjj.ResponseBody, error) {
+	return &s.response, nil
+}
+
+func (s *stubSource) RecentPlays(since time.Time) ([]jjj.Play, error) {
+	return nowplaying.PlaysSince(s.recent, since), nil
+}
+
+func play(title string, playedTime string) *jjj.Play {
+	return &jjj.Play{
+		Arid:       "arid-" + title,
+		PlayedTime: playedTime,
+		Recording:  jjj.Recording{Title: title, Artists: []jjj.Artist{{Name: "Artist"}}},
+	}
+}
+
+// spotifyDown are the songs that Spotify fails to search for
+var spotifyDown = map[string]bool{}
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	broker := queue.NewMemory(queue.RealClock{})
+	ignore := func(ctx context.Context, event events.SQSEvent) error { return nil }
+	queue.ChuneRefresh = broker.Queue("chune-refresh", ignore)
+	queue.BeanCounter = broker.Queue("bean-counter", ignore)
+
+	// songs are known by their title, unless they're unknown
+	LookupSong = func(s *types.Song, play *jjj.Play) error {
+		if spotifyDown[s.Name] {
+			return errors.New("spotify is down")
+		}
+		if strings.HasPrefix(s.Name, "Unknown") {
+			return &LowConfidenceError{Candidates: []types.ReviewCandidate{{SongID: "close", Score: 0.5}}}
+		}
+		s.SongID = strings.ReplaceAll(s.Name, " ", "")
+		return nil
+	}
+	os.Exit(m.Run())
+}
+
+func TestSongFromPlay(t *testing.T) {
+	p := play("No Release", "2022-01-22T12:00:00+11:00")
+	song := songFromPlay(p)
+	if assert.NotNil(t, song) {
+		assert.Equal(t, "Artist", song.Artist)
+		assert.Equal(t, "", song.Album)
+		assert.Equal(t, "2022-01-22T12:00:00+11:00", *song.PlayedAt)
+	}
+
+	p.Recording.Artists = nil
+	assert.Nil(t, songFromPlay(p))
+	assert.Nil(t, songFromPlay(&jjj.Play{}))
+	assert.Nil(t, songFromPlay(nil))
+}
+
+func TestHandleRequestBackfills(t *testing.T) {
+	playedAt := "2022-01-22T12:00:00+11:00"
+	last := types.Song{SongID: types.TestSongID, PlayedAt: &playedAt}
+	assert.Nil(t, last.Played(types.TestCountdownID, 1))
+
+	nowplaying.Current = &stubSource{
+		response: jjj.ResponseBody{
+			NextUpdated: "2022-01-22T12:09:00+11:00",
+			Prev:        play("Second Missed", "2022-01-22T12:03:00+11:00"),
+			Now:         play("Now Playing", "2022-01-22T12:06:00+11:00"),
+		},
+		recent: []jjj.Play{
+			*play("Old Song", "2022-01-22T11:57:00+11:00"),
+			*play("First Missed", "2022-01-22T12:01:30+11:00"),
+			*play("Second Missed", "2022-01-22T12:03:00+11:00"),
+			*play("Now Playing", "2022-01-22T12:06:00+11:00"),
+		},
+	}
+
+	body, _ := json.Marshal(types.ChuneRefreshBody{SongID: types.TestSongID})
+	err := HandleRequest(context.Background(), events.SQSEvent{Records: []events.SQSMessage{{Body: string(body)}}})
+	assert.Nil(t, err)
+
+	played, err := types.Store.GetPlayedSongIDs(types.TestCountdownID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{types.TestSongID, "FirstMissed", "SecondMissed", "NowPlaying"}, played)
+
+	song := types.Song{SongID: "SecondMissed"}
+	assert.Nil(t, song.GetPlay(types.TestCountdownID))
+	assert.Equal(t, 3, *song.PlayOrder)
+	assert.Equal(t, 98, *song.PlayedPosition)
+}
+
+func TestHandleRequestRetriesFailedBackfill(t *testing.T) {
+	handle := func(songID string) {
+		body, _ := json.Marshal(types.ChuneRefreshBody{SongID: songID})
+		assert.Nil(t, HandleRequest(context.Background(), events.SQSEvent{Records: []events.SQSMessage{{Body: string(body)}}}))
+	}
+	lastPlayed := func(n int) []string {
+		played, err := types.Store.GetPlayedSongIDs(types.TestCountdownID)
+		assert.Nil(t, err)
+		return played[len(played)-n:]
+	}
+
+	nowplaying.Current = &stubSource{response: jjj.ResponseBody{Now: play("Before Flaky", "2022-01-22T14:00:00+11:00")}}
+	handle("")
+	assert.Equal(t, []string{"BeforeFlaky"}, lastPlayed(1))
+
+	nowplaying.Current = &stubSource{
+		response: jjj.ResponseBody{
+			Prev: play("After Flaky", "2022-01-22T14:06:00+11:00"),
+			Now:  play("Still Playing", "2022-01-22T14:09:00+11:00"),
+		},
+		recent: []jjj.Play{
+			*play("Flaky Song", "2022-01-22T14:03:00+11:00"),
+			*play("After Flaky", "2022-01-22T14:06:00+11:00"),
+			*play("Still Playing", "2022-01-22T14:09:00+11:00"),
+		},
+	}
+
+	// nothing after the song Spotify failed on is recorded
+	spotifyDown["Flaky Song"] = true
+	handle("BeforeFlaky")
+	assert.Equal(t, []string{"BeforeFlaky"}, lastPlayed(1))
+
+	// and it's backfilled in order once Spotify is back
+	delete(spotifyDown, "Flaky Song")
+	handle("BeforeFlaky")
+	assert.Equal(t, []string{"BeforeFlaky", "FlakySong", "AfterFlaky", "StillPlaying"}, lastPlayed(4))
+}
+
+func TestIdentifyLowConfidence(t *testing.T) {
+	p := play("Unknown Song", "2022-01-22T12:30:00+11:00")
+	before, _ := types.Store.GetPlayCount(types.TestCountdownID)
+
+	assert.Equal(t, errInReview, identify(types.TestCountdownID, songFromPlay(p), p))
+	assert.Equal(t, errInReview, identify(types.TestCountdownID, songFromPlay(p), p))
+
+	review, err := types.Store.GetPlayReview(types.TestCountdownID, p.Arid)
+	assert.Nil(t, err)
+	if assert.NotNil(t, review) {
+		assert.Equal(t, before, review.PlayOrder)
+		assert.Equal(t, "close", review.Candidates[0].SongID)
+	}
+
+	// the play keeps its position even though it wasn't credited
+	after, _ := types.Store.GetPlayCount(types.TestCountdownID)
+	assert.Equal(t, before+1, after)
+}
+
+func TestHandleRequestWaitsUntilLive(t *testing.T) {
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err := countdown.Get()
+	assert.Nil(t, err)
+	previous := countdown
+	defer func() { _ = types.Store.PutCountdown(&previous) }()
+
+	countdown.State = types.CountdownVotingClosed
+	assert.Nil(t, types.Store.PutCountdown(&countdown))
+	nowplaying.Current = &stubSource{response: jjj.ResponseBody{Now: play("Too Early", "2022-01-22T13:00:00+11:00")}}
+	before, _ := types.Store.GetPlayCount(types.TestCountdownID)
+
+	body, _ := json.Marshal(types.ChuneRefreshBody{SongID: types.TestSongID})
+	assert.Nil(t, HandleRequest(context.Background(), events.SQSEvent{Records: []events.SQSMessage{{Body: string(body)}}}))
+
+	// nothing was credited
+	after, _ := types.Store.GetPlayCount(types.TestCountdownID)
+	assert.Equal(t, before, after)
+	exists, _ := (&types.Song{SongID: "TooEarly"}).Exists()
+	assert.False(t, exists)
+}
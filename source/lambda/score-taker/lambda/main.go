@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	scoreTaker "jjj.rflett.com/jjj-api/lambda/score-taker"
+)
+
+func main() {
+	config.Require("CRIER_QUEUE")
+	lambda.Start(scoreTaker.HandleRequest)
+}
@@ -1,12 +1,13 @@
-package main
+package scoreTaker
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/queue"
 	"jjj.rflett.com/jjj-api/types"
 )
 
@@ -19,15 +20,35 @@ func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 		return jsonErr
 	}
 
-	// append points to users points
-	u := types.User{UserID: mb.UserID}
-	err := u.UpdatePoints(mb.Points)
-	if err == nil {
-		logger.Log.Info().Str("userID", u.UserID).Msg(fmt.Sprintf("Added %d points to user", mb.Points))
+	if mb.Award == nil {
+		awardMissingErr := errors.New("award is nil")
+		logger.Log.Error().Err(awardMissingErr).Str("userID", mb.UserID).Msg("Nothing to score the user with")
+		return awardMissingErr
 	}
-	return err
+
+	// record the award and the user's points together, a redelivered message has already been recorded
+	recorded, err := mb.Award.Record()
+	if err != nil || !recorded {
+		return err
+	}
+	logger.Log.Info().Str("userID", mb.UserID).Msg(fmt.Sprintf("Added %d points to user", mb.Points))
+
+	// tell them their song was played, corrections to it don't tell them again
+	if mb.Award.Revision == 1 {
+		notifyVoter(mb.Award)
+	}
+	return nil
 }
 
-func main() {
-	lambda.Start(HandleRequest)
+// notifyVoter queues the message telling the voter their song was played. The points are already recorded so it isn't
+// retried if it fails.
+func notifyVoter(a *types.Award) {
+	s := types.Song{SongID: a.SongID}
+	if err := s.Get(); err != nil {
+		logger.Log.Error().Err(err).Str("songID", a.SongID).Msg("Unable to get the song to tell its voter")
+		return
+	}
+	if err := queue.TownCrier.Send(types.SongPlayedBody(a, &s), 0); err != nil {
+		logger.Log.Error().Err(err).Str("userID", a.UserID).Msg("Unable to queue the voter's notification")
+	}
 }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	townCrier "jjj.rflett.com/jjj-api/lambda/town-crier"
+)
+
+func main() {
+	config.Require(config.Values.PushRequired()...)
+	lambda.Start(townCrier.HandleRequest)
+}
@@ -1,14 +1,24 @@
-package main
+package townCrier
 
 import (
 	"context"
 	"encoding/json"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/logger"
 	"jjj.rflett.com/jjj-api/types"
+	"strconv"
+	"time"
 )
 
+// sentAt is when the message was put on the queue, it's the same each time it's delivered
+func sentAt(record events.SQSMessage) time.Time {
+	ms, err := strconv.ParseInt(record.Attributes["SentTimestamp"], 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
 func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 	// unmarshall sqsEvent to messageBody
 	mb := types.CrierBody{}
@@ -18,14 +28,35 @@ func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 		return jsonErr
 	}
 
-	// get user
+	// keep it in their inbox even if it isn't pushed
 	user := types.User{UserID: mb.UserID}
+	record := sqsEvent.Records[0]
+	if _, err := user.AddToInbox(mb.Notification, sentAt(record), record.MessageId); err != nil {
+		return err
+	}
+
+	// leave it if the user doesn't want it, or it's their quiet hours
+	preferences, err := user.NotificationPreferences()
+	if err != nil {
+		return err
+	}
+	if !preferences.Allows(&mb.Notification, time.Now()) {
+		logger.Log.Info().Str("userID", mb.UserID).Str("kind", mb.Kind).Msg("Not pushing the notification because of the user's preferences")
+		return nil
+	}
+
+	// get user
 	endpoints, err := user.GetEndpoints()
 	if err != nil {
 		logger.Log.Error().Err(err).Msg("Unable to get user")
 		return err
 	}
 
+	// the badge is everything they haven't read
+	if unread, err := user.UnreadCount(); err == nil {
+		mb.Notification.Badge = &unread
+	}
+
 	// send notifications
 	for _, endpoint := range *endpoints {
 		endpoint.UserID = mb.UserID
@@ -36,7 +67,3 @@ func HandleRequest(ctx context.Context, sqsEvent events.SQSEvent) error {
 
 	return nil
 }
-
-func main() {
-	lambda.Start(HandleRequest)
-}
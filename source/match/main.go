@@ -0,0 +1,233 @@
+package match
+
+import (
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold is the lowest score a candidate can have and still be accepted as a match, it's set by MATCH_THRESHOLD
+var Threshold = 0.8
+
+// how much each part of a candidate counts towards its score
+const (
+	titleWeight    = 0.5
+	artistWeight   = 0.3
+	releaseWeight  = 0.1
+	durationWeight = 0.1
+)
+
+// how close the durations need to be, within durationExact is a perfect score and beyond durationLimit scores nothing
+const (
+	durationExact = 3 * time.Second
+	durationLimit = 30 * time.Second
+)
+
+func init() {
+	if config.Values.MatchThreshold == "" {
+		return
+	}
+	threshold, err := strconv.ParseFloat(config.Values.MatchThreshold, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		logger.Log.Fatal().Str("threshold", config.Values.MatchThreshold).Msg("MATCH_THRESHOLD must be a number between 0 and 1")
+	}
+	Threshold = threshold
+}
+
+// Target is the recording that's being matched
+type Target struct {
+	Title    string
+	Artists  []string
+	Release  string
+	Duration time.Duration
+}
+
+// Candidate is something the Target might be, like a Spotify track
+type Candidate struct {
+	ID       string
+	Title    string
+	Artists  []string
+	Release  string
+	Duration time.Duration
+}
+
+// Scored is a Candidate and how well it matches the Target, from 0 to 1
+type Scored struct {
+	Candidate
+	Score float64
+}
+
+// TargetFromPlay builds the Target for a JJJ play, with every artist credited on the recording and in its title
+func TargetFromPlay(play *jjj.Play) Target {
+	t := Target{
+		Title:    play.Recording.Title,
+		Release:  play.ReleaseTitle(),
+		Duration: time.Duration(play.Recording.Duration) * time.Second,
+	}
+	for _, artist := range play.Recording.Artists {
+		t.Artists = append(t.Artists, artist.Name)
+	}
+	if len(t.Artists) == 0 && play.ArtistName() != "" {
+		t.Artists = []string{play.ArtistName()}
+	}
+	return t
+}
+
+// Score returns how well the candidate matches the target from 0 to 1. The release and duration are only counted
+// when both the target and the candidate have them.
+func Score(target Target, candidate Candidate) float64 {
+	total := titleWeight*similarity(Title(target.Title), Title(candidate.Title)) +
+		artistWeight*artistScore(target, candidate)
+	weights := titleWeight + artistWeight
+
+	if target.Release != "" && candidate.Release != "" {
+		total += releaseWeight * similarity(Title(target.Release), Title(candidate.Release))
+		weights += releaseWeight
+	}
+	if target.Duration > 0 && candidate.Duration > 0 {
+		total += durationWeight * durationScore(target.Duration, candidate.Duration)
+		weights += durationWeight
+	}
+	return total / weights
+}
+
+// Rank scores every candidate, best first
+func Rank(target Target, candidates []Candidate) []Scored {
+	scored := make([]Scored, 0, len(candidates))
+	for _, c := range candidates {
+		scored = append(scored, Scored{Candidate: c, Score: Score(target, c)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].Score > scored[j].Score
+	})
+	return scored
+}
+
+// Best returns the best scoring candidate and whether it scored at least the Threshold
+func Best(target Target, candidates []Candidate) (*Scored, bool) {
+	scored := Rank(target, candidates)
+	if len(scored) == 0 {
+		return nil, false
+	}
+	return &scored[0], scored[0].Score >= Threshold
+}
+
+// artists returns every artist credited on a recording, including those featured in the title
+func artists(credits []string, title string) []string {
+	var all []string
+	for _, credit := range credits {
+		all = append(all, SplitArtists(credit)...)
+	}
+	return append(all, FeaturedArtists(title)...)
+}
+
+// artistScore is how well the artists match, it's the best match for each of the target's artists averaged, so extra
+// artists on the candidate aren't penalised as much as missing ones
+func artistScore(target Target, candidate Candidate) float64 {
+	targetArtists := artists(target.Artists, target.Title)
+	candidateArtists := artists(candidate.Artists, candidate.Title)
+	if len(targetArtists) == 0 || len(candidateArtists) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for _, t := range targetArtists {
+		best := 0.0
+		for _, c := range candidateArtists {
+			if s := similarity(t, c); s > best {
+				best = s
+			}
+		}
+		total += best
+	}
+	return total / float64(len(targetArtists))
+}
+
+// durationScore is 1 when the durations are within durationExact and falls to 0 at durationLimit
+func durationScore(a time.Duration, b time.Duration) float64 {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	switch {
+	case diff <= durationExact:
+		return 1
+	case diff >= durationLimit:
+		return 0
+	default:
+		return 1 - float64(diff-durationExact)/float64(durationLimit-durationExact)
+	}
+}
+
+// similarity compares two normalised strings from 0 to 1, it's the better of the edit distance and the words they
+// share so that both typos and reordered words score well
+func similarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+	edit := 1 - float64(levenshtein([]rune(a), []rune(b)))/float64(max(len([]rune(a)), len([]rune(b))))
+	words := dice(strings.Fields(a), strings.Fields(b))
+	if edit > words {
+		return edit
+	}
+	return words
+}
+
+// dice is the Sørensen–Dice coefficient of the two sets of words
+func dice(a []string, b []string) float64 {
+	inA := map[string]bool{}
+	for _, w := range a {
+		inA[w] = true
+	}
+	inB := map[string]bool{}
+	shared := 0
+	for _, w := range b {
+		if inA[w] && !inB[w] {
+			shared++
+		}
+		inB[w] = true
+	}
+	return 2 * float64(shared) / float64(len(inA)+len(inB))
+}
+
+// levenshtein is the number of single character edits to turn a into b
+func levenshtein(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(min(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
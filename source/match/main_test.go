@@ -0,0 +1,57 @@
+package match
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNormalise(t *testing.T) {
+	assert.Equal(t, "beyonce", Normalise("Beyoncé"))
+	assert.Equal(t, "sigur ros", Normalise("Sigur Rós"))
+	assert.Equal(t, "angus and julia stone", Normalise("Angus & Julia Stone"))
+	assert.Equal(t, "dont stop me now", Normalise("Don't Stop Me Now!"))
+}
+
+func TestTitle(t *testing.T) {
+	assert.Equal(t, "stay", Title("Stay (feat. Justin Bieber)"))
+	assert.Equal(t, "stay", Title("STAY ft. Justin Bieber"))
+	assert.Equal(t, "elephant", Title("Elephant (Radio Edit)"))
+	assert.Equal(t, "heat waves triple j like a version", Title("Heat Waves (triple j Like A Version)"))
+	assert.Equal(t, "with or without you", Title("With Or Without You"))
+	assert.Equal(t, "stay", Title("STAY (with Justin Bieber)"))
+	assert.Equal(t, []string{"justin bieber"}, FeaturedArtists("Stay (feat. Justin Bieber)"))
+	assert.Equal(t, []string{"justin bieber"}, FeaturedArtists("STAY (with Justin Bieber)"))
+}
+
+func TestBest(t *testing.T) {
+	target := Target{
+		Title:    "Stay",
+		Artists:  []string{"The Kid LAROI", "Justin Bieber"},
+		Release:  "F*ck Love 3: Over You",
+		Duration: 141 * time.Second,
+	}
+	candidates := []Candidate{
+		{ID: "karaoke", Title: "Stay (Karaoke Version)", Artists: []string{"Sing2Piano"}, Duration: 140 * time.Second},
+		{ID: "stay", Title: "STAY (with Justin Bieber)", Artists: []string{"The Kid LAROI", "Justin Bieber"}, Release: "F*CK LOVE 3: OVER YOU", Duration: 141 * time.Second},
+		{ID: "rihanna", Title: "Stay", Artists: []string{"Rihanna", "Mikky Ekko"}, Release: "Unapologetic", Duration: 240 * time.Second},
+	}
+
+	best, ok := Best(target, candidates)
+	assert.True(t, ok)
+	assert.Equal(t, "stay", best.ID)
+
+	// only wrong songs
+	best, ok = Best(target, []Candidate{candidates[0], candidates[2]})
+	assert.False(t, ok)
+	assert.Less(t, best.Score, Threshold)
+
+	_, ok = Best(target, nil)
+	assert.False(t, ok)
+}
+
+func TestScoreAccents(t *testing.T) {
+	target := Target{Title: "Hoppípolla", Artists: []string{"Sigur Ros"}}
+	candidate := Candidate{Title: "Hoppípolla", Artists: []string{"Sigur Rós"}, Release: "Takk...", Duration: 268 * time.Second}
+	assert.Equal(t, 1.0, Score(target, candidate))
+}
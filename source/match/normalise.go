@@ -0,0 +1,105 @@
+package match
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// featuring matches a featured artist credit like (with X) or feat. X and everything after it
+	featuring = regexp.MustCompile(`(?i)([\(\[]\s*with\s+|[\(\[]?\s*\b(feat|ft|featuring)\b\.?\s+).*$`)
+	// featuringWord is the start of a featured artist credit
+	featuringWord = regexp.MustCompile(`(?i)^(with|feat|ft|featuring)\b\.?\s*`)
+	// bracketed matches bracketed parts of a title like (Radio Edit) or [Remastered]
+	bracketed = regexp.MustCompile(`[\(\[][^\)\]]*[\)\]]`)
+	// versionWords are the bracketed parts of a title that don't make it a different recording
+	versionWords = regexp.MustCompile(`(?i)\b(radio edit|single version|album version|edit|remaster(ed)?( \d{4})?|explicit|clean|original mix)\b`)
+	// artistSeparators split a combined artist credit into each artist
+	artistSeparators = regexp.MustCompile(`(?i)\s*(,|&|\bx\b|\band\b|\bvs\.?|\bfeat\.?|\bft\.?|\bfeaturing\b)\s*`)
+)
+
+// folds are the letters that don't decompose to an ASCII letter and an accent
+var folds = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ł': "l", 'þ': "th", 'ð': "d", 'ı': "i",
+}
+
+// accents map accented latin letters to the letter without the accent
+var accents = map[string]string{
+	"a": "àáâãäåāăą",
+	"c": "çćĉċč",
+	"e": "èéêëēĕėęě",
+	"g": "ĝğġģ",
+	"i": "ìíîïĩīĭįı",
+	"n": "ñńņňŉ",
+	"o": "òóôõöōŏő",
+	"s": "śŝşš",
+	"t": "ţťŧ",
+	"u": "ùúûüũūŭůűų",
+	"y": "ýÿŷ",
+	"z": "źżž",
+}
+
+func init() {
+	for plain, accented := range accents {
+		for _, r := range accented {
+			folds[r] = plain
+		}
+	}
+}
+
+// Normalise lower cases the string, removes accents and punctuation and collapses the whitespace so that the same
+// title or artist written slightly differently compares as equal
+func Normalise(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case folds[r] != "":
+			b.WriteString(folds[r])
+		case r == '&':
+			b.WriteString(" and ")
+		case r == '\'' || r == '’' || r == '.':
+			// don't split words like don't or a.k.a
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Title normalises a song title without its featured artists or version words like (Radio Edit), but keeps the
+// parts that make it a different recording like (Triple J Like A Version) or (Remix)
+func Title(title string) string {
+	title = featuring.ReplaceAllString(title, "")
+	title = bracketed.ReplaceAllStringFunc(title, func(part string) string {
+		if strings.TrimSpace(versionWords.ReplaceAllString(strings.Trim(part, "()[]"), "")) == "" {
+			return ""
+		}
+		return part
+	})
+	title = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(title), "-"))
+	return Normalise(title)
+}
+
+// FeaturedArtists returns the artists credited in a title with feat. or ft.
+func FeaturedArtists(title string) []string {
+	credit := featuring.FindString(title)
+	if credit == "" {
+		return nil
+	}
+	credit = featuringWord.ReplaceAllString(strings.Trim(credit, " ()[]"), "")
+	return SplitArtists(credit)
+}
+
+// SplitArtists splits a combined artist credit like "Dom Dolla & Mansionair" into each normalised artist
+func SplitArtists(credit string) []string {
+	var artists []string
+	for _, artist := range artistSeparators.Split(credit, -1) {
+		if n := Normalise(artist); n != "" {
+			artists = append(artists, n)
+		}
+	}
+	return artists
+}
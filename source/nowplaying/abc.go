@@ -0,0 +1,100 @@
+package nowplaying
+
+import (
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// abcPlaysURL is the ABC plays API, it's formatted with the service and the timezone
+	abcPlaysURL = "https://music.abcradio.net.au/api/v1/plays/%s/now.json?tz=%s"
+	// abcRecentURL is the ABC plays search, it's formatted with the service, the time to search from and the timezone
+	abcRecentURL = "https://music.abcradio.net.au/api/v1/plays/search.json?station=%s&from=%s&order=asc&limit=20&tz=%s"
+)
+
+// Services are the ABC stations the plays API has now playing for
+var Services = []string{"triplej", "doublej", "unearthed"}
+
+// ABC is a Source that asks the ABC plays API what's playing on one of its stations
+type ABC struct {
+	Service        string
+	Location       string
+	Endpoint       string // Endpoint is the plays API URL, formatted with the service and the timezone
+	RecentEndpoint string // RecentEndpoint is the plays search URL, formatted with the service, from time and timezone
+	Client         *http.Client
+}
+
+// NewABC returns an ABC Source for the service, with its times in the location
+func NewABC(service string, location string) (*ABC, error) {
+	known := false
+	for _, s := range Services {
+		known = known || s == service
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown ABC service %s", service)
+	}
+	if _, err := time.LoadLocation(location); err != nil {
+		return nil, err
+	}
+	return &ABC{
+		Service:        service,
+		Location:       location,
+		Endpoint:       abcPlaysURL,
+		RecentEndpoint: abcRecentURL,
+		Client:         newHTTPClient(),
+	}, nil
+}
+
+// URL is the now playing URL for the service
+func (a *ABC) URL() string {
+	return fmt.Sprintf(a.Endpoint, url.PathEscape(a.Service), url.QueryEscape(a.Location))
+}
+
+// NowPlaying gets what's playing on the service right now, plays from a different service are treated as nothing
+func (a *ABC) NowPlaying() (*jjj.ResponseBody, error) {
+	response := jjj.ResponseBody{}
+	if err := getJSON(a.Client, a.URL(), &response); err != nil {
+		logger.Log.Error().Err(err).Str("service", a.Service).Msg("Couldn't get latest song")
+		return nil, err
+	}
+
+	if !a.ours(response.Now) {
+		logger.Log.Warn().Str("service", a.Service).Str("serviceID", response.Now.ServiceID).Msg("Now playing is for a different service")
+		response.Now = nil
+	}
+	if !a.ours(response.Prev) {
+		response.Prev = nil
+	}
+
+	return &response, nil
+}
+
+// RecentPlays gets the plays on the service since the time, oldest first
+func (a *ABC) RecentPlays(since time.Time) ([]jjj.Play, error) {
+	location, _ := time.LoadLocation(a.Location)
+	from := since.In(location).Format(time.RFC3339)
+	u := fmt.Sprintf(a.RecentEndpoint, url.QueryEscape(a.Service), url.QueryEscape(from), url.QueryEscape(a.Location))
+
+	response := jjj.PlaysResponse{}
+	if err := getJSON(a.Client, u, &response); err != nil {
+		logger.Log.Error().Err(err).Str("service", a.Service).Msg("Couldn't get the recent plays")
+		return nil, err
+	}
+
+	var plays []jjj.Play
+	for i := range response.Items {
+		if a.ours(&response.Items[i]) {
+			plays = append(plays, response.Items[i])
+		}
+	}
+	return PlaysSince(plays, since), nil
+}
+
+// ours is false for plays that are from a different service
+func (a *ABC) ours(play *jjj.Play) bool {
+	return play == nil || play.ServiceID == "" || play.ServiceID == a.Service
+}
@@ -0,0 +1,124 @@
+package nowplaying
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is a Source that serves recorded responses in order, one each time it's asked, and then keeps serving the last
+type File struct {
+	mu        sync.Mutex
+	responses []jjj.ResponseBody
+	served    int
+}
+
+// NewFile returns a File Source for the responses at path, see Load
+func NewFile(path string) (*File, error) {
+	responses, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("%s has no responses", path)
+	}
+	return &File{responses: responses}, nil
+}
+
+// NowPlaying returns the next recorded response
+func (f *File) NowPlaying() (*jjj.ResponseBody, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.responses) == 0 {
+		return nil, errors.New("there are no recorded responses")
+	}
+	if f.served < len(f.responses) {
+		f.served++
+	}
+	response := f.responses[f.served-1]
+	return &response, nil
+}
+
+// RecentPlays returns the prev and now plays of the responses that have been served, that were played since the time
+func (f *File) RecentPlays(since time.Time) ([]jjj.Play, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Plays(f.responses[:f.served], since), nil
+}
+
+// Plays returns the prev and now plays of the responses that were played since the time, see PlaysSince
+func Plays(responses []jjj.ResponseBody, since time.Time) []jjj.Play {
+	var plays []jjj.Play
+	for _, response := range responses {
+		if response.Prev != nil {
+			plays = append(plays, *response.Prev)
+		}
+		if response.Now != nil {
+			plays = append(plays, *response.Now)
+		}
+	}
+	return PlaysSince(plays, since)
+}
+
+// Load reads recorded now playing responses from a JSONL file with a response on each line, or a directory of JSON
+// files with a response in each, in name order
+func Load(path string) ([]jjj.ResponseBody, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []jjj.ResponseBody
+	if info.IsDir() {
+		files, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			response := jjj.ResponseBody{}
+			if err = json.Unmarshal(data, &response); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			responses = append(responses, response)
+		}
+		return responses, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		response := jjj.ResponseBody{}
+		if err = json.Unmarshal(scanner.Bytes(), &response); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		responses = append(responses, response)
+	}
+	return responses, scanner.Err()
+}
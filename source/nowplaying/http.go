@@ -0,0 +1,66 @@
+package nowplaying
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/logger"
+	"net/http"
+	"time"
+)
+
+const (
+	// requestTimeout bounds each request so a slow ABC API can't use up the lambda's timeout
+	requestTimeout = 4 * time.Second
+	// maxAttempts is how many times a request is tried before giving up
+	maxAttempts = 3
+)
+
+// retryBackoff is how long to wait before the second attempt, it doubles for each attempt after that
+var retryBackoff = 500 * time.Millisecond
+
+// newHTTPClient returns a client with the requestTimeout
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
+
+// retryable is true for responses that might succeed if they're tried again
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// getJSON gets the url and unmarshals the response body into v, retrying network errors and server errors
+func getJSON(client *http.Client, url string, v interface{}) error {
+	var err error
+	backoff := retryBackoff
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			logger.Log.Warn().Err(err).Str("url", url).Int("attempt", attempt).Msg("Retrying request")
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+
+		var response *http.Response
+		response, err = client.Get(url)
+		if err != nil {
+			continue
+		}
+
+		body, readErr := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			err = fmt.Errorf("%s returned %d", url, response.StatusCode)
+			if retryable(response.StatusCode) {
+				continue
+			}
+			return err
+		}
+		if readErr != nil {
+			err = readErr
+			continue
+		}
+
+		return json.Unmarshal(body, v)
+	}
+	return err
+}
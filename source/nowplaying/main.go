@@ -0,0 +1,76 @@
+package nowplaying
+
+import (
+	"fmt"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"sort"
+	"time"
+)
+
+// Source is somewhere to find out what's playing on the radio
+type Source interface {
+	// NowPlaying returns the latest now playing response
+	NowPlaying() (*jjj.ResponseBody, error)
+	// RecentPlays returns the plays since the time, oldest first, so plays that were missed can be caught up on
+	RecentPlays(since time.Time) ([]jjj.Play, error)
+}
+
+// Current is the Source the chune-machine checks, it's picked by NOW_PLAYING_SOURCE
+var Current Source
+
+func init() {
+	var err error
+	if Current, err = FromConfig(config.Values); err != nil {
+		logger.Log.Fatal().Err(err).Msg("Invalid now playing source")
+	}
+}
+
+// FromConfig returns the Source that the config asks for
+func FromConfig(c *config.Config) (Source, error) {
+	switch c.NowPlayingSource {
+	case "abc":
+		return NewABC(c.NowPlayingService, c.NowPlayingTZ)
+	case "file":
+		if c.NowPlayingFile == "" {
+			return nil, fmt.Errorf("NOW_PLAYING_FILE is required for the file source")
+		}
+		return NewFile(c.NowPlayingFile)
+	default:
+		return nil, fmt.Errorf("unknown now playing source %s", c.NowPlayingSource)
+	}
+}
+
+// PlaysSince returns the plays that were played after the time, oldest first and without any duplicates. Plays
+// without a valid played_time are left out.
+func PlaysSince(plays []jjj.Play, since time.Time) []jjj.Play {
+	seen := map[string]bool{}
+	var after []jjj.Play
+	var times []time.Time
+	for _, play := range plays {
+		playedTime, err := time.Parse(time.RFC3339, play.PlayedTime)
+		if err != nil || !playedTime.After(since) || seen[play.Arid] {
+			continue
+		}
+		seen[play.Arid] = true
+		after = append(after, play)
+		times = append(times, playedTime)
+	}
+
+	sort.Sort(byPlayedTime{plays: after, times: times})
+	return after
+}
+
+// byPlayedTime sorts plays by their parsed played times
+type byPlayedTime struct {
+	plays []jjj.Play
+	times []time.Time
+}
+
+func (b byPlayedTime) Len() int           { return len(b.plays) }
+func (b byPlayedTime) Less(i, j int) bool { return b.times[i].Before(b.times[j]) }
+func (b byPlayedTime) Swap(i, j int) {
+	b.plays[i], b.plays[j] = b.plays[j], b.plays[i]
+	b.times[i], b.times[j] = b.times[j], b.times[i]
+}
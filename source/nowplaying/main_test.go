@@ -0,0 +1,90 @@
+package nowplaying
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromConfig(t *testing.T) {
+	source, err := FromConfig(&config.Config{NowPlayingSource: "abc", NowPlayingService: "doublej", NowPlayingTZ: "Australia/Sydney"})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://music.abcradio.net.au/api/v1/plays/doublej/now.json?tz=Australia%2FSydney", source.(*ABC).URL())
+
+	_, err = FromConfig(&config.Config{NowPlayingSource: "abc", NowPlayingService: "classic", NowPlayingTZ: "Australia/Sydney"})
+	assert.EqualError(t, err, "unknown ABC service classic")
+
+	_, err = FromConfig(&config.Config{NowPlayingSource: "file"})
+	assert.NotNil(t, err)
+
+	_, err = FromConfig(&config.Config{NowPlayingSource: "radio"})
+	assert.EqualError(t, err, "unknown now playing source radio")
+}
+
+func TestABCIgnoresOtherServices(t *testing.T) {
+	body, _ := ioutil.ReadFile("testdata/plays/001.json")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	doubleJ, _ := NewABC("doublej", "Australia/Sydney")
+	doubleJ.Endpoint = server.URL + "/%s?tz=%s"
+	response, err := doubleJ.NowPlaying()
+	assert.Nil(t, err)
+	if assert.NotNil(t, response.Now) {
+		assert.Equal(t, "First Song", response.Now.Recording.Title)
+	}
+
+	tripleJ, _ := NewABC("triplej", "Australia/Sydney")
+	tripleJ.Endpoint = doubleJ.Endpoint
+	response, err = tripleJ.NowPlaying()
+	assert.Nil(t, err)
+	assert.Nil(t, response.Now)
+}
+
+func TestFile(t *testing.T) {
+	source, err := NewFile("testdata/plays")
+	assert.Nil(t, err)
+
+	for _, title := range []string{"First Song", "Second Song", "Second Song"} {
+		response, err := source.NowPlaying()
+		assert.Nil(t, err)
+		assert.Equal(t, title, response.Now.Recording.Title)
+	}
+
+	_, err = NewFile("testdata/missing.jsonl")
+	assert.NotNil(t, err)
+}
+
+func TestGetJSONRetries(t *testing.T) {
+	retryBackoff = 0
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if attempts == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"total": 1}`))
+	}))
+	defer server.Close()
+
+	response := jjj.PlaysResponse{}
+	assert.Nil(t, getJSON(newHTTPClient(), server.URL, &response))
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 1, response.Total)
+
+	// client errors aren't retried
+	attempts = 0
+	assert.EqualError(t, getJSON(newHTTPClient(), server.URL+"/missing", &response), server.URL+"/missing returned 404")
+	assert.Equal(t, 1, attempts)
+}
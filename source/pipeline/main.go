@@ -0,0 +1,28 @@
+package pipeline
+
+import (
+	beanCounter "jjj.rflett.com/jjj-api/lambda/bean-counter"
+	chuneMachine "jjj.rflett.com/jjj-api/lambda/chune-machine"
+	scoreTaker "jjj.rflett.com/jjj-api/lambda/score-taker"
+	townCrier "jjj.rflett.com/jjj-api/lambda/town-crier"
+	"jjj.rflett.com/jjj-api/queue"
+)
+
+// Queue names on the in-memory broker, they're used as the EventSourceARN of each message
+const (
+	ChuneRefreshQueue = "chune-refresh"
+	BeanCounterQueue  = "bean-counter"
+	ScorerQueue       = "scorer"
+	TownCrierQueue    = "town-crier"
+)
+
+// New wires chune-machine, bean-counter, score-taker and town-crier together on an in-memory broker, replacing the
+// SQS queues so a song being played flows all the way through to the push notifications in this process
+func New(clock queue.Clock) *queue.Memory {
+	m := queue.NewMemory(clock)
+	queue.ChuneRefresh = m.Queue(ChuneRefreshQueue, chuneMachine.HandleRequest)
+	queue.BeanCounter = m.Queue(BeanCounterQueue, beanCounter.HandleRequest)
+	queue.Scorer = m.Queue(ScorerQueue, scoreTaker.HandleRequest)
+	queue.TownCrier = m.Queue(TownCrierQueue, townCrier.HandleRequest)
+	return m
+}
@@ -0,0 +1,126 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/scoring"
+	"jjj.rflett.com/jjj-api/types"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func TestSongPlayedAwardsPoints(t *testing.T) {
+	clock := queue.NewVirtualClock(time.Date(2022, 1, 26, 12, 0, 0, 0, time.UTC))
+	broker := New(clock)
+
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	assert.Nil(t, user.GetPoints(types.TestCountdownID))
+	before := user.Points
+
+	// play the song the test user voted for
+	playedAt := clock.Now().Format(time.RFC3339)
+	song := types.Song{SongID: types.TestSongID, PlayedAt: &playedAt}
+	assert.Nil(t, song.Played(types.TestCountdownID, 7))
+
+	// the bean-counter, the scorer, and the crier telling the test user
+	assert.Nil(t, queue.BeanCounter.Send(types.BeanCounterBody{CountdownID: types.TestCountdownID, SongID: types.TestSongID}, 0))
+	assert.Equal(t, 3, broker.Drain(context.Background()))
+	assert.Equal(t, 0, broker.Pending())
+
+	assert.Nil(t, user.GetPoints(types.TestCountdownID))
+	assert.Equal(t, before+7, user.Points)
+
+	// the award says which rule gave the points
+	award, err := types.Store.GetAward(types.TestCountdownID, types.TestAuthProviderUserID, types.TestSongID)
+	assert.Nil(t, err)
+	if assert.NotNil(t, award) {
+		assert.Equal(t, 94, award.Position)
+		assert.Equal(t, []scoring.Award{{Rule: scoring.Position, Points: 7}}, award.Rules)
+	}
+
+	// and the test user's group has a snapshot of where everyone was after the play
+	group := types.Group{GroupID: types.TestAuthProviderGroupID}
+	snapshot, err := group.StandingsAt(types.TestCountdownID, 7)
+	assert.Nil(t, err)
+	if assert.NotNil(t, snapshot) {
+		assert.Equal(t, types.TestSongID, snapshot.SongID)
+		assert.Equal(t, []types.Standing{{UserID: types.TestAuthProviderUserID, Rank: 1, Points: before + 7, SongsHit: 1}}, snapshot.Standings)
+	}
+
+	// the crier kept it in their inbox when it was sent
+	inbox, err := user.GetInbox("", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, inbox.Unread)
+	if assert.Len(t, inbox.Items, 1) {
+		assert.Equal(t, types.NotificationSongPlayed, inbox.Items[0].Kind)
+		assert.Equal(t, "2022-01-26T12:00:00Z", inbox.Items[0].CreatedAt)
+	}
+
+	// a redelivered message doesn't score the song again, or tell the user again
+	assert.Nil(t, queue.BeanCounter.Send(types.BeanCounterBody{CountdownID: types.TestCountdownID, SongID: types.TestSongID}, 0))
+	assert.Equal(t, 2, broker.Drain(context.Background()))
+	assert.Nil(t, user.GetPoints(types.TestCountdownID))
+	assert.Equal(t, before+7, user.Points)
+	unread, err := user.UnreadCount()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, unread)
+}
+
+func TestSongPlayedTellsTheGroup(t *testing.T) {
+	clock := queue.NewVirtualClock(time.Date(2022, 1, 26, 13, 0, 0, 0, time.UTC))
+	broker := New(clock)
+	config.Values.GroupAlerts = "true"
+	defer func() { config.Values.GroupAlerts = "" }()
+
+	// keep what would have been sent instead of sending it
+	told := map[string]types.CrierBody{}
+	queue.TownCrier = broker.Queue(TownCrierQueue, func(ctx context.Context, event events.SQSEvent) error {
+		body := types.CrierBody{}
+		err := json.Unmarshal([]byte(event.Records[0].Body), &body)
+		told[body.UserID] = body
+		return err
+	})
+
+	// alice is in the test user's group and bob isn't
+	now := clock.Now().Format(time.RFC3339)
+	for _, u := range []types.User{{UserID: "alice", Name: "Alice"}, {UserID: "bob", Name: "Bob"}} {
+		u.CreatedAt = now
+		u.PK, u.SK = u.PKVal(), u.SKVal()
+		assert.Nil(t, types.Store.PutUser(&u))
+	}
+	assert.Nil(t, types.Store.PutMembership(types.TestAuthProviderGroupID, "alice", now))
+
+	// the test user's #2 is played at #91
+	rank := 2
+	song := types.Song{SongID: "group-song", Name: "Group Song", Artist: "The Testers", Rank: &rank}
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	_, err := user.AddVote(types.TestCountdownID, &song)
+	assert.Nil(t, err)
+	song.PlayedAt = &now
+	assert.Nil(t, song.Played(types.TestCountdownID, 10))
+	assert.Nil(t, queue.BeanCounter.Send(types.BeanCounterBody{CountdownID: types.TestCountdownID, SongID: song.SongID}, 0))
+	broker.Drain(context.Background())
+
+	assert.Len(t, told, 2)
+	voter := told[types.TestAuthProviderUserID]
+	assert.Equal(t, "group-song", voter.SongID)
+	assert.Equal(t, 91, voter.Position)
+	assert.Equal(t, 10, voter.Points)
+	assert.Equal(t, "Group Song by The Testers was your #2 and played at #91, that's 10 points", voter.Message)
+
+	alert := told["alice"]
+	assert.Equal(t, types.NotificationGroupPick, alert.Kind)
+	assert.Equal(t, "Ryan's pick just played", alert.Title)
+	assert.Equal(t, "Group Song by The Testers just played at #91", alert.Message)
+	assert.Equal(t, 0, alert.Points)
+}
@@ -0,0 +1,142 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// the APNs servers, the sandbox is for development builds of the app
+const (
+	APNsProduction = "https://api.push.apple.com"
+	APNsSandbox    = "https://api.sandbox.push.apple.com"
+)
+
+// apnsTokenLifetime is how long a provider token is used for. APNs rejects them after an hour, and refreshing them more
+// than every 20 minutes gets TooManyProviderTokenUpdates.
+const apnsTokenLifetime = 50 * time.Minute
+
+// APNs pushes to Apple devices over HTTP/2 with a provider token signed by the team's key
+type APNs struct {
+	Endpoint string // Endpoint defaults to APNsProduction
+	Topic    string // Topic is the app's bundle ID
+	KeyID    string
+	TeamID   string
+	Key      string // Key is the PEM of the .p8 signing key
+	Client   *http.Client
+
+	// Placeholder sends a placeholder instead of a signed provider token, only the Mock accepts it
+	Placeholder bool
+
+	once     sync.Once
+	key      *ecdsa.PrivateKey
+	keyErr   error
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// apnsResponse is the body APNs responds with when it doesn't send the notification
+type apnsResponse struct {
+	Reason string `json:"reason"`
+}
+
+// providerToken returns the signed token, which is reused until it's nearly expired
+func (a *APNs) providerToken() (string, error) {
+	if a.Placeholder {
+		return "placeholder", nil
+	}
+	if a.Key == "" {
+		return "", errors.New("there's no key to sign it with")
+	}
+	a.once.Do(func() {
+		a.key, a.keyErr = jwt.ParseECPrivateKeyFromPEM([]byte(a.Key))
+	})
+	if a.keyErr != nil {
+		return "", a.keyErr
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Since(a.issuedAt) < apnsTokenLifetime {
+		return a.token, nil
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.StandardClaims{Issuer: a.TeamID, IssuedAt: now.Unix()})
+	token.Header["kid"] = a.KeyID
+	signed, err := token.SignedString(a.key)
+	if err != nil {
+		return "", err
+	}
+	a.token, a.issuedAt = signed, now
+	return signed, nil
+}
+
+// expireToken makes the next push sign a new provider token
+func (a *APNs) expireToken() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = ""
+}
+
+// classifyAPNs works out what APNs not sending a notification means for the device
+func classifyAPNs(status int, reason string) Class {
+	switch {
+	case status == http.StatusGone, reason == "BadDeviceToken", reason == "DeviceTokenNotForTopic", reason == "Unregistered":
+		return InvalidToken
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError, reason == "ExpiredProviderToken":
+		return Retryable
+	}
+	return Rejected
+}
+
+// Push sends the message's APNs payload to the device
+func (a *APNs) Push(ctx context.Context, device Device, message Message) (string, error) {
+	token, err := a.providerToken()
+	if err != nil {
+		return "", &Error{Class: Rejected, Provider: "apns", Reason: "unable to sign the provider token", Err: err}
+	}
+
+	endpoint := a.Endpoint
+	if endpoint == "" {
+		endpoint = APNsProduction
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/3/device/%s", endpoint, device.Token), bytes.NewReader(message.APNs))
+	if err != nil {
+		return "", &Error{Class: Rejected, Provider: "apns", Err: err}
+	}
+	req.Header.Set("authorization", fmt.Sprintf("bearer %s", token))
+	req.Header.Set("apns-topic", a.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	if message.CollapseKey != "" {
+		req.Header.Set("apns-collapse-id", message.CollapseKey)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", &Error{Class: Retryable, Provider: "apns", Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusOK {
+		return resp.Header.Get("apns-id"), nil
+	}
+	var body apnsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	if body.Reason == "ExpiredProviderToken" {
+		a.expireToken()
+	}
+	return "", &Error{Class: classifyAPNs(resp.StatusCode, body.Reason), Provider: "apns", Status: resp.StatusCode, Reason: body.Reason}
+}
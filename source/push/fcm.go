@@ -0,0 +1,161 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// FCMEndpoint is the FCM server
+const FCMEndpoint = "https://fcm.googleapis.com"
+
+// fcmScope lets a service account send FCM messages
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCM pushes to Android devices with the FCM HTTP v1 API, authorised as a service account
+type FCM struct {
+	Endpoint    string // Endpoint defaults to FCMEndpoint
+	ProjectID   string // ProjectID defaults to the credentials' project
+	Credentials []byte // Credentials are the service account's JSON key
+	Client      *http.Client
+
+	// Placeholder sends a placeholder instead of the service account's access token, only the Mock accepts it
+	Placeholder bool
+
+	once      sync.Once
+	client    *http.Client
+	projectID string
+	setupErr  error
+}
+
+// fcmResponse is the body FCM responds with
+type fcmResponse struct {
+	Name  string `json:"name"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type      string `json:"@type"`
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// setup builds the client that adds the service account's access token to each request
+func (f *FCM) setup() error {
+	f.once.Do(func() {
+		base := f.Client
+		if base == nil {
+			base = http.DefaultClient
+		}
+		f.projectID = f.ProjectID
+
+		var tokens oauth2.TokenSource = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "placeholder"})
+		if !f.Placeholder {
+			if len(f.Credentials) == 0 {
+				f.setupErr = errors.New("there are no service account credentials")
+				return
+			}
+			conf, err := google.JWTConfigFromJSON(f.Credentials, fcmScope)
+			if err != nil {
+				f.setupErr = err
+				return
+			}
+			var key struct {
+				ProjectID string `json:"project_id"`
+			}
+			_ = json.Unmarshal(f.Credentials, &key)
+			if f.projectID == "" {
+				f.projectID = key.ProjectID
+			}
+			tokens = conf.TokenSource(context.WithValue(context.Background(), oauth2.HTTPClient, base))
+		}
+		f.client = &http.Client{Transport: &oauth2.Transport{Source: oauth2.ReuseTokenSource(nil, tokens), Base: base.Transport}, Timeout: base.Timeout}
+	})
+	return f.setupErr
+}
+
+// fcmErrorCode is FCM's reason for an error, which is in the details, or the status when there isn't one
+func fcmErrorCode(resp fcmResponse) string {
+	if resp.Error == nil {
+		return ""
+	}
+	for _, detail := range resp.Error.Details {
+		if detail.ErrorCode != "" {
+			return detail.ErrorCode
+		}
+	}
+	return resp.Error.Status
+}
+
+// classifyFCM works out what FCM not sending a message means for the device
+func classifyFCM(status int, code string, message string) Class {
+	switch {
+	case code == "UNREGISTERED", code == "SENDER_ID_MISMATCH":
+		return InvalidToken
+	case code == "INVALID_ARGUMENT" && strings.Contains(strings.ToLower(message), "registration token"):
+		return InvalidToken
+	case code == "QUOTA_EXCEEDED", code == "UNAVAILABLE", code == "INTERNAL":
+		return Retryable
+	case status == http.StatusTooManyRequests, status >= http.StatusInternalServerError:
+		return Retryable
+	}
+	return Rejected
+}
+
+// Push sends the message's FCM message to the device
+func (f *FCM) Push(ctx context.Context, device Device, message Message) (string, error) {
+	if err := f.setup(); err != nil {
+		return "", &Error{Class: Rejected, Provider: "fcm", Reason: "invalid credentials", Err: err}
+	}
+
+	// the message is sent as is with the device's token added
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(message.FCM, &fields); err != nil {
+		return "", &Error{Class: Rejected, Provider: "fcm", Err: err}
+	}
+	fields["token"], _ = json.Marshal(device.Token)
+	body, err := json.Marshal(map[string]interface{}{"message": fields})
+	if err != nil {
+		return "", &Error{Class: Rejected, Provider: "fcm", Err: err}
+	}
+
+	endpoint := f.Endpoint
+	if endpoint == "" {
+		endpoint = FCMEndpoint
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/projects/%s/messages:send", endpoint, f.projectID), bytes.NewReader(body))
+	if err != nil {
+		return "", &Error{Class: Rejected, Provider: "fcm", Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		// this includes not getting an access token
+		return "", &Error{Class: Retryable, Provider: "fcm", Err: err}
+	}
+	defer resp.Body.Close()
+
+	var sent fcmResponse
+	_ = json.NewDecoder(resp.Body).Decode(&sent)
+	if resp.StatusCode == http.StatusOK {
+		return sent.Name, nil
+	}
+	code, reason := fcmErrorCode(sent), ""
+	if sent.Error != nil {
+		reason = sent.Error.Message
+	}
+	if code == "" {
+		code = http.StatusText(resp.StatusCode)
+	}
+	return "", &Error{Class: classifyFCM(resp.StatusCode, code, reason), Provider: "fcm", Status: resp.StatusCode, Reason: strings.TrimSpace(fmt.Sprintf("%s %s", code, reason))}
+}
@@ -0,0 +1,132 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"jjj.rflett.com/jjj-api/config"
+)
+
+// the platforms a device can be on, they're the same as the SNS platforms
+const (
+	PlatformApple  = "ios"
+	PlatformGoogle = "android"
+)
+
+// Device is somewhere a notification can be pushed to
+type Device struct {
+	Platform string
+	Token    string // Token is the device's APNs or FCM token
+	Arn      string // Arn is the device's SNS platform endpoint
+}
+
+// Message is a notification built for each platform
+type Message struct {
+	APNs        json.RawMessage // APNs is the APNs payload
+	FCM         json.RawMessage // FCM is the FCM v1 message without the device's token
+	CollapseKey string          // CollapseKey is sent to APNs as a header, FCM has it in its message
+}
+
+// Provider pushes messages to devices
+type Provider interface {
+	// Push sends the message to the device and returns the provider's ID for it. A failure is an *Error, which says
+	// whether the device is still there.
+	Push(ctx context.Context, device Device, message Message) (string, error)
+}
+
+// Class is what a failed push means for the device
+type Class int
+
+const (
+	// Retryable failures could work later, like being throttled or the provider being down
+	Retryable Class = iota
+	// InvalidToken means the device has gone, the app was uninstalled or its token expired, so it should be removed
+	InvalidToken
+	// Rejected failures won't work again, like a bad payload or credentials, but the device is fine
+	Rejected
+)
+
+func (c Class) String() string {
+	switch c {
+	case InvalidToken:
+		return "invalid token"
+	case Rejected:
+		return "rejected"
+	}
+	return "retryable"
+}
+
+// Error is a failed push
+type Error struct {
+	Class    Class
+	Provider string
+	Status   int    // Status is the HTTP status the provider responded with, if it did
+	Reason   string // Reason is why the provider said it failed
+	Err      error
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%s push failed (%s)", e.Provider, e.Class)
+	if e.Reason != "" {
+		message = fmt.Sprintf("%s: %s", message, e.Reason)
+	}
+	if e.Err != nil {
+		message = fmt.Sprintf("%s: %s", message, e.Err)
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// IsInvalidToken returns whether the push failed because the device has gone
+func IsInvalidToken(err error) bool {
+	var pushErr *Error
+	return errors.As(err, &pushErr) && pushErr.Class == InvalidToken
+}
+
+// Direct pushes to APNs and FCM itself instead of through SNS
+type Direct struct {
+	APNs Provider
+	FCM  Provider
+}
+
+// Push sends the message to the device's platform
+func (d *Direct) Push(ctx context.Context, device Device, message Message) (string, error) {
+	if device.Token == "" {
+		return "", &Error{Class: Rejected, Provider: "direct", Reason: "the device doesn't have a token, it was registered before they were kept"}
+	}
+	switch device.Platform {
+	case PlatformApple:
+		return d.APNs.Push(ctx, device, message)
+	case PlatformGoogle:
+		return d.FCM.Push(ctx, device, message)
+	}
+	return "", &Error{Class: Rejected, Provider: "direct", Reason: fmt.Sprintf("%s is not a platform", device.Platform)}
+}
+
+// Pusher sends every notification, it's SNS unless PUSH_PROVIDER is direct
+var Pusher Provider
+
+func init() {
+	Pusher = &SNS{}
+	if config.Values.PushProvider != "direct" {
+		return
+	}
+	config.Require(config.Values.PushRequired()...)
+	apns := &APNs{
+		Endpoint: config.Values.Endpoints.APNs,
+		Topic:    config.Values.APNsTopic,
+		KeyID:    config.Values.APNsKeyID,
+		TeamID:   config.Values.APNsTeamID,
+		Key:      config.Values.APNsKey,
+	}
+	fcm := &FCM{
+		Endpoint:    config.Values.Endpoints.FCM,
+		ProjectID:   config.Values.FCMProjectID,
+		Credentials: []byte(config.Values.FCMCredentials),
+	}
+	Pusher = &Direct{APNs: apns, FCM: fcm}
+}
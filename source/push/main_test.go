@@ -0,0 +1,213 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/aws/smithy-go"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+var testMessage = Message{
+	APNs:        json.RawMessage(`{"aps":{"alert":{"title":"Your song just played!","body":"Elephant"}},"kind":"song_played"}`),
+	FCM:         json.RawMessage(`{"notification":{"title":"Your song just played!","body":"Elephant"},"data":{"kind":"song_played"}}`),
+	CollapseKey: "leaderboard-group",
+}
+
+func testAPNsKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	return key, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func testFCMCredentials(t *testing.T, tokenURI string) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.Nil(t, err)
+	credentials, _ := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "jaypi-test",
+		"client_email": "pusher@jaypi-test.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURI,
+	})
+	return credentials
+}
+
+func pushClass(err error) Class {
+	if pushErr, ok := err.(*Error); ok {
+		return pushErr.Class
+	}
+	return -1
+}
+
+func TestAPNsProviderToken(t *testing.T) {
+	key, pemKey := testAPNsKey(t)
+	a := &APNs{KeyID: "ABC123DEFG", TeamID: "DEF123GHIJ", Key: pemKey}
+
+	signed, err := a.providerToken()
+	assert.Nil(t, err)
+	token, err := jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "ES256", token.Method.Alg())
+	assert.Equal(t, "ABC123DEFG", token.Header["kid"])
+	assert.Equal(t, "DEF123GHIJ", token.Claims.(jwt.MapClaims)["iss"])
+
+	// it's reused until it expires
+	again, _ := a.providerToken()
+	assert.Equal(t, signed, again)
+	a.expireToken()
+	_, err = a.providerToken()
+	assert.Nil(t, err)
+
+	_, err = (&APNs{Key: "not a key"}).Push(context.Background(), Device{Platform: PlatformApple, Token: "device"}, testMessage)
+	assert.Equal(t, Rejected, pushClass(err))
+}
+
+func TestAPNsPush(t *testing.T) {
+	mock := NewMock()
+	url := mock.Start()
+	defer mock.Close()
+	_, pemKey := testAPNsKey(t)
+	a := &APNs{Endpoint: url, Topic: "online.jaypi.app", KeyID: "ABC123DEFG", TeamID: "DEF123GHIJ", Key: pemKey}
+
+	id, err := a.Push(context.Background(), Device{Platform: PlatformApple, Token: "good"}, testMessage)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, id)
+	deliveries := mock.Deliveries()
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, "apns", deliveries[0].Provider)
+	assert.Equal(t, "good", deliveries[0].Token)
+	assert.Equal(t, "online.jaypi.app", deliveries[0].Headers["apns-topic"])
+	assert.Equal(t, "alert", deliveries[0].Headers["apns-push-type"])
+	assert.Equal(t, "leaderboard-group", deliveries[0].Headers["apns-collapse-id"])
+	assert.JSONEq(t, string(testMessage.APNs), string(deliveries[0].Body))
+
+	mock.Unregister("gone")
+	mock.Fail("wrong-app", http.StatusBadRequest, "DeviceTokenNotForTopic")
+	mock.Fail("busy", http.StatusTooManyRequests, "TooManyRequests")
+	mock.Fail("big", http.StatusRequestEntityTooLarge, "PayloadTooLarge")
+	for token, class := range map[string]Class{"gone": InvalidToken, "wrong-app": InvalidToken, "busy": Retryable, "big": Rejected} {
+		_, err = a.Push(context.Background(), Device{Platform: PlatformApple, Token: token}, testMessage)
+		assert.Equal(t, class, pushClass(err), token)
+	}
+	assert.Len(t, mock.Deliveries(), 1)
+}
+
+func TestFCMPush(t *testing.T) {
+	mock := NewMock()
+	url := mock.Start()
+	defer mock.Close()
+	f := &FCM{Endpoint: url, Credentials: testFCMCredentials(t, fmt.Sprintf("%s/token", url))}
+
+	id, err := f.Push(context.Background(), Device{Platform: PlatformGoogle, Token: "good"}, testMessage)
+	assert.Nil(t, err)
+	assert.Equal(t, "projects/jaypi-test/messages/mock-1", id)
+	deliveries := mock.Deliveries()
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, "fcm", deliveries[0].Provider)
+	assert.Equal(t, "good", deliveries[0].Token)
+	var body map[string]interface{}
+	assert.Nil(t, json.Unmarshal(deliveries[0].Body, &body))
+	assert.Equal(t, "good", body["token"])
+	assert.Equal(t, map[string]interface{}{"kind": "song_played"}, body["data"])
+
+	mock.Unregister("gone")
+	mock.Fail("busy", http.StatusTooManyRequests, "QUOTA_EXCEEDED")
+	mock.Fail("down", http.StatusServiceUnavailable, "UNAVAILABLE")
+	mock.Fail("bad", http.StatusBadRequest, "INVALID_ARGUMENT")
+	for token, class := range map[string]Class{"gone": InvalidToken, "busy": Retryable, "down": Retryable, "bad": Rejected} {
+		_, err = f.Push(context.Background(), Device{Platform: PlatformGoogle, Token: token}, testMessage)
+		assert.Equal(t, class, pushClass(err), token)
+	}
+
+	_, err = (&FCM{Credentials: []byte("{}")}).Push(context.Background(), Device{Platform: PlatformGoogle, Token: "good"}, testMessage)
+	assert.Equal(t, Rejected, pushClass(err))
+}
+
+func TestClassifyFCM(t *testing.T) {
+	assert.Equal(t, InvalidToken, classifyFCM(http.StatusNotFound, "UNREGISTERED", "Requested entity was not found."))
+	assert.Equal(t, InvalidToken, classifyFCM(http.StatusForbidden, "SENDER_ID_MISMATCH", ""))
+	assert.Equal(t, InvalidToken, classifyFCM(http.StatusBadRequest, "INVALID_ARGUMENT", "The registration token is not a valid FCM registration token"))
+	assert.Equal(t, Rejected, classifyFCM(http.StatusBadRequest, "INVALID_ARGUMENT", "Invalid value at 'message.android.ttl'"))
+	assert.Equal(t, Rejected, classifyFCM(http.StatusUnauthorized, "THIRD_PARTY_AUTH_ERROR", ""))
+	assert.Equal(t, Retryable, classifyFCM(http.StatusInternalServerError, "INTERNAL", ""))
+}
+
+func TestClassifySNS(t *testing.T) {
+	assert.Equal(t, InvalidToken, classifySNS(&smithy.GenericAPIError{Code: "EndpointDisabled"}).Class)
+	assert.Equal(t, Retryable, classifySNS(&smithy.GenericAPIError{Code: "Throttled"}).Class)
+	assert.Equal(t, Rejected, classifySNS(&smithy.GenericAPIError{Code: "InvalidParameter"}).Class)
+	assert.Equal(t, Retryable, classifySNS(fmt.Errorf("connection reset")).Class)
+}
+
+func TestSNSMessage(t *testing.T) {
+	ios, err := SNSMessage(PlatformApple, testMessage)
+	assert.Nil(t, err)
+	var payload map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(ios), &payload))
+	assert.JSONEq(t, string(testMessage.APNs), payload["APNS"])
+	assert.Contains(t, snsAttributes(PlatformApple, testMessage), "AWS.SNS.MOBILE.APNS.COLLAPSE_ID")
+	assert.Nil(t, snsAttributes(PlatformGoogle, testMessage))
+
+	android, err := SNSMessage(PlatformGoogle, testMessage)
+	assert.Nil(t, err)
+	assert.Nil(t, json.Unmarshal([]byte(android), &payload))
+	assert.JSONEq(t, fmt.Sprintf(`{"fcmV1Message":{"message":%s}}`, testMessage.FCM), payload["GCM"])
+
+	_, err = SNSMessage("windows", testMessage)
+	assert.NotNil(t, err)
+}
+
+func TestDirect(t *testing.T) {
+	mock := NewMock()
+	mock.Start()
+	defer mock.Close()
+	d := mock.Direct()
+
+	_, err := d.Push(context.Background(), Device{Platform: PlatformApple, Token: "iphone"}, testMessage)
+	assert.Nil(t, err)
+	_, err = d.Push(context.Background(), Device{Platform: PlatformGoogle, Token: "pixel"}, testMessage)
+	assert.Nil(t, err)
+	deliveries := mock.Deliveries()
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, "apns", deliveries[0].Provider)
+	assert.Equal(t, "fcm", deliveries[1].Provider)
+
+	// devices registered before tokens were kept can only be reached through SNS
+	_, err = d.Push(context.Background(), Device{Platform: PlatformApple, Arn: "arn:aws:sns:endpoint"}, testMessage)
+	assert.Equal(t, Rejected, pushClass(err))
+
+	mock.Unregister("old")
+	_, err = d.Push(context.Background(), Device{Platform: PlatformGoogle, Token: "old"}, testMessage)
+	assert.True(t, IsInvalidToken(fmt.Errorf("sending: %w", err)))
+	assert.False(t, IsInvalidToken(fmt.Errorf("connection reset")))
+}
+
+func TestDirectWithoutCredentials(t *testing.T) {
+	mock := NewMock()
+	url := mock.Start()
+	defer mock.Close()
+
+	// only the Mock's providers send placeholder tokens
+	d := &Direct{APNs: &APNs{Endpoint: url}, FCM: &FCM{Endpoint: url, ProjectID: "jaypi-test"}}
+	_, err := d.Push(context.Background(), Device{Platform: PlatformApple, Token: "iphone"}, testMessage)
+	assert.Equal(t, Rejected, pushClass(err))
+	_, err = d.Push(context.Background(), Device{Platform: PlatformGoogle, Token: "pixel"}, testMessage)
+	assert.Equal(t, Rejected, pushClass(err))
+	assert.Empty(t, mock.Deliveries())
+}
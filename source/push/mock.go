@@ -0,0 +1,182 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Delivery is a push the Mock accepted
+type Delivery struct {
+	Provider string            `json:"provider"` // Provider is apns or fcm
+	Token    string            `json:"token"`
+	Headers  map[string]string `json:"headers,omitempty"` // Headers are the apns- headers
+	Body     json.RawMessage   `json:"body"`
+}
+
+// mockFailure is how the Mock responds to a token
+type mockFailure struct {
+	status int
+	reason string
+}
+
+// Mock pretends to be APNs, FCM and Google's token endpoint, recording what's pushed to it. Point the APNs and FCM
+// endpoints and the credentials' token_uri at it.
+type Mock struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+	failures   map[string]mockFailure
+	server     *httptest.Server
+}
+
+// NewMock returns a Mock that accepts everything
+func NewMock() *Mock {
+	return &Mock{failures: map[string]mockFailure{}}
+}
+
+// Start serves the Mock on a local port and returns its URL
+func (m *Mock) Start() string {
+	m.server = httptest.NewServer(m)
+	return m.server.URL
+}
+
+// Close stops the server Start started
+func (m *Mock) Close() {
+	if m.server != nil {
+		m.server.Close()
+	}
+}
+
+// Direct returns a Direct that pushes to the started Mock with placeholder tokens, so it doesn't need a key or
+// credentials
+func (m *Mock) Direct() *Direct {
+	return &Direct{
+		APNs: &APNs{Endpoint: m.server.URL, Placeholder: true},
+		FCM:  &FCM{Endpoint: m.server.URL, ProjectID: "jaypi-test", Placeholder: true},
+	}
+}
+
+// Unregister makes pushes to the token fail like the app has been uninstalled
+func (m *Mock) Unregister(token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[token] = mockFailure{}
+}
+
+// Fail makes pushes to the token fail with the status and reason, which is an APNs reason or an FCM error code
+func (m *Mock) Fail(token string, status int, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures[token] = mockFailure{status: status, reason: reason}
+}
+
+// Deliveries returns the pushes that have been accepted
+func (m *Mock) Deliveries() []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Delivery{}, m.deliveries...)
+}
+
+// deliver records the push, or returns how it should fail
+func (m *Mock) deliver(d Delivery) (mockFailure, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if failure, ok := m.failures[d.Token]; ok {
+		return failure, false
+	}
+	m.deliveries = append(m.deliveries, d)
+	return mockFailure{}, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/deliveries":
+		writeJSON(w, http.StatusOK, m.Deliveries())
+	case r.Method == http.MethodPost && r.URL.Path == "/token":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"access_token": "mock", "token_type": "Bearer", "expires_in": 3600})
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/3/device/"):
+		m.serveAPNs(w, r)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v1/projects/") && strings.HasSuffix(r.URL.Path, "/messages:send"):
+		m.serveFCM(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *Mock) serveAPNs(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("authorization"), "bearer ") {
+		writeJSON(w, http.StatusForbidden, apnsResponse{Reason: "MissingProviderToken"})
+		return
+	}
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, apnsResponse{Reason: "PayloadEmpty"})
+		return
+	}
+
+	d := Delivery{Provider: "apns", Token: strings.TrimPrefix(r.URL.Path, "/3/device/"), Headers: map[string]string{}, Body: body}
+	for name := range r.Header {
+		if strings.HasPrefix(strings.ToLower(name), "apns-") {
+			d.Headers[strings.ToLower(name)] = r.Header.Get(name)
+		}
+	}
+	failure, ok := m.deliver(d)
+	if !ok {
+		if failure.status == 0 {
+			failure = mockFailure{status: http.StatusGone, reason: "Unregistered"}
+		}
+		writeJSON(w, failure.status, apnsResponse{Reason: failure.reason})
+		return
+	}
+	w.Header().Set("apns-id", fmt.Sprintf("mock-%d", len(m.Deliveries())))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (m *Mock) serveFCM(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeJSON(w, http.StatusUnauthorized, fcmError(http.StatusUnauthorized, "UNAUTHENTICATED", "Request is missing required authentication credential."))
+		return
+	}
+	var body struct {
+		Message json.RawMessage `json:"message"`
+	}
+	var message struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || json.Unmarshal(body.Message, &message) != nil {
+		writeJSON(w, http.StatusBadRequest, fcmError(http.StatusBadRequest, "INVALID_ARGUMENT", "Invalid JSON payload received."))
+		return
+	}
+
+	failure, ok := m.deliver(Delivery{Provider: "fcm", Token: message.Token, Body: body.Message})
+	if !ok {
+		if failure.status == 0 {
+			failure = mockFailure{status: http.StatusNotFound, reason: "UNREGISTERED"}
+		}
+		writeJSON(w, failure.status, fcmError(failure.status, failure.reason, "Requested entity was not found."))
+		return
+	}
+	project := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/projects/"), "/messages:send")
+	writeJSON(w, http.StatusOK, map[string]string{"name": fmt.Sprintf("projects/%s/messages/mock-%d", project, len(m.Deliveries()))})
+}
+
+// fcmError is an FCM error body with the error code in its details
+func fcmError(status int, code string, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    status,
+			"message": message,
+			"status":  code,
+			"details": []map[string]string{{"@type": "type.googleapis.com/google.firebase.fcm.v1.FcmError", "errorCode": code}},
+		},
+	}
+}
@@ -0,0 +1,85 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snsTypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/smithy-go"
+	"jjj.rflett.com/jjj-api/clients"
+)
+
+// SNS pushes to a device's SNS platform endpoint, which passes it on to APNs or FCM
+type SNS struct{}
+
+// SNSMessage is the message as SNS wants it for the platform, the payload is a JSON string under the platform's key
+func SNSMessage(platform string, m Message) (string, error) {
+	var key string
+	var payload []byte
+	switch platform {
+	case PlatformApple:
+		key, payload = "APNS", m.APNs
+	case PlatformGoogle:
+		fcm, err := json.Marshal(map[string]interface{}{"fcmV1Message": map[string]json.RawMessage{"message": m.FCM}})
+		if err != nil {
+			return "", err
+		}
+		key, payload = "GCM", fcm
+	default:
+		return "", fmt.Errorf("%s is not a platform", platform)
+	}
+	message, err := json.Marshal(map[string]string{key: string(payload)})
+	return string(message), err
+}
+
+// snsAttributes set the APNs headers, FCM has the same settings in its message
+func snsAttributes(platform string, m Message) map[string]snsTypes.MessageAttributeValue {
+	if platform != PlatformApple {
+		return nil
+	}
+	attributes := map[string]snsTypes.MessageAttributeValue{
+		"AWS.SNS.MOBILE.APNS.PUSH_TYPE": {DataType: aws.String("String"), StringValue: aws.String("alert")},
+	}
+	if m.CollapseKey != "" {
+		attributes["AWS.SNS.MOBILE.APNS.COLLAPSE_ID"] = snsTypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(m.CollapseKey)}
+	}
+	return attributes
+}
+
+// classifySNS works out what an SNS error means for the device. SNS disables an endpoint when APNs or FCM says its
+// token is no good.
+func classifySNS(err error) *Error {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return &Error{Class: Retryable, Provider: "sns", Err: err}
+	}
+	pushErr := &Error{Class: Rejected, Provider: "sns", Reason: apiErr.ErrorCode(), Err: err}
+	switch apiErr.ErrorCode() {
+	case "EndpointDisabled", "NotFound":
+		pushErr.Class = InvalidToken
+	case "Throttled", "InternalError", "KMSThrottling", "ServiceUnavailable":
+		pushErr.Class = Retryable
+	}
+	return pushErr
+}
+
+// Push publishes the message to the device's endpoint
+func (s *SNS) Push(ctx context.Context, device Device, message Message) (string, error) {
+	snsMessage, err := SNSMessage(device.Platform, message)
+	if err != nil {
+		return "", &Error{Class: Rejected, Provider: "sns", Err: err}
+	}
+	resp, err := clients.SNSClient.Publish(ctx, &sns.PublishInput{
+		Message:           &snsMessage,
+		MessageStructure:  aws.String("json"),
+		MessageAttributes: snsAttributes(device.Platform, message),
+		TargetArn:         &device.Arn,
+	})
+	if err != nil {
+		return "", classifySNS(err)
+	}
+	return *resp.MessageId, nil
+}
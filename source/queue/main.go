@@ -0,0 +1,34 @@
+package queue
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/config"
+	"time"
+)
+
+// Queue is somewhere messages are sent to be handled later, like an SQS queue in front of a lambda
+type Queue interface {
+	// Send marshals the body to JSON and sends it, it won't be delivered until the delay has passed
+	Send(body interface{}, delay time.Duration) error
+	// SendBatch marshals each body to JSON and sends them all without a delay
+	SendBatch(bodies []interface{}) error
+}
+
+// Handler handles the messages from a Queue, it's the signature of the SQS lambdas
+type Handler func(ctx context.Context, event events.SQSEvent) error
+
+// The queues between each stage of the scoring pipeline, they default to the SQS queues in the config
+var (
+	ChuneRefresh Queue
+	BeanCounter  Queue
+	Scorer       Queue
+	TownCrier    Queue
+)
+
+func init() {
+	ChuneRefresh = &SQS{URL: config.Values.RefreshQueue}
+	BeanCounter = &SQS{URL: config.Values.CounterQueue}
+	Scorer = &SQS{URL: config.Values.ScorerQueue}
+	TownCrier = &SQS{URL: config.Values.CrierQueue}
+}
@@ -0,0 +1,253 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/logger"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// maxReceives is how many times a message is delivered before it's dropped, like a redrive policy
+	maxReceives = 3
+	// retryDelay is how long a failed message waits before it's delivered again, like a visibility timeout
+	retryDelay = 30 * time.Second
+	// pollInterval is how often Run checks for messages when there's nothing pending
+	pollInterval = 100 * time.Millisecond
+)
+
+// Clock tells the Memory broker what the time is
+type Clock interface {
+	Now() time.Time
+}
+
+// RealClock is the wall clock
+type RealClock struct{}
+
+// Now returns the current time
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// VirtualClock is a Clock that only moves when it's told to, so delayed messages can be delivered without waiting
+type VirtualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewVirtualClock returns a VirtualClock starting at start
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now returns the virtual time
+func (c *VirtualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set moves the clock to t, the clock never goes backwards
+func (c *VirtualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+// Advance moves the clock forward by d
+func (c *VirtualClock) Advance(d time.Duration) {
+	c.Set(c.Now().Add(d))
+}
+
+// message is a message waiting to be delivered
+type message struct {
+	id       string
+	queue    *memoryQueue
+	body     string
+	sentAt   time.Time
+	due      time.Time
+	receives int
+}
+
+// Memory is an in-memory message broker, its queues deliver messages to their Handler in the same process
+type Memory struct {
+	mu      sync.Mutex
+	clock   Clock
+	pending []*message
+	nextID  int
+}
+
+// memoryQueue is a Queue on a Memory broker
+type memoryQueue struct {
+	name    string
+	handler Handler
+	broker  *Memory
+}
+
+// NewMemory returns an empty Memory broker that uses the clock to decide when messages are due
+func NewMemory(clock Clock) *Memory {
+	return &Memory{clock: clock}
+}
+
+// Queue creates a queue on the broker whose messages are delivered to handler
+func (m *Memory) Queue(name string, handler Handler) Queue {
+	return &memoryQueue{name: name, handler: handler, broker: m}
+}
+
+// Send puts the body on the queue to be delivered once the delay has passed
+func (q *memoryQueue) Send(body interface{}, delay time.Duration) error {
+	messageBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	q.broker.add(q, string(messageBody), delay)
+	return nil
+}
+
+// SendBatch puts each body on the queue to be delivered straight away
+func (q *memoryQueue) SendBatch(bodies []interface{}) error {
+	for _, body := range bodies {
+		if err := q.Send(body, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add schedules a message, messages that are due at the same time are delivered in the order they were sent
+func (m *Memory) add(q *memoryQueue, body string, delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	now := m.clock.Now()
+	m.pending = append(m.pending, &message{
+		id:     fmt.Sprintf("%s-%d", q.name, m.nextID),
+		queue:  q,
+		body:   body,
+		sentAt: now,
+		due:    now.Add(delay),
+	})
+	sort.SliceStable(m.pending, func(i, j int) bool {
+		return m.pending[i].due.Before(m.pending[j].due)
+	})
+}
+
+// next removes and returns the earliest message that's due by the deadline
+func (m *Memory) next(deadline time.Time) *message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.pending) == 0 || m.pending[0].due.After(deadline) {
+		return nil
+	}
+	msg := m.pending[0]
+	m.pending = m.pending[1:]
+	return msg
+}
+
+// Pending returns how many messages are waiting to be delivered
+func (m *Memory) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.pending)
+}
+
+// deliver sends the message to its queue's handler as an SQSEvent, putting it back on the queue if the handler fails
+func (m *Memory) deliver(ctx context.Context, msg *message) {
+	msg.receives++
+	event := events.SQSEvent{Records: []events.SQSMessage{{
+		MessageId:      msg.id,
+		Body:           msg.body,
+		Attributes:     map[string]string{"SentTimestamp": strconv.FormatInt(msg.sentAt.UnixNano()/int64(time.Millisecond), 10)},
+		EventSource:    "aws:sqs",
+		EventSourceARN: msg.queue.name,
+	}}}
+
+	err := msg.queue.handler(ctx, event)
+	if err == nil {
+		return
+	}
+
+	if msg.receives >= maxReceives {
+		logger.Log.Error().Err(err).Str("queue", msg.queue.name).Str("messageID", msg.id).Msg("Dropping message after too many failed deliveries")
+		return
+	}
+
+	logger.Log.Warn().Err(err).Str("queue", msg.queue.name).Str("messageID", msg.id).Msg("Message failed, it will be delivered again")
+	m.mu.Lock()
+	msg.due = m.clock.Now().Add(retryDelay)
+	m.pending = append(m.pending, msg)
+	sort.SliceStable(m.pending, func(i, j int) bool {
+		return m.pending[i].due.Before(m.pending[j].due)
+	})
+	m.mu.Unlock()
+}
+
+// Drain delivers every message that's due now, including any that are sent while draining, and returns how many were delivered
+func (m *Memory) Drain(ctx context.Context) int {
+	delivered := 0
+	for ctx.Err() == nil {
+		msg := m.next(m.clock.Now())
+		if msg == nil {
+			break
+		}
+		m.deliver(ctx, msg)
+		delivered++
+	}
+	return delivered
+}
+
+// RunUntil delivers messages in the order they're due until the deadline. A VirtualClock jumps forward to each message,
+// otherwise it waits for the message to be due.
+func (m *Memory) RunUntil(ctx context.Context, deadline time.Time) int {
+	delivered := 0
+	for ctx.Err() == nil {
+		msg := m.next(deadline)
+		if msg == nil {
+			break
+		}
+
+		if wait := msg.due.Sub(m.clock.Now()); wait > 0 {
+			if vc, ok := m.clock.(*VirtualClock); ok {
+				vc.Set(msg.due)
+			} else {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					m.mu.Lock()
+					m.pending = append([]*message{msg}, m.pending...)
+					m.mu.Unlock()
+					return delivered
+				}
+			}
+		}
+
+		m.deliver(ctx, msg)
+		delivered++
+	}
+
+	if vc, ok := m.clock.(*VirtualClock); ok {
+		vc.Set(deadline)
+	}
+	return delivered
+}
+
+// Run delivers messages as they become due until the context is cancelled
+func (m *Memory) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		if m.Drain(ctx) == 0 {
+			select {
+			case <-time.After(pollInterval):
+			case <-ctx.Done():
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMemoryDelay(t *testing.T) {
+	start := time.Date(2022, 1, 26, 12, 0, 0, 0, time.UTC)
+	clock := NewVirtualClock(start)
+	m := NewMemory(clock)
+
+	var bodies []string
+	q := m.Queue("test", func(ctx context.Context, event events.SQSEvent) error {
+		bodies = append(bodies, event.Records[0].Body)
+		return nil
+	})
+
+	assert.Nil(t, q.Send("later", time.Minute))
+	assert.Nil(t, q.SendBatch([]interface{}{"now", "also now"}))
+
+	assert.Equal(t, 2, m.Drain(context.Background()))
+	assert.Equal(t, []string{`"now"`, `"also now"`}, bodies)
+
+	assert.Equal(t, 1, m.RunUntil(context.Background(), start.Add(time.Hour)))
+	assert.Equal(t, `"later"`, bodies[2])
+	assert.Equal(t, start.Add(time.Hour), clock.Now())
+}
+
+func TestMemoryRetries(t *testing.T) {
+	clock := NewVirtualClock(time.Date(2022, 1, 26, 12, 0, 0, 0, time.UTC))
+	m := NewMemory(clock)
+
+	receives := 0
+	q := m.Queue("test", func(ctx context.Context, event events.SQSEvent) error {
+		receives++
+		return errors.New("nope")
+	})
+
+	assert.Nil(t, q.Send("fails", 0))
+	assert.Equal(t, maxReceives, m.RunUntil(context.Background(), clock.Now().Add(time.Hour)))
+	assert.Equal(t, maxReceives, receives)
+	assert.Equal(t, 0, m.Pending())
+}
@@ -0,0 +1,93 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqsTypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/dchest/uniuri"
+	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/logger"
+	"time"
+)
+
+// MessageBatch is the most messages SQS accepts in a single batch
+const MessageBatch = 10
+
+// SQS is a Queue backed by an SQS queue
+type SQS struct {
+	URL string
+}
+
+// Send puts the body on the queue, SQS only supports delays up to 15 minutes
+func (q *SQS) Send(body interface{}, delay time.Duration) error {
+	messageBody, err := json.Marshal(body)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("queueUrl", q.URL).Msg("Unable to marshal message body")
+		return err
+	}
+
+	input := &sqs.SendMessageInput{
+		DelaySeconds: int32(delay.Seconds()),
+		MessageBody:  aws.String(string(messageBody)),
+		QueueUrl:     &q.URL,
+	}
+	message, err := clients.SQSClient.SendMessage(context.TODO(), input)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("queueUrl", q.URL).Msg("Unable to send message to SQS")
+		return err
+	}
+
+	logger.Log.Info().Str("queueUrl", q.URL).Str("messageID", *message.MessageId).Msg("Successfully put message on the queue")
+	return nil
+}
+
+// SendBatch puts the bodies on the queue in batches of MessageBatch
+func (q *SQS) SendBatch(bodies []interface{}) error {
+	count := len(bodies)
+
+	for i := 0; i < count; i += MessageBatch {
+		j := i + MessageBatch
+		if j > count {
+			j = count
+		}
+
+		// create the batch of messageBatch entries
+		var entries []sqsTypes.SendMessageBatchRequestEntry
+		for _, body := range bodies[i:j] {
+			messageBody, err := json.Marshal(body)
+			if err != nil {
+				logger.Log.Error().Err(err).Str("queueUrl", q.URL).Msg("Unable to marshal message body")
+				return err
+			}
+			entries = append(entries, sqsTypes.SendMessageBatchRequestEntry{
+				Id:          aws.String(uniuri.NewLen(6)),
+				MessageBody: aws.String(string(messageBody)),
+			})
+		}
+
+		// send the batch to SQS
+		input := &sqs.SendMessageBatchInput{
+			QueueUrl: &q.URL,
+			Entries:  entries,
+		}
+		sendOutput, sendErr := clients.SQSClient.SendMessageBatch(context.TODO(), input)
+		if sendErr != nil {
+			logger.Log.Error().Err(sendErr).Str("queueUrl", q.URL).Msg("Unable to send message batch to SQS")
+			return sendErr
+		}
+
+		// check send results
+		logger.Log.Info().Msg(fmt.Sprintf("Successfully put %d messages on the queue", len(sendOutput.Successful)))
+
+		if len(sendOutput.Failed) > 0 {
+			logger.Log.Warn().Msg(fmt.Sprintf("Failed to put %d messages on the queue", len(sendOutput.Failed)))
+			for _, failedMessage := range sendOutput.Failed {
+				logger.Log.Warn().Str("id", *failedMessage.Id).Msg(*failedMessage.Message)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package recompute
+
+import (
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/types"
+)
+
+// DefaultBatchSize is how many users' scores are applied in each transaction, there are fewer when their awards don't
+// all fit in one
+const DefaultBatchSize = 25
+
+// Report is what recomputing a countdown's scores changed, or would change on a dry run
+type Report struct {
+	CountdownID string              `json:"countdownID"`
+	DryRun      bool                `json:"dryRun"`
+	Changes     []types.ScoreChange `json:"changes"`
+	Applied     int                 `json:"applied"`
+	Conflicts   []string            `json:"conflicts,omitempty"`
+	Refused     []string            `json:"refused,omitempty"` // Refused are the users whose awards don't fit in one transaction
+}
+
+// Run recomputes every user's points in the countdown from its plays and votes. A dry run only reports the changes,
+// otherwise they're applied in batches of users, each in one transaction. When a user in a batch was scored while it
+// ran, the batch is applied again one user at a time and they're reported as a conflict, running it again picks them
+// up.
+func Run(countdownID string, dryRun bool, batchSize int) (*Report, error) {
+	countdown := types.Countdown{CountdownID: countdownID}
+	if _, err := countdown.Get(); err != nil {
+		return nil, err
+	}
+
+	changes, err := countdown.RecomputeScores()
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{CountdownID: countdownID, DryRun: dryRun, Changes: changes}
+	if dryRun {
+		return report, nil
+	}
+
+	if batchSize < 1 {
+		batchSize = DefaultBatchSize
+	}
+	batches, tooBig := types.ScoreChangeBatches(changes, batchSize)
+	for _, change := range tooBig {
+		logger.Log.Warn().Str("userID", change.UserID).Int("awards", len(change.Awards)+len(change.Removed)).Msg("The recomputed score doesn't fit in one transaction")
+		report.Refused = append(report.Refused, change.UserID)
+	}
+	for _, batch := range batches {
+		err = countdown.ApplyScoreChanges(batch)
+		if err == nil {
+			report.Applied += len(batch)
+			logger.Log.Info().Int("applied", report.Applied).Int("total", len(changes)).Msg("Applied a batch of recomputed scores")
+			continue
+		}
+		if err != types.ErrConditionalCheckFailed {
+			return report, err
+		}
+
+		// someone in the batch has been scored since, so the rest are applied without them
+		for _, change := range batch {
+			err = countdown.ApplyScoreChange(change)
+			if err == types.ErrConditionalCheckFailed {
+				report.Conflicts = append(report.Conflicts, change.UserID)
+				continue
+			}
+			if err != nil {
+				return report, err
+			}
+			report.Applied++
+		}
+	}
+	return report, nil
+}
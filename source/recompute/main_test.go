@@ -0,0 +1,172 @@
+package recompute
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/scoring"
+	"jjj.rflett.com/jjj-api/types"
+	"testing"
+	"time"
+)
+
+// scorePlayedSong plays the test song and records its voter's award with the countdown's current rules
+func scorePlayedSong(t *testing.T, countdown *types.Countdown) {
+	playedAt := time.Now().UTC().Format(time.RFC3339)
+	song := types.Song{SongID: types.TestSongID, PlayedAt: &playedAt}
+	assert.Nil(t, song.Played(types.TestCountdownID, 1))
+	awards, err := countdown.Awards(&song)
+	assert.Nil(t, err)
+	for i := range awards {
+		_, err = awards[i].Record()
+		assert.Nil(t, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	types.UseTestStorage()
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err := countdown.Get()
+	assert.Nil(t, err)
+
+	// the test user gets a point for #100, and 5 for a song that was mis-matched and isn't played anymore
+	scorePlayedSong(t, &countdown)
+	stale := types.Award{CountdownID: types.TestCountdownID, UserID: types.TestAuthProviderUserID, SongID: "mismatched", Points: 5}
+	_, err = stale.Record()
+	assert.Nil(t, err)
+
+	// then the countdown is changed to flat scoring
+	_, err = countdown.SetScoring([]scoring.Config{{Rule: scoring.Flat}})
+	assert.Nil(t, err)
+
+	// a dry run shows the change without making it
+	report, err := Run(types.TestCountdownID, true, 1)
+	assert.Nil(t, err)
+	if assert.Len(t, report.Changes, 1) {
+		change := report.Changes[0]
+		assert.Equal(t, types.TestAuthProviderUserID, change.UserID)
+		assert.Equal(t, 6, change.Previous)
+		assert.Equal(t, 10, change.Points)
+		assert.Equal(t, 4, change.Delta)
+		if assert.Len(t, change.Awards, 1) {
+			assert.Equal(t, 2, change.Awards[0].Revision)
+		}
+		if assert.Len(t, change.Removed, 1) {
+			assert.Equal(t, "mismatched", change.Removed[0].SongID)
+		}
+	}
+	assert.Equal(t, 0, report.Applied)
+	points, _ := types.Store.GetUserPoints(types.TestCountdownID, types.TestAuthProviderUserID)
+	assert.Equal(t, 6, points)
+
+	// applying it replaces the points and the awards
+	report, err = Run(types.TestCountdownID, false, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, report.Applied)
+	assert.Empty(t, report.Conflicts)
+	points, _ = types.Store.GetUserPoints(types.TestCountdownID, types.TestAuthProviderUserID)
+	assert.Equal(t, 10, points)
+	award, _ := types.Store.GetAward(types.TestCountdownID, types.TestAuthProviderUserID, types.TestSongID)
+	if assert.NotNil(t, award) {
+		assert.Equal(t, 10, award.Points)
+		assert.Equal(t, 2, award.Revision)
+	}
+	award, _ = types.Store.GetAward(types.TestCountdownID, types.TestAuthProviderUserID, "mismatched")
+	assert.Nil(t, award)
+
+	// and there's nothing left to change
+	report, err = Run(types.TestCountdownID, true, 1)
+	assert.Nil(t, err)
+	assert.Empty(t, report.Changes)
+}
+
+func TestRunConflict(t *testing.T) {
+	memory := types.UseTestStorage()
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err := countdown.Get()
+	assert.Nil(t, err)
+	scorePlayedSong(t, &countdown)
+
+	// the user is scored after their change was worked out
+	changes, err := countdown.RecomputeScores()
+	assert.Nil(t, err)
+	assert.Empty(t, changes)
+	late := types.Award{CountdownID: types.TestCountdownID, UserID: types.TestAuthProviderUserID, SongID: "late", Points: 3}
+	_, err = late.Record()
+	assert.Nil(t, err)
+
+	change := types.ScoreChange{UserID: types.TestAuthProviderUserID, Previous: 1, Points: 1}
+	assert.Equal(t, types.ErrConditionalCheckFailed, countdown.ApplyScoreChange(change))
+	points, _ := memory.GetUserPoints(types.TestCountdownID, types.TestAuthProviderUserID)
+	assert.Equal(t, 4, points)
+}
+
+func TestRunBatches(t *testing.T) {
+	memory := types.UseTestStorage()
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err := countdown.Get()
+	assert.Nil(t, err)
+
+	// three users were scored for a song that was mis-matched, which recomputing takes away
+	users := []string{"ash", "kit", "lou"}
+	for _, userID := range users {
+		stale := types.Award{CountdownID: types.TestCountdownID, UserID: userID, SongID: "mismatched", Points: 5}
+		_, err = stale.Record()
+		assert.Nil(t, err)
+	}
+
+	report, err := Run(types.TestCountdownID, false, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, report.Applied)
+	assert.Empty(t, report.Conflicts)
+	for _, userID := range users {
+		points, _ := memory.GetUserPoints(types.TestCountdownID, userID)
+		assert.Equal(t, 0, points, userID)
+	}
+}
+
+func TestApplyScoreChangesTogether(t *testing.T) {
+	memory := types.UseTestStorage()
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	for _, userID := range []string{"ash", "kit"} {
+		a := types.Award{CountdownID: types.TestCountdownID, UserID: userID, SongID: "mismatched", Points: 5}
+		_, err := a.Record()
+		assert.Nil(t, err)
+	}
+
+	// kit has been scored since, so neither change is saved
+	changes := []types.ScoreChange{{UserID: "ash", Previous: 5}, {UserID: "kit", Previous: 1}}
+	assert.Equal(t, types.ErrConditionalCheckFailed, countdown.ApplyScoreChanges(changes))
+	points, _ := memory.GetUserPoints(types.TestCountdownID, "ash")
+	assert.Equal(t, 5, points)
+}
+
+func TestApplyScoreChangeTooBig(t *testing.T) {
+	memory := types.UseTestStorage()
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	a := types.Award{CountdownID: types.TestCountdownID, UserID: "ash", SongID: "mismatched", Points: 5}
+	_, err := a.Record()
+	assert.Nil(t, err)
+
+	// the points and 25 awards are more than one transaction holds, so none of it is saved
+	change := types.ScoreChange{UserID: "ash", Previous: 5, Points: 25}
+	for i := 0; i < 25; i++ {
+		award := types.Award{CountdownID: types.TestCountdownID, UserID: "ash", SongID: fmt.Sprintf("song-%d", i), Points: 1}
+		award.PK, award.SK = award.PKVal(), award.SKVal()
+		change.Awards = append(change.Awards, award)
+	}
+	assert.Equal(t, types.ErrTransactionTooBig, countdown.ApplyScoreChange(change))
+	points, _ := memory.GetUserPoints(types.TestCountdownID, "ash")
+	assert.Equal(t, 5, points)
+	award, _ := memory.GetAward(types.TestCountdownID, "ash", "song-0")
+	assert.Nil(t, award)
+
+	// so it's never put in a batch
+	small := types.ScoreChange{UserID: "kit"}
+	batches, tooBig := types.ScoreChangeBatches([]types.ScoreChange{change, small}, DefaultBatchSize)
+	if assert.Len(t, batches, 1) {
+		assert.Equal(t, []types.ScoreChange{small}, batches[0])
+	}
+	if assert.Len(t, tooBig, 1) {
+		assert.Equal(t, "ash", tooBig[0].UserID)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package signin
 
 import (
 	"encoding/json"
@@ -6,9 +6,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
 func TestSigninSuccess(t *testing.T) {
 	bodyAsString, _ := json.Marshal(&RequestBody{
 		Email:    types.TestAuthProviderId,
@@ -1,10 +1,9 @@
-package main
+package signup
 
 import (
 	"encoding/json"
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"jjj.rflett.com/jjj-api/logger"
 	"jjj.rflett.com/jjj-api/services"
@@ -75,7 +74,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnJSON(loginResponse, http.StatusCreated)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,4 +1,4 @@
-package main
+package signup
 
 import (
 	"encoding/json"
@@ -8,9 +8,15 @@ import (
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
 func TestSignupSuccess(t *testing.T) {
 	randStr := services.RandStringRunes(6)
 
@@ -1,15 +1,10 @@
-package main
+package validateJwt
 
 import (
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // Literally does nothing, just here to have the auth handler in front of to validate JWT's for the frontend
 func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	return events.APIGatewayProxyResponse{StatusCode: 204}, nil
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,123 @@
+package correctPlayPosition
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func correctRequest(ctx events.APIGatewayProxyRequestContext, songID string, position int) events.APIGatewayProxyRequest {
+	body, _ := json.Marshal(RequestBody{Position: position})
+	return events.APIGatewayProxyRequest{
+		RequestContext: ctx,
+		Body:           string(body),
+		PathParameters: map[string]string{"countdownId": types.TestCountdownID, "songId": songID},
+	}
+}
+
+func TestCorrectPlayPositionForbidden(t *testing.T) {
+	response, err := Handler(correctRequest(types.TestRequestContext, types.TestSongID, 1))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestCorrectPlayPosition(t *testing.T) {
+	var scored []types.ScoreTakerBody
+	broker := queue.NewMemory(queue.RealClock{})
+	scorer := queue.Scorer
+	queue.Scorer = broker.Queue("scorer", func(ctx context.Context, event events.SQSEvent) error {
+		body := types.ScoreTakerBody{}
+		_ = json.Unmarshal([]byte(event.Records[0].Body), &body)
+		scored = append(scored, body)
+		return nil
+	})
+	defer func() { queue.Scorer = scorer }()
+
+	// the test song was the first play, so it's #100
+	playedAt := time.Now().UTC().Format(time.RFC3339)
+	song := types.Song{SongID: types.TestSongID, PlayedAt: &playedAt}
+	playCount, _ := types.Store.GetPlayCount(types.TestCountdownID)
+	assert.Nil(t, song.Played(types.TestCountdownID, playCount))
+	assert.Equal(t, 100, *song.PlayedPosition)
+
+	// and its voter was awarded a point for it
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err := countdown.Get()
+	assert.Nil(t, err)
+	awards, err := countdown.Awards(&song)
+	assert.Nil(t, err)
+	for i := range awards {
+		_, err = awards[i].Record()
+		assert.Nil(t, err)
+	}
+
+	response, _ := Handler(correctRequest(types.TestAdminRequestContext, types.TestSongID, 101))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response, _ = Handler(correctRequest(types.TestAdminRequestContext, "not-played", 99))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	// JJJ skipped #100
+	response, err = Handler(correctRequest(types.TestAdminRequestContext, types.TestSongID, 99))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &song))
+	assert.Equal(t, 99, *song.PlayedPosition)
+
+	// the voter is owed the extra point
+	broker.Drain(context.Background())
+	if assert.Len(t, scored, 1) {
+		assert.Equal(t, types.TestAuthProviderUserID, scored[0].UserID)
+		assert.Equal(t, 1, scored[0].Points)
+		assert.Equal(t, 2, scored[0].Award.Points)
+		assert.Equal(t, 99, scored[0].Award.Position)
+		assert.Equal(t, 1, scored[0].Award.Correction)
+	}
+	late := scored[0]
+
+	// and the next play follows on from it
+	next := types.Song{SongID: "next", PlayedAt: &playedAt}
+	playCount, _ = types.Store.GetPlayCount(types.TestCountdownID)
+	assert.Nil(t, next.Played(types.TestCountdownID, playCount))
+	assert.Equal(t, 98, *next.PlayedPosition)
+
+	// two more corrections are made before the score-taker gets to any of them
+	scored = nil
+	for _, position := range []int{97, 96} {
+		response, _ = Handler(correctRequest(types.TestAdminRequestContext, types.TestSongID, position))
+		assert.Equal(t, http.StatusOK, response.StatusCode)
+	}
+	broker.Drain(context.Background())
+	if assert.Len(t, scored, 2) {
+		for _, body := range scored {
+			recorded, err := body.Award.Record()
+			assert.Nil(t, err)
+			assert.True(t, recorded)
+		}
+	}
+
+	// the first one being recorded late, or again, doesn't undo them
+	recorded, err := late.Award.Record()
+	assert.Nil(t, err)
+	assert.False(t, recorded)
+
+	award, _ := types.Store.GetAward(types.TestCountdownID, types.TestAuthProviderUserID, types.TestSongID)
+	if assert.NotNil(t, award) {
+		assert.Equal(t, 96, award.Position)
+		assert.Equal(t, 5, award.Points)
+		assert.Equal(t, 3, award.Revision)
+	}
+	points, _ := types.Store.GetUserPoints(types.TestCountdownID, types.TestAuthProviderUserID)
+	assert.Equal(t, 5, points)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/rest/countdown/correctPlayPosition"
+)
+
+func main() {
+	config.Require("SCORER_QUEUE")
+	lambda.Start(correctPlayPosition.Handler)
+}
@@ -0,0 +1,62 @@
+package correctPlayPosition
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// RequestBody is the expected body of the request
+type RequestBody struct {
+	Position int `json:"position"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err := json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	countdown := types.Countdown{CountdownID: request.PathParameters["countdownId"]}
+	if status, err := countdown.Get(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	songID := request.PathParameters["songId"]
+	owed, status, err := countdown.CorrectPosition(songID, reqBody.Position)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// settle the difference with the song's voters
+	bodies := make([]interface{}, 0, len(owed))
+	for _, body := range owed {
+		bodies = append(bodies, body)
+	}
+	if err = queue.Scorer.SendBatch(bodies); err != nil {
+		logger.Log.Error().Err(err).Str("songID", songID).Msg("Unable to queue the points owed after correcting the position")
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
+	// return the song as it was played
+	song := types.Song{SongID: songID}
+	if err = song.Get(); err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	if err = song.GetPlay(countdown.CountdownID); err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(song, http.StatusOK)
+}
@@ -0,0 +1,56 @@
+package createCountdown
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func createRequest(ctx events.APIGatewayProxyRequestContext, body RequestBody) events.APIGatewayProxyRequest {
+	b, _ := json.Marshal(body)
+	return events.APIGatewayProxyRequest{RequestContext: ctx, Body: string(b)}
+}
+
+func TestCreateCountdownForbidden(t *testing.T) {
+	response, err := Handler(createRequest(types.TestRequestContext, RequestBody{Name: "Hottest 200", Year: 2022}))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestCreateCountdown(t *testing.T) {
+	// a countdown that isn't current leaves the current one alone
+	response, err := Handler(createRequest(types.TestAdminRequestContext, RequestBody{Name: "Hottest 200", Year: 2022}))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	countdownID, _ := types.CurrentCountdownID()
+	assert.Equal(t, types.TestCountdownID, countdownID)
+
+	// a current one replaces it
+	response, err = Handler(createRequest(types.TestAdminRequestContext, RequestBody{Name: "Hottest 100", Year: 2023, Current: true}))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	countdown := types.Countdown{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &countdown))
+	assert.NotEmpty(t, countdown.CountdownID)
+	assert.True(t, countdown.Current)
+
+	countdownID, _ = types.CurrentCountdownID()
+	assert.Equal(t, countdown.CountdownID, countdownID)
+
+	// the previous countdown is still there
+	previous := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err = previous.Get()
+	assert.Nil(t, err)
+	assert.False(t, previous.Current)
+}
@@ -0,0 +1,51 @@
+package createCountdown
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/scoring"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// RequestBody is the expected body of the create countdown request
+type RequestBody struct {
+	Name      string                  `json:"name"`
+	Year      int                     `json:"year"`
+	Current   bool                    `json:"current"`
+	Length    int                     `json:"length"`
+	Direction string                  `json:"direction"`
+	Scoring   []scoring.Config        `json:"scoring"`
+	Schedule  types.CountdownSchedule `json:"schedule"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err := json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	// create
+	countdown := types.Countdown{
+		Name:      reqBody.Name,
+		Year:      reqBody.Year,
+		Current:   reqBody.Current,
+		Schedule:  reqBody.Schedule,
+		Length:    reqBody.Length,
+		Direction: reqBody.Direction,
+		Scoring:   reqBody.Scoring,
+	}
+	if status, err := countdown.Create(); err != nil {
+		return services.ReturnError(err, status)
+	}
+	return services.ReturnJSON(countdown, http.StatusCreated)
+}
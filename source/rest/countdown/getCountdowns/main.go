@@ -0,0 +1,25 @@
+package getCountdowns
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+type responseBody struct {
+	Countdowns []types.Countdown `json:"countdowns"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	countdowns, err := types.GetCountdowns()
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
+	if countdowns == nil {
+		countdowns = []types.Countdown{}
+	}
+	return services.ReturnJSON(responseBody{Countdowns: countdowns}, http.StatusOK)
+}
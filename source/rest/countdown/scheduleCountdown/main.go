@@ -0,0 +1,40 @@
+package scheduleCountdown
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"time"
+)
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	// unmarshall request body to the schedule
+	schedule := types.CountdownSchedule{}
+	if err := json.Unmarshal([]byte(request.Body), &schedule); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	countdown := types.Countdown{CountdownID: request.PathParameters["countdownId"]}
+	if status, err := countdown.Get(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	if status, err := countdown.SetSchedule(schedule); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// the new schedule might already be due
+	if status, err := countdown.Advance(time.Now()); err != nil {
+		return services.ReturnError(err, status)
+	}
+	return services.ReturnJSON(countdown, http.StatusOK)
+}
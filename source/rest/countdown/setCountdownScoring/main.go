@@ -0,0 +1,40 @@
+package setCountdownScoring
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/scoring"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// RequestBody is the expected body of the request
+type RequestBody struct {
+	Scoring []scoring.Config `json:"scoring"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err := json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	countdown := types.Countdown{CountdownID: request.PathParameters["countdownId"]}
+	if status, err := countdown.Get(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	if status, err := countdown.SetScoring(reqBody.Scoring); err != nil {
+		return services.ReturnError(err, status)
+	}
+	return services.ReturnJSON(countdown, http.StatusOK)
+}
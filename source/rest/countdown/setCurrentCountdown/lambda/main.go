@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/rest/countdown/setCurrentCountdown"
+)
+
+func main() {
+	lambda.Start(setCurrentCountdown.Handler)
+}
@@ -0,0 +1,27 @@
+package setCurrentCountdown
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	countdown := types.Countdown{CountdownID: request.PathParameters["countdownId"]}
+	if status, err := countdown.Get(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	if status, err := countdown.MakeCurrent(); err != nil {
+		return services.ReturnError(err, status)
+	}
+	return services.ReturnJSON(countdown, http.StatusOK)
+}
@@ -0,0 +1,40 @@
+package transitionCountdown
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"time"
+)
+
+// RequestBody is the expected body of the transition countdown request
+type RequestBody struct {
+	State string `json:"state"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err := json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	countdown := types.Countdown{CountdownID: request.PathParameters["countdownId"]}
+	if status, err := countdown.Get(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	if status, err := countdown.Transition(reqBody.State, time.Now()); err != nil {
+		return services.ReturnError(err, status)
+	}
+	return services.ReturnJSON(countdown, http.StatusOK)
+}
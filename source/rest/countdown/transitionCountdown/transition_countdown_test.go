@@ -0,0 +1,64 @@
+package transitionCountdown
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func transitionRequest(ctx events.APIGatewayProxyRequestContext, countdownID string, state string) events.APIGatewayProxyRequest {
+	body, _ := json.Marshal(RequestBody{State: state})
+	return events.APIGatewayProxyRequest{
+		RequestContext: ctx,
+		Body:           string(body),
+		PathParameters: map[string]string{"countdownId": countdownID},
+	}
+}
+
+func TestTransitionCountdownForbidden(t *testing.T) {
+	response, err := Handler(transitionRequest(types.TestRequestContext, types.TestCountdownID, types.CountdownFinished))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestTransitionCountdown(t *testing.T) {
+	// voting was scheduled to close an hour ago
+	countdown := types.Countdown{
+		Name:     "Hottest 200",
+		Year:     2022,
+		State:    types.CountdownVotingOpen,
+		Schedule: types.CountdownSchedule{VotingCloses: time.Now().Add(-time.Hour).Format(time.RFC3339)},
+	}
+	_, err := countdown.Create()
+	assert.Nil(t, err)
+
+	// it can't skip ahead or go to a state that doesn't exist
+	response, _ := Handler(transitionRequest(types.TestAdminRequestContext, countdown.CountdownID, types.CountdownFinished))
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+	response, _ = Handler(transitionRequest(types.TestAdminRequestContext, countdown.CountdownID, "paused"))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	// reopening voting clears the scheduled close so it stays open
+	response, err = Handler(transitionRequest(types.TestAdminRequestContext, countdown.CountdownID, types.CountdownVotingOpen))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	stored := types.Countdown{CountdownID: countdown.CountdownID}
+	_, err = stored.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, types.CountdownVotingOpen, stored.StateAt(time.Now()))
+	assert.Empty(t, stored.Schedule.VotingCloses)
+
+	response, _ = Handler(transitionRequest(types.TestAdminRequestContext, "missing", types.CountdownVotingClosed))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
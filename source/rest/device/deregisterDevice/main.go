@@ -1,10 +1,9 @@
-package main
+package deregisterDevice
 
 import (
 	"encoding/json"
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -55,7 +54,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
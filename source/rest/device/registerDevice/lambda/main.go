@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/rest/device/registerDevice"
+)
+
+func main() {
+	config.Require("GOOGLE_PLATFORM_APP", "APPLE_PLATFORM_APP")
+	lambda.Start(registerDevice.Handler)
+}
@@ -1,25 +1,24 @@
-package main
+package registerDevice
 
 import (
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
-	"os"
 )
 
 var (
 	platforms = map[string]types.PlatformApp{
 		types.SNSPlatformGoogle: {
-			Arn:      os.Getenv("GOOGLE_PLATFORM_APP"),
+			Arn:      config.Values.GooglePlatformApp,
 			Platform: types.SNSPlatformGoogle,
 		},
 		types.SNSPlatformApple: {
-			Arn:      os.Getenv("APPLE_PLATFORM_APP"),
+			Arn:      config.Values.ApplePlatformApp,
 			Platform: types.SNSPlatformApple,
 		},
 	}
@@ -69,7 +68,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,4 +1,4 @@
-package main
+package createGame
 
 import (
 	"encoding/json"
@@ -7,9 +7,15 @@ import (
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
 func TestCreateGame(t *testing.T) {
 	name := services.RandStringRunes(12)
 	desc := services.RandStringRunes(30)
@@ -1,10 +1,9 @@
-package main
+package createGame
 
 import (
 	"encoding/json"
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -48,7 +47,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnJSON(game, http.StatusCreated)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,4 +1,4 @@
-package main
+package createGroup
 
 import (
 	"encoding/json"
@@ -7,7 +7,6 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // RequestBody is the expected body of the create groupOld request
@@ -39,7 +38,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnJSON(group, http.StatusCreated)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
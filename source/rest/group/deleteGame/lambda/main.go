@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/rest/group/deleteGame"
+)
+
+func main() {
+	lambda.Start(deleteGame.Handler)
+}
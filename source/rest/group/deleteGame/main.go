@@ -1,9 +1,8 @@
-package main
+package deleteGame
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -32,7 +31,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
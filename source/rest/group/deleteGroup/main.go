@@ -1,9 +1,8 @@
-package main
+package deleteGroup
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -30,13 +29,13 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		return services.ReturnError(err, status)
 	}
 
-	users, err := group.GetMembers(false)
+	memberIDs, err := types.Store.GetMemberIDs(groupID)
 	if err != nil {
 		return services.ReturnError(err, http.StatusBadRequest)
 	}
 
 	// the owner needs to be the last member of the group
-	if len(users) != 1 {
+	if len(memberIDs) != 1 {
 		return services.ReturnError(
 			errors.New("You have to be the last member of the group to delete it. Please nominate a new owner instead"),
 			http.StatusForbidden,
@@ -49,7 +48,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,9 +1,8 @@
-package main
+package getGames
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -36,7 +35,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	rb := ResponseBody{Games: games}
 	return services.ReturnJSON(rb, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,9 +1,8 @@
-package main
+package getGroup
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -28,7 +27,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnJSON(group, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,134 @@
+package getGroupHistory
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, userID := range []string{"alice", "bob"} {
+		if err := types.Store.PutMembership(types.TestAuthProviderGroupID, userID, now); err != nil {
+			panic(err)
+		}
+	}
+
+	// nobody in the group voted for the songs played first and last
+	play("outside", 1, map[string]int{"outsider": 2})
+	play("first", 2, map[string]int{types.TestAuthProviderUserID: 5, "alice": 3})
+	play("second", 3, map[string]int{"bob": 4})
+	play("last", 4, map[string]int{"outsider": 1})
+	os.Exit(m.Run())
+}
+
+// play plays the song and takes the snapshots before the awards it gave out are recorded, like the scorer is behind
+func play(songID string, playOrder int, points map[string]int) {
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	if _, err := countdown.Get(); err != nil {
+		panic(err)
+	}
+	playedAt := time.Now().UTC().Format(time.RFC3339)
+	song := types.Song{SongID: songID, PlayedAt: &playedAt}
+	if err := song.Played(types.TestCountdownID, playOrder); err != nil {
+		panic(err)
+	}
+
+	var awards []types.Award
+	for userID, p := range points {
+		awards = append(awards, types.Award{CountdownID: types.TestCountdownID, UserID: userID, SongID: songID, Points: p})
+	}
+	if err := countdown.SnapshotGroups(&song, awards); err != nil {
+		panic(err)
+	}
+	for i := range awards {
+		if _, err := awards[i].Record(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// getHistory gets the test group's history with the query string
+func getHistory(query map[string]string) (events.APIGatewayProxyResponse, error) {
+	return Handler(events.APIGatewayProxyRequest{
+		RequestContext:        types.TestRequestContext,
+		PathParameters:        map[string]string{"groupId": types.TestAuthProviderGroupID},
+		QueryStringParameters: query,
+	})
+}
+
+func TestGetGroupHistory(t *testing.T) {
+	response, err := getHistory(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	history := types.GroupHistory{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &history))
+	if assert.Len(t, history.Snapshots, 2) {
+		assert.Equal(t, 2, history.Snapshots[0].PlayOrder)
+		assert.Equal(t, []types.Standing{
+			{UserID: types.TestAuthProviderUserID, Rank: 1, Points: 5, SongsHit: 1},
+			{UserID: "alice", Rank: 2, Points: 3, SongsHit: 1},
+			{UserID: "bob", Rank: 3, Points: 0, SongsHit: 0},
+		}, history.Snapshots[0].Standings)
+
+		assert.Equal(t, 3, history.Snapshots[1].PlayOrder)
+		assert.Equal(t, "second", history.Snapshots[1].SongID)
+		assert.Equal(t, []types.Standing{
+			{UserID: types.TestAuthProviderUserID, Rank: 1, Points: 5, SongsHit: 1},
+			{UserID: "bob", Rank: 2, Points: 4, SongsHit: 1},
+			{UserID: "alice", Rank: 3, Points: 3, SongsHit: 1},
+		}, history.Snapshots[1].Standings)
+	}
+
+	// taking it again once the scorer has recorded the play's awards, like when the message is redelivered, doesn't
+	// count them twice
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err = countdown.Get()
+	assert.Nil(t, err)
+	playOrder := 3
+	bob := types.Award{CountdownID: types.TestCountdownID, UserID: "bob", SongID: "second", Points: 4}
+	assert.Nil(t, countdown.SnapshotGroups(&types.Song{SongID: "second", PlayOrder: &playOrder}, []types.Award{bob}))
+	group := types.Group{GroupID: types.TestAuthProviderGroupID}
+	snapshot, err := group.StandingsAt(types.TestCountdownID, 3)
+	assert.Nil(t, err)
+	if assert.NotNil(t, snapshot) {
+		assert.Equal(t, history.Snapshots[1].Standings, snapshot.Standings)
+	}
+}
+
+func TestGetGroupHistoryAtPlay(t *testing.T) {
+	// the last play didn't change the group, so its standings are the ones after the play before
+	response, err := getHistory(map[string]string{"play": "4"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	snapshot := types.Snapshot{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &snapshot))
+	assert.Equal(t, 3, snapshot.PlayOrder)
+	if assert.NotEmpty(t, snapshot.Standings) {
+		assert.Equal(t, types.TestAuthProviderUserID, snapshot.Standings[0].UserID)
+	}
+
+	response, err = getHistory(map[string]string{"play": "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, err = getHistory(map[string]string{"play": "0"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
+
+func TestGetGroupHistoryForbidden(t *testing.T) {
+	response, err := Handler(events.APIGatewayProxyRequest{
+		RequestContext: types.TestRequestContext,
+		PathParameters: map[string]string{"groupId": "not-a-member"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
@@ -0,0 +1,53 @@
+package getGroupHistory
+
+import (
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"strconv"
+)
+
+// Handler returns the group's standings after each play in the countdown, or just the standings at a play
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	// get groupID from pathParameters
+	groupID := request.PathParameters["groupId"]
+
+	// check user is in the group
+	if ok, _ := services.UserIsInGroup(authContext.UserID, groupID); !ok {
+		return services.ReturnError(errors.New("You have to a member of the group to do this"), http.StatusForbidden)
+	}
+
+	// the snapshots are for the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	group := types.Group{GroupID: groupID}
+	v, ok := request.QueryStringParameters["play"]
+	if !ok {
+		history, err := group.History(countdownID)
+		if err != nil {
+			return services.ReturnError(err, http.StatusInternalServerError)
+		}
+		return services.ReturnJSON(history, http.StatusOK)
+	}
+
+	// the standings at a play are from the last snapshot taken at or before it
+	playOrder, err := strconv.Atoi(v)
+	if err != nil || playOrder < 1 {
+		return services.ReturnError(errors.New("the play needs to be a number from 1"), http.StatusBadRequest)
+	}
+	snapshot, err := group.StandingsAt(countdownID, playOrder)
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	if snapshot == nil {
+		return services.ReturnError(errors.New("Nobody in the group had scored by then"), http.StatusNotFound)
+	}
+	return services.ReturnJSON(snapshot, http.StatusOK)
+}
@@ -0,0 +1,90 @@
+package getGroupLeaderboard
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+// addMember adds a user to the test group
+func addMember(t *testing.T, userID string, name string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	u := types.User{UserID: userID, Name: name, CreatedAt: now}
+	u.PK, u.SK = u.PKVal(), u.SKVal()
+	assert.Nil(t, types.Store.PutUser(&u))
+	assert.Nil(t, types.Store.PutMembership(types.TestAuthProviderGroupID, userID, now))
+}
+
+// score plays the song and records the points each user got for it
+func score(t *testing.T, songID string, points map[string]int) {
+	assert.Nil(t, types.Store.AddPlayedSongID(types.TestCountdownID, songID))
+	for userID, p := range points {
+		a := types.Award{CountdownID: types.TestCountdownID, UserID: userID, SongID: songID, Points: p}
+		_, err := a.Record()
+		assert.Nil(t, err)
+	}
+}
+
+func TestGetGroupLeaderboard(t *testing.T) {
+	addMember(t, "alice", "Alice")
+	addMember(t, "bob", "Bob")
+	addMember(t, "cat", "Cat")
+	score(t, "first", map[string]int{types.TestAuthProviderUserID: 5, "alice": 5, "bob": 3})
+	score(t, "second", map[string]int{"bob": 4, "cat": 1})
+
+	request := events.APIGatewayProxyRequest{
+		RequestContext: types.TestRequestContext,
+		PathParameters: map[string]string{"groupId": types.TestAuthProviderGroupID},
+	}
+	response, err := Handler(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	leaderboard := types.Leaderboard{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &leaderboard))
+	if assert.NotNil(t, leaderboard.LastSongID) {
+		assert.Equal(t, "second", *leaderboard.LastSongID)
+	}
+
+	// bob overtakes alice and the test user with the last song, who are tied behind him
+	type place struct {
+		userID   string
+		rank     int
+		tied     bool
+		points   int
+		songsHit int
+		movement int
+	}
+	var places []place
+	for _, e := range leaderboard.Entries {
+		if assert.NotNil(t, e.Movement) {
+			places = append(places, place{e.UserID, e.Rank, e.Tied, e.Points, e.SongsHit, *e.Movement})
+		}
+	}
+	assert.Equal(t, []place{
+		{"bob", 1, false, 7, 2, 1},
+		{"alice", 2, true, 5, 1, -1},
+		{types.TestAuthProviderUserID, 2, true, 5, 1, -1},
+		{"cat", 3, false, 1, 1, 0},
+	}, places)
+}
+
+func TestGetGroupLeaderboardForbidden(t *testing.T) {
+	request := events.APIGatewayProxyRequest{
+		RequestContext: types.TestRequestContext,
+		PathParameters: map[string]string{"groupId": "not-a-member"},
+	}
+	response, err := Handler(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
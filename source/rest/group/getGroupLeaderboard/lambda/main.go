@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/rest/group/getGroupLeaderboard"
+)
+
+func main() {
+	lambda.Start(getGroupLeaderboard.Handler)
+}
@@ -0,0 +1,35 @@
+package getGroupLeaderboard
+
+import (
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// Handler returns the group's members ranked by their points in the countdown
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	// get groupID from pathParameters
+	groupID := request.PathParameters["groupId"]
+
+	// check user is in the group
+	if ok, _ := services.UserIsInGroup(authContext.UserID, groupID); !ok {
+		return services.ReturnError(errors.New("You have to a member of the group to do this"), http.StatusForbidden)
+	}
+
+	// the points are for the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	group := types.Group{GroupID: groupID}
+	leaderboard, err := group.Leaderboard(countdownID)
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(leaderboard, http.StatusOK)
+}
@@ -1,4 +1,4 @@
-package main
+package getGroupMembers
 
 import (
 	"encoding/json"
@@ -6,9 +6,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
 func TestGetGroupMembers(t *testing.T) {
 	request := events.APIGatewayProxyRequest{
 		RequestContext: types.TestRequestContext,
@@ -1,9 +1,8 @@
-package main
+package getGroupMembers
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -29,9 +28,15 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		return services.ReturnError(errors.New("You have to a member of the group to do this"), http.StatusForbidden)
 	}
 
+	// their points and votes are for the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
 	// get group
 	group := types.Group{GroupID: groupID}
-	users, err := group.GetMembers(withVotes)
+	users, err := group.GetMembers(countdownID, withVotes)
 	if err != nil {
 		return services.ReturnError(err, http.StatusBadRequest)
 	}
@@ -40,7 +45,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	rb := ResponseBody{Members: users}
 	return services.ReturnJSON(rb, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
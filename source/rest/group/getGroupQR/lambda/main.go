@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/rest/group/getGroupQR"
+)
+
+func main() {
+	lambda.Start(getGroupQR.Handler)
+}
@@ -1,9 +1,8 @@
-package main
+package getGroupQR
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -29,7 +28,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		return services.ReturnJSON(qr, http.StatusOK)
 	}
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,4 +1,4 @@
-package main
+package joinGroup
 
 import (
 	"encoding/json"
@@ -6,7 +6,6 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // requestBody is the expected body of the create groupOld request
@@ -38,7 +37,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnJSON(group, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/rest/group/leaveGroup"
+)
+
+func main() {
+	lambda.Start(leaveGroup.Handler)
+}
@@ -1,4 +1,4 @@
-package main
+package updateGame
 
 import (
 	"encoding/json"
@@ -8,7 +8,6 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 )
 
 type requestBody struct {
@@ -63,7 +62,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	}
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,88 @@
+package getLeaderboard
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+
+	// a is out in front and the test user is tied with b
+	points := map[string]int{"a": 10, types.TestAuthProviderUserID: 5, "b": 5, "c": 3, "d": 1}
+	for userID, p := range points {
+		a := types.Award{CountdownID: types.TestCountdownID, UserID: userID, SongID: types.TestSongID, Points: p}
+		if _, err := a.Record(); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// place is where a user is on the leaderboard
+type place struct {
+	userID string
+	rank   int
+	tied   bool
+	points int
+}
+
+// getLeaderboard gets the leaderboard as the test user with the query string and returns the places on it and the next
+// cursor
+func getLeaderboard(t *testing.T, query map[string]string) ([]place, string) {
+	return getLeaderboardAs(t, types.TestRequestContext, query)
+}
+
+// getLeaderboardAs gets the leaderboard as the user in the request context
+func getLeaderboardAs(t *testing.T, ctx events.APIGatewayProxyRequestContext, query map[string]string) ([]place, string) {
+	response, err := Handler(events.APIGatewayProxyRequest{RequestContext: ctx, QueryStringParameters: query})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	leaderboard := types.GlobalLeaderboard{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &leaderboard))
+	var places []place
+	for _, e := range leaderboard.Entries {
+		assert.Nil(t, e.Movement)
+		places = append(places, place{e.UserID, e.Rank, e.Tied, e.Points})
+	}
+	return places, leaderboard.Next
+}
+
+func TestGetLeaderboardTop(t *testing.T) {
+	places, next := getLeaderboard(t, map[string]string{"limit": "2"})
+	assert.Equal(t, []place{{"a", 1, false, 10}, {types.TestAuthProviderUserID, 2, true, 5}}, places)
+	assert.NotEmpty(t, next)
+
+	// the tie carries over onto the next page, and the rank after it skips ahead
+	places, next = getLeaderboard(t, map[string]string{"limit": "2", "cursor": next})
+	assert.Equal(t, []place{{"b", 2, true, 5}, {"c", 4, false, 3}}, places)
+
+	places, next = getLeaderboard(t, map[string]string{"limit": "2", "cursor": next})
+	assert.Equal(t, []place{{"d", 5, false, 1}}, places)
+	assert.Empty(t, next)
+}
+
+func TestGetLeaderboardAround(t *testing.T) {
+	places, next := getLeaderboard(t, map[string]string{"view": ViewAround, "limit": "1"})
+	assert.Equal(t, []place{{"a", 1, false, 10}, {types.TestAuthProviderUserID, 2, true, 5}, {"b", 2, true, 5}}, places)
+	assert.Empty(t, next)
+
+	// the user just ahead of c is tied with the test user, who's off the top
+	ctx := events.APIGatewayProxyRequestContext{Authorizer: map[string]interface{}{"AuthProvider": "", "AuthProviderId": "", "Name": "", "UserID": "c"}}
+	places, _ = getLeaderboardAs(t, ctx, map[string]string{"view": ViewAround, "limit": "1"})
+	assert.Equal(t, []place{{"b", 2, true, 5}, {"c", 4, false, 3}, {"d", 5, false, 1}}, places)
+}
+
+func TestGetLeaderboardBadRequest(t *testing.T) {
+	for _, query := range []map[string]string{{"view": "bottom"}, {"limit": "0"}, {"limit": "101"}, {"cursor": "nope"}} {
+		response, err := Handler(events.APIGatewayProxyRequest{RequestContext: types.TestRequestContext, QueryStringParameters: query})
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode, query)
+	}
+}
@@ -0,0 +1,65 @@
+package getLeaderboard
+
+import (
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// ViewTop pages through the leaderboard from the top
+	ViewTop = "top"
+	// ViewAround is the user's place on the leaderboard with the users either side of them
+	ViewAround = "around"
+
+	defaultTopLimit    = 25
+	defaultAroundLimit = 5
+	maxLimit           = 100
+)
+
+// Handler returns a page of everyone in the countdown ranked by their points
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	// the points are for the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	view := ViewTop
+	if v, ok := request.QueryStringParameters["view"]; ok {
+		view = v
+	}
+	if view != ViewTop && view != ViewAround {
+		return services.ReturnError(fmt.Errorf("the view needs to be %s or %s", ViewTop, ViewAround), http.StatusBadRequest)
+	}
+
+	limit := defaultTopLimit
+	if view == ViewAround {
+		limit = defaultAroundLimit
+	}
+	if v, ok := request.QueryStringParameters["limit"]; ok {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return services.ReturnError(fmt.Errorf("the limit needs to be between 1 and %d", maxLimit), http.StatusBadRequest)
+		}
+	}
+
+	var leaderboard *types.GlobalLeaderboard
+	if view == ViewAround {
+		leaderboard, err = types.GetLeaderboardAround(countdownID, authContext.UserID, limit)
+	} else {
+		leaderboard, err = types.GetLeaderboardPage(countdownID, request.QueryStringParameters["cursor"], limit)
+	}
+	if err == types.ErrInvalidCursor {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(leaderboard, http.StatusOK)
+}
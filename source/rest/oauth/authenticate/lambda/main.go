@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/rest/oauth/authenticate"
+)
+
+func main() {
+	config.Require("OAUTH_CALLBACK_HOST")
+	lambda.Start(authenticate.Handler)
+}
@@ -1,4 +1,4 @@
-package main
+package authenticate
 
 import (
 	"github.com/google/uuid"
@@ -7,7 +7,6 @@ import (
 	"net/http"
 
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 )
 
 // Handler is our handle on life
@@ -26,7 +25,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	headers := map[string]string{"Location": provider.AuthCodeURL(stateStr)}
 	return events.APIGatewayProxyResponse{Body: "", StatusCode: http.StatusTemporaryRedirect, Headers: headers}, nil
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,11 +1,10 @@
-package main
+package callback
 
 import (
 	"context"
 	"encoding/json"
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"io/ioutil"
 	"jjj.rflett.com/jjj-api/logger"
 	"jjj.rflett.com/jjj-api/services"
@@ -129,7 +128,3 @@ func registerOrLoginOauthUser(userInfo types.OauthResponse, providerName string)
 	}
 	return services.ReturnJSON(loginResponse, http.StatusCreated)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,42 @@
+package getPlayReviews
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"sort"
+)
+
+type responseBody struct {
+	Reviews []types.PlayReview `json:"reviews"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	reviews, err := types.Store.GetPlayReviews(countdownID)
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
+	// oldest plays first
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].PlayOrder < reviews[j].PlayOrder
+	})
+
+	if reviews == nil {
+		reviews = []types.PlayReview{}
+	}
+	return services.ReturnJSON(responseBody{Reviews: reviews}, http.StatusOK)
+}
@@ -1,8 +1,7 @@
-package main
+package getPlayedSongs
 
 import (
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -15,6 +14,10 @@ type responseBody struct {
 
 // Handler is our handle on life
 func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
 
 	startIndex := "0"
 	if v, ok := request.QueryStringParameters["startIndex"]; ok {
@@ -26,12 +29,12 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		numItems = v
 	}
 
-	recentSongs, err := services.GetRecentlyPlayed(startIndex, numItems)
+	recentSongs, err := services.GetRecentlyPlayed(countdownID, startIndex, numItems)
 	if err != nil {
 		return services.ReturnError(err, http.StatusInternalServerError)
 	}
 
-	currentPlayCount, err := services.GetCurrentPlayCount()
+	currentPlayCount, err := services.GetCurrentPlayCount(countdownID)
 	if err != nil {
 		return services.ReturnError(err, http.StatusInternalServerError)
 	}
@@ -43,7 +46,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	rb := responseBody{PlayedCount: currentPlayCount, Songs: recentSongs}
 	return services.ReturnJSON(rb, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
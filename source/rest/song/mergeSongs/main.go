@@ -0,0 +1,54 @@
+package mergeSongs
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// RequestBody is the expected body of the request
+type RequestBody struct {
+	DuplicateID string `json:"duplicateID"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err := json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	// merge the duplicate into the song
+	song := types.Song{SongID: request.PathParameters["songId"]}
+	owed, status, err := song.Merge(reqBody.DuplicateID)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// give the voters who missed out their points
+	bodies := make([]interface{}, 0, len(owed))
+	for _, body := range owed {
+		bodies = append(bodies, body)
+	}
+	if err = queue.Scorer.SendBatch(bodies); err != nil {
+		logger.Log.Error().Err(err).Str("songID", song.SongID).Msg("Unable to queue the points owed after merging")
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
+	// return the song with its aliases
+	if song.Aliases, err = types.Store.GetSongAliases(song.SongID); err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(song, http.StatusOK)
+}
@@ -0,0 +1,110 @@
+package mergeSongs
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/scoring"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func mergeRequest(ctx events.APIGatewayProxyRequestContext, duplicateID string) events.APIGatewayProxyRequest {
+	body, _ := json.Marshal(RequestBody{DuplicateID: duplicateID})
+	return events.APIGatewayProxyRequest{
+		RequestContext: ctx,
+		Body:           string(body),
+		PathParameters: map[string]string{"songId": types.TestSongID},
+	}
+}
+
+func TestMergeSongsForbidden(t *testing.T) {
+	response, err := Handler(mergeRequest(types.TestRequestContext, "single"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestMergeSongs(t *testing.T) {
+	// the single was played instead of the version the test user voted for
+	now := time.Now().UTC().Format(time.RFC3339)
+	single := types.Song{SongID: "single", Name: "Test Song", Artist: "Test Artist", CreatedAt: &now}
+	assert.Nil(t, single.Create())
+	single.AddAlias(types.AliasProviderArid, "recording-arid")
+	assert.Nil(t, single.SaveAliases())
+	single.PlayedAt = &now
+	assert.Nil(t, single.Played(types.TestCountdownID, 5))
+
+	var scored []types.ScoreTakerBody
+	broker := queue.NewMemory(queue.RealClock{})
+	scorer := queue.Scorer
+	queue.Scorer = broker.Queue("scorer", func(ctx context.Context, event events.SQSEvent) error {
+		body := types.ScoreTakerBody{}
+		_ = json.Unmarshal([]byte(event.Records[0].Body), &body)
+		scored = append(scored, body)
+		return nil
+	})
+	defer func() { queue.Scorer = scorer }()
+
+	response, err := Handler(mergeRequest(types.TestAdminRequestContext, "single"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	broker.Drain(context.Background())
+
+	// the test user is owed the points for the single
+	if assert.Len(t, scored, 1) {
+		assert.Equal(t, types.TestCountdownID, scored[0].CountdownID)
+		assert.Equal(t, types.TestAuthProviderUserID, scored[0].UserID)
+		assert.Equal(t, 5, scored[0].Points)
+		assert.Equal(t, []scoring.Award{{Rule: scoring.Position, Points: 5}}, scored[0].Award.Rules)
+	}
+
+	// the single and its recording now resolve to the test song, which took its played position
+	songID, _ := types.ResolveSongID(types.AliasProviderSpotify, "single")
+	assert.Equal(t, types.TestSongID, songID)
+	songID, _ = types.ResolveSongID(types.AliasProviderArid, "recording-arid")
+	assert.Equal(t, types.TestSongID, songID)
+
+	song := types.Song{SongID: types.TestSongID}
+	assert.Nil(t, song.GetPlay(types.TestCountdownID))
+	assert.Equal(t, 5, *song.PlayOrder)
+	assert.Equal(t, 96, *song.PlayedPosition)
+	voters, _ := song.Voters(types.TestCountdownID)
+	assert.Equal(t, []string{types.TestAuthProviderUserID}, voters)
+
+	// it can't be merged again
+	response, _ = Handler(mergeRequest(types.TestAdminRequestContext, "single"))
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+}
+
+func TestMergeSongsBothPlayed(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	song := types.Song{SongID: types.TestSongID, PlayedAt: &now}
+	if play, _ := types.Store.GetSongPlay(types.TestCountdownID, types.TestSongID); play == nil {
+		assert.Nil(t, song.Played(types.TestCountdownID, 6))
+	}
+	live := types.Song{SongID: "live", Name: "Test Song (Live)", Artist: "Test Artist", CreatedAt: &now}
+	assert.Nil(t, live.Create())
+	live.AddAlias(types.AliasProviderArid, "live-arid")
+	assert.Nil(t, live.SaveAliases())
+	live.PlayedAt = &now
+	assert.Nil(t, live.Played(types.TestCountdownID, 7))
+
+	// their voters were scored for both plays, so they're left alone
+	response, err := Handler(mergeRequest(types.TestAdminRequestContext, "live"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+	songID, _ := types.ResolveSongID(types.AliasProviderArid, "live-arid")
+	assert.Equal(t, "live", songID)
+	assert.Nil(t, live.Get())
+	assert.Nil(t, live.MergedInto)
+}
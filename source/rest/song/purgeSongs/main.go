@@ -1,8 +1,7 @@
-package main
+package purgeSongs
 
 import (
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"net/http"
 )
@@ -15,11 +14,12 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		return services.ReturnError(err, http.StatusForbidden)
 	}
 
-	services.PurgeSongs()
+	// only the current countdown is purged, previous countdowns are kept
+	countdownID, status, err := services.GetCurrentCountdownID()
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+	services.PurgePlays(countdownID)
 
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/rest/song/resolvePlayReview"
+)
+
+func main() {
+	config.Require("COUNTER_QUEUE")
+	lambda.Start(resolvePlayReview.Handler)
+}
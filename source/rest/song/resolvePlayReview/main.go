@@ -0,0 +1,54 @@
+package resolvePlayReview
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// RequestBody is the expected body of the request, the song is created from it if the songID isn't a stored song
+type RequestBody struct {
+	SongID string `json:"songID"`
+	Name   string `json:"name"`
+	Artist string `json:"artist"`
+	Album  string `json:"album"`
+}
+
+// Handler is our handle on life
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	if err := authContext.IsAdmin(); err != nil {
+		return services.ReturnError(err, http.StatusForbidden)
+	}
+
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err = json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	// match the play to the song
+	review := types.PlayReview{CountdownID: countdownID, ReviewID: request.PathParameters["reviewId"]}
+	song := types.Song{SongID: reqBody.SongID, Name: reqBody.Name, Artist: reqBody.Artist, Album: reqBody.Album}
+	if status, err := review.Resolve(&song); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// give the voters their points
+	if err = queue.BeanCounter.Send(types.BeanCounterBody{CountdownID: countdownID, SongID: song.SongID}, 0); err != nil {
+		logger.Log.Error().Err(err).Str("songID", song.SongID).Msg("Unable to put the song onto the beanCounterQueue")
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
+	return services.ReturnJSON(song, http.StatusOK)
+}
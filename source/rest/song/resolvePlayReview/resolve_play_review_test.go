@@ -0,0 +1,117 @@
+package resolvePlayReview
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/types"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"net/http"
+	"os"
+	"testing"
+)
+
+var (
+	broker  = queue.NewMemory(queue.RealClock{})
+	counted []string
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	queue.BeanCounter = broker.Queue("bean-counter", func(ctx context.Context, event events.SQSEvent) error {
+		body := types.BeanCounterBody{}
+		_ = json.Unmarshal([]byte(event.Records[0].Body), &body)
+		counted = append(counted, body.SongID)
+		return nil
+	})
+	os.Exit(m.Run())
+}
+
+func reviewPlay(t *testing.T, arid string) *types.PlayReview {
+	play := &jjj.Play{
+		Arid:       arid,
+		PlayedTime: "2022-01-22T12:00:00+11:00",
+		Recording:  jjj.Recording{Arid: "recording-" + arid, Title: "Hard To Match", Artists: []jjj.Artist{{Name: "Someone"}}},
+	}
+	review := types.NewPlayReview(types.TestCountdownID, play, nil)
+	assert.Nil(t, review.Create())
+	return review
+}
+
+func resolveRequest(ctx events.APIGatewayProxyRequestContext, reviewID string, body RequestBody) events.APIGatewayProxyRequest {
+	b, _ := json.Marshal(body)
+	return events.APIGatewayProxyRequest{
+		RequestContext: ctx,
+		Body:           string(b),
+		PathParameters: map[string]string{"reviewId": reviewID},
+	}
+}
+
+func TestResolvePlayReviewForbidden(t *testing.T) {
+	response, err := Handler(resolveRequest(types.TestRequestContext, "forbidden", RequestBody{SongID: types.TestSongID}))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
+
+func TestResolvePlayReviewExistingSong(t *testing.T) {
+	review := reviewPlay(t, "existing")
+
+	// the play kept its position
+	playCount, _ := types.Store.GetPlayCount(types.TestCountdownID)
+	assert.Equal(t, review.PlayOrder+1, playCount)
+
+	response, err := Handler(resolveRequest(types.TestAdminRequestContext, review.ReviewID, RequestBody{SongID: types.TestSongID}))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	// the song took the play's position and time and the play count didn't move
+	song := types.Song{SongID: types.TestSongID}
+	assert.Nil(t, song.GetPlay(types.TestCountdownID))
+	assert.Equal(t, review.PlayOrder, *song.PlayOrder)
+	assert.Equal(t, "2022-01-22T12:00:00+11:00", *song.PlayedAt)
+	after, _ := types.Store.GetPlayCount(types.TestCountdownID)
+	assert.Equal(t, playCount, after)
+
+	// the recording is known from now on and the review is gone
+	songID, _ := types.ResolveSongID(types.AliasProviderArid, "recording-existing")
+	assert.Equal(t, types.TestSongID, songID)
+	stored, _ := types.Store.GetPlayReview(types.TestCountdownID, review.ReviewID)
+	assert.Nil(t, stored)
+
+	// it can't be resolved twice
+	response, _ = Handler(resolveRequest(types.TestAdminRequestContext, review.ReviewID, RequestBody{SongID: types.TestSongID}))
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+func TestResolvePlayReviewNewSong(t *testing.T) {
+	review := reviewPlay(t, "new")
+
+	response, _ := Handler(resolveRequest(types.TestAdminRequestContext, review.ReviewID, RequestBody{SongID: "missing"}))
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err := Handler(resolveRequest(types.TestAdminRequestContext, review.ReviewID, RequestBody{Name: "Hard To Match", Artist: "Someone"}))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	song := types.Song{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &song))
+	assert.NotEmpty(t, song.SongID)
+	assert.Equal(t, review.PlayOrder, *song.PlayOrder)
+
+	stored := types.Song{SongID: song.SongID}
+	assert.Nil(t, stored.Get())
+	assert.Equal(t, "Hard To Match", stored.Name)
+}
+
+func TestResolvePlayReviewQueuesBeanCounter(t *testing.T) {
+	review := reviewPlay(t, "queued")
+	broker.Drain(context.Background())
+	counted = nil
+
+	response, _ := Handler(resolveRequest(types.TestAdminRequestContext, review.ReviewID, RequestBody{Name: "Queued", Artist: "Someone", SongID: "queued"}))
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	broker.Drain(context.Background())
+	assert.Equal(t, []string{"queued"}, counted)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/config"
+	"jjj.rflett.com/jjj-api/rest/song/songSearch"
+)
+
+func main() {
+	config.Require("SPOTIFY_CLIENT_ID", "SPOTIFY_SECRET_ID")
+	lambda.Start(songSearch.Handler)
+}
@@ -1,24 +1,23 @@
-package main
+package songSearch
 
 import (
 	"context"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/zmb3/spotify"
 	"golang.org/x/oauth2/clientcredentials"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/logger"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"jjj.rflett.com/jjj-api/types/jjj"
 	"net/http"
-	"os"
 )
 
 var (
-	config = &clientcredentials.Config{
-		ClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
-		ClientSecret: os.Getenv("SPOTIFY_SECRET_ID"),
+	spotifyConfig = &clientcredentials.Config{
+		ClientID:     config.Values.SpotifyClientID,
+		ClientSecret: config.Values.SpotifySecretID,
 		TokenURL:     spotify.TokenURL,
 	}
 	client = spotify.Client{}
@@ -76,10 +75,6 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 }
 
 func init() {
-	token, _ := config.Token(context.Background())
+	token, _ := spotifyConfig.Token(context.Background())
 	client = spotify.Authenticator{}.NewClient(token)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,13 +1,23 @@
-package main
+package getAvatarURL
 
 import (
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/clients"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	clients.S3Client = s3.New(s3.Options{Region: "ap-southeast-2", Credentials: aws.AnonymousCredentials{}})
+	os.Exit(m.Run())
+}
+
 func TestGetAvatarURL(t *testing.T) {
 	request := events.APIGatewayProxyRequest{
 		RequestContext: types.TestRequestContext,
@@ -1,12 +1,12 @@
-package main
+package getAvatarURL
 
 import (
 	"context"
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -31,7 +31,7 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 
 	// get the pre-sign url
 	input := &s3.GetObjectInput{
-		Bucket: &types.AssetsBucket,
+		Bucket: &config.Values.AssetsBucket,
 		Key:    &avatarUuid,
 	}
 	psClient := s3.NewPresignClient(clients.S3Client, func(options *s3.PresignOptions) {
@@ -46,7 +46,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	// response
 	return services.ReturnJSON(presignResponse.URL, http.StatusCreated)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,62 @@
+package getInbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+
+	// the test user has been told about three songs
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	sent := time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 3; i++ {
+		n := types.Notification{Kind: types.NotificationSongPlayed, Title: fmt.Sprintf("#%d", i)}
+		if _, err := user.AddToInbox(n, sent.Add(time.Duration(i)*time.Minute), fmt.Sprintf("message-%d", i)); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func getInbox(query map[string]string) (events.APIGatewayProxyResponse, types.Inbox) {
+	response, _ := Handler(events.APIGatewayProxyRequest{
+		RequestContext:        types.TestRequestContext,
+		QueryStringParameters: query,
+	})
+	inbox := types.Inbox{}
+	_ = json.Unmarshal([]byte(response.Body), &inbox)
+	return response, inbox
+}
+
+func TestGetInbox(t *testing.T) {
+	response, inbox := getInbox(map[string]string{"limit": "2"})
+	assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	assert.Equal(t, 3, inbox.Unread)
+	if assert.Len(t, inbox.Items, 2) {
+		assert.Equal(t, "#3", inbox.Items[0].Notification.Title)
+		assert.False(t, inbox.Items[0].Read)
+	}
+
+	response, inbox = getInbox(map[string]string{"limit": "2", "cursor": inbox.Next})
+	assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	if assert.Len(t, inbox.Items, 1) {
+		assert.Equal(t, "#1", inbox.Items[0].Notification.Title)
+	}
+	assert.Empty(t, inbox.Next)
+}
+
+func TestGetInboxInvalid(t *testing.T) {
+	response, _ := getInbox(map[string]string{"limit": "0"})
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+	response, _ = getInbox(map[string]string{"cursor": "not a cursor!"})
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+}
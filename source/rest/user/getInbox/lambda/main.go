@@ -0,0 +1,10 @@
+package main
+
+import (
+	"github.com/aws/aws-lambda-go/lambda"
+	"jjj.rflett.com/jjj-api/rest/user/getInbox"
+)
+
+func main() {
+	lambda.Start(getInbox.Handler)
+}
@@ -0,0 +1,39 @@
+package getInbox
+
+import (
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultLimit = 25
+	maxLimit     = 100
+)
+
+// Handler returns a page of the user's inbox, newest first
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	limit := defaultLimit
+	if v, ok := request.QueryStringParameters["limit"]; ok {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return services.ReturnError(fmt.Errorf("the limit needs to be between 1 and %d", maxLimit), http.StatusBadRequest)
+		}
+	}
+
+	user := types.User{UserID: authContext.UserID}
+	inbox, err := user.GetInbox(request.QueryStringParameters["cursor"], limit)
+	if err == types.ErrInvalidCursor {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(inbox, http.StatusOK)
+}
@@ -0,0 +1,33 @@
+package getNotifications
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func TestGetNotificationsDefaults(t *testing.T) {
+	response, err := Handler(events.APIGatewayProxyRequest{RequestContext: types.TestRequestContext})
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	}
+
+	preferences := types.NotificationPreferences{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &preferences))
+	assert.Equal(t, types.TestAuthProviderUserID, preferences.UserID)
+	assert.True(t, preferences.Enabled)
+	assert.True(t, preferences.Kinds[types.NotificationSongPlayed])
+	assert.True(t, preferences.Kinds[types.NotificationGameTriggered])
+	assert.True(t, preferences.Kinds[types.NotificationGroupPick])
+	assert.Empty(t, preferences.MutedGroups)
+	assert.Nil(t, preferences.QuietHours)
+}
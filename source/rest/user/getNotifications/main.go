@@ -0,0 +1,20 @@
+package getNotifications
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// Handler returns the user's notification preferences
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	user := types.User{UserID: authContext.UserID}
+	preferences, err := user.NotificationPreferences()
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(preferences, http.StatusOK)
+}
@@ -1,9 +1,8 @@
-package main
+package getUser
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -38,10 +37,19 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		}
 	}
 
+	// their points and votes are for the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+	if err = user.GetPoints(countdownID); err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
 	// get their votes if required
 	if withVotes {
 		// get the members votes
-		votes, voteErr := user.GetVotes()
+		votes, voteErr := user.GetVotes(countdownID)
 		if voteErr == nil {
 			user.Votes = &votes
 		}
@@ -58,7 +66,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	// response
 	return services.ReturnJSON(user, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,109 @@
+package getUserPoints
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/scoring"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+
+	// the test user has voted for two more songs that haven't been played
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	for rank, songID := range map[int]string{2: "second", 3: "third"} {
+		r := rank
+		song := types.Song{SongID: songID, Name: songID, Rank: &r}
+		if _, err := user.AddVote(types.TestCountdownID, &song); err != nil {
+			panic(err)
+		}
+	}
+
+	// #100 is someone else's song and the test user's #1 is played at #1
+	play("other", 1)
+	play(types.TestSongID, 100)
+	os.Exit(m.Run())
+}
+
+// play plays the song in the order and records its awards
+func play(songID string, playOrder int) {
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	if _, err := countdown.Get(); err != nil {
+		panic(err)
+	}
+	playedAt := time.Now().UTC().Format(time.RFC3339)
+	song := types.Song{SongID: songID, PlayedAt: &playedAt}
+	if err := song.Played(types.TestCountdownID, playOrder); err != nil {
+		panic(err)
+	}
+	awards, err := countdown.Awards(&song)
+	if err != nil {
+		panic(err)
+	}
+	for i := range awards {
+		if _, err = awards[i].Record(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// getPoints gets the user's points breakdown as the test user
+func getPoints(userID string) (events.APIGatewayProxyResponse, error) {
+	return Handler(events.APIGatewayProxyRequest{
+		RequestContext: types.TestRequestContext,
+		PathParameters: map[string]string{"userId": userID},
+	})
+}
+
+func TestGetUserPoints(t *testing.T) {
+	response, err := getPoints(types.TestAuthProviderUserID)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	breakdown := types.PointsBreakdown{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &breakdown))
+	assert.Equal(t, 100, breakdown.Points)
+
+	// the unplayed songs can each get 99 at #2, but only one of them can be played there
+	assert.Equal(t, 99+98, breakdown.MaxAchievable)
+
+	if assert.Len(t, breakdown.Songs, 3) {
+		played := breakdown.Songs[0]
+		assert.Equal(t, types.TestSongID, played.SongID)
+		assert.True(t, played.Played)
+		if assert.NotNil(t, played.Position) {
+			assert.Equal(t, 1, *played.Position)
+		}
+		assert.Equal(t, 100, played.Points)
+		assert.Equal(t, []scoring.Award{{Rule: scoring.Position, Points: 100}}, played.Rules)
+		assert.Nil(t, played.MaxPoints)
+
+		for i, songID := range []string{"second", "third"} {
+			unplayed := breakdown.Songs[i+1]
+			assert.Equal(t, songID, unplayed.SongID)
+			assert.Equal(t, i+2, unplayed.Rank)
+			assert.False(t, unplayed.Played)
+			assert.Nil(t, unplayed.Position)
+			assert.Equal(t, 0, unplayed.Points)
+			if assert.NotNil(t, unplayed.MaxPoints) {
+				assert.Equal(t, 99, *unplayed.MaxPoints)
+			}
+		}
+	}
+}
+
+func TestGetUserPointsNotInGroup(t *testing.T) {
+	stranger := types.User{UserID: "stranger", Name: "Stranger", CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	stranger.PK, stranger.SK = stranger.PKVal(), stranger.SKVal()
+	assert.Nil(t, types.Store.PutUser(&stranger))
+
+	response, err := getPoints("stranger")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, response.StatusCode)
+}
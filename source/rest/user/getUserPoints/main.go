@@ -0,0 +1,45 @@
+package getUserPoints
+
+import (
+	"errors"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// Handler returns where the user's points in the countdown came from
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	// get userId from pathParameters
+	userID := request.PathParameters["userId"]
+
+	user := types.User{UserID: userID}
+	if status, err := user.GetByUserID(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// users can get themselves without doing the group check
+	if authContext.UserID != userID {
+		inSameGroup, err := services.UsersAreInSameGroup(authContext.UserID, userID)
+		if err != nil {
+			return services.ReturnError(err, http.StatusBadRequest)
+		}
+		if !inSameGroup {
+			return services.ReturnError(errors.New("You have to a member of the group to do this"), http.StatusForbidden)
+		}
+	}
+
+	// their points are for the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	breakdown, err := user.PointsBreakdown(countdownID)
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(breakdown, http.StatusOK)
+}
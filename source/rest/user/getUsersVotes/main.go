@@ -1,9 +1,8 @@
-package main
+package getUsersVotes
 
 import (
 	"errors"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -34,8 +33,12 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		}
 	}
 
-	// get their votes
-	votes, voteErr := user.GetVotes()
+	// get their votes in the countdown
+	countdownID, status, err := services.GetCountdownID(request)
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+	votes, voteErr := user.GetVotes(countdownID)
 	if voteErr == nil {
 		user.Votes = &votes
 	}
@@ -44,7 +47,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	rb := ResponseBody{Votes: votes}
 	return services.ReturnJSON(rb, http.StatusOK)
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -0,0 +1,25 @@
+package markAllInboxRead
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// Handler marks every notification in the user's inbox as read and returns how many are still unread, which is only
+// the ones that arrived while it ran
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	user := types.User{UserID: authContext.UserID}
+	if _, err := user.MarkAllRead(); err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+
+	unread, err := user.UnreadCount()
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(types.InboxCount{Unread: unread}, http.StatusOK)
+}
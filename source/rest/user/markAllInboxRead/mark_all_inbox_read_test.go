@@ -0,0 +1,42 @@
+package markAllInboxRead
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func TestMarkAllInboxRead(t *testing.T) {
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	sent := time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := user.AddToInbox(types.Notification{Kind: types.NotificationSongPlayed}, sent, fmt.Sprintf("message-%d", i))
+		assert.Nil(t, err)
+	}
+
+	response, err := Handler(events.APIGatewayProxyRequest{RequestContext: types.TestRequestContext})
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	}
+	count := types.InboxCount{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &count))
+	assert.Equal(t, 0, count.Unread)
+
+	inbox, err := user.GetInbox("", 10)
+	assert.Nil(t, err)
+	assert.Len(t, inbox.Items, 3)
+	for _, item := range inbox.Items {
+		assert.True(t, item.Read)
+	}
+}
@@ -0,0 +1,27 @@
+package markInboxRead
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// Handler marks a notification in the user's inbox as read and returns how many are still unread
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	// get notificationId from pathParameters
+	notificationID := request.PathParameters["notificationId"]
+
+	user := types.User{UserID: authContext.UserID}
+	if status, err := user.MarkRead(notificationID); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	unread, err := user.UnreadCount()
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(types.InboxCount{Unread: unread}, http.StatusOK)
+}
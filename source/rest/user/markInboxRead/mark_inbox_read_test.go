@@ -0,0 +1,45 @@
+package markInboxRead
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func markRead(notificationID string) events.APIGatewayProxyResponse {
+	response, _ := Handler(events.APIGatewayProxyRequest{
+		RequestContext: types.TestRequestContext,
+		PathParameters: map[string]string{"notificationId": notificationID},
+	})
+	return response
+}
+
+func TestMarkInboxRead(t *testing.T) {
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	sent := time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC)
+	read, err := user.AddToInbox(types.Notification{Kind: types.NotificationSongPlayed}, sent, "read")
+	assert.Nil(t, err)
+	_, err = user.AddToInbox(types.Notification{Kind: types.NotificationSongPlayed}, sent, "unread")
+	assert.Nil(t, err)
+
+	// reading it again leaves the count alone
+	for i := 0; i < 2; i++ {
+		response := markRead(read.NotificationID)
+		assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+		count := types.InboxCount{}
+		assert.Nil(t, json.Unmarshal([]byte(response.Body), &count))
+		assert.Equal(t, 1, count.Unread)
+	}
+
+	assert.Equal(t, http.StatusNotFound, markRead("missing").StatusCode)
+}
@@ -0,0 +1,70 @@
+package updateNotifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"jjj.rflett.com/jjj-api/services"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+)
+
+// RequestBody is the expected body of the update notifications request, it replaces all of the user's preferences
+// except Enabled, which is left as it was when it's missing
+type RequestBody struct {
+	Enabled     *bool             `json:"enabled"`
+	Kinds       map[string]bool   `json:"kinds"`
+	MutedGroups []string          `json:"mutedGroups"`
+	QuietHours  *types.QuietHours `json:"quietHours"`
+}
+
+// Handler replaces the user's notification preferences
+func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	authContext := services.GetAuthorizerContext(request.RequestContext)
+
+	// unmarshall request body to RequestBody struct
+	reqBody := RequestBody{}
+	if err := json.Unmarshal([]byte(request.Body), &reqBody); err != nil {
+		return services.ReturnError(err, http.StatusBadRequest)
+	}
+
+	// they can only mute their own groups
+	for _, groupID := range reqBody.MutedGroups {
+		isMember, err := services.UserIsInGroup(authContext.UserID, groupID)
+		if err != nil {
+			return services.ReturnError(err, http.StatusInternalServerError)
+		}
+		if !isMember {
+			return services.ReturnError(fmt.Errorf("you aren't a member of the group %s", groupID), http.StatusBadRequest)
+		}
+	}
+
+	// an app that doesn't know about the switch doesn't turn it off
+	user := types.User{UserID: authContext.UserID}
+	stored, err := user.NotificationPreferences()
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	enabled := stored.Enabled
+	if reqBody.Enabled != nil {
+		enabled = *reqBody.Enabled
+	}
+
+	preferences := &types.NotificationPreferences{
+		UserID:      authContext.UserID,
+		Enabled:     enabled,
+		Kinds:       reqBody.Kinds,
+		MutedGroups: reqBody.MutedGroups,
+		QuietHours:  reqBody.QuietHours,
+	}
+	if status, err := preferences.Save(); err != nil {
+		return services.ReturnError(err, status)
+	}
+
+	// respond with every kind listed like a GET
+	preferences, err = user.NotificationPreferences()
+	if err != nil {
+		return services.ReturnError(err, http.StatusInternalServerError)
+	}
+	return services.ReturnJSON(preferences, http.StatusOK)
+}
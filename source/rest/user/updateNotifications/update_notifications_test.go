@@ -0,0 +1,81 @@
+package updateNotifications
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func update(body RequestBody) events.APIGatewayProxyResponse {
+	bodyAsString, _ := json.Marshal(body)
+	response, _ := Handler(events.APIGatewayProxyRequest{
+		RequestContext: types.TestRequestContext,
+		Body:           string(bodyAsString),
+	})
+	return response
+}
+
+func TestUpdateNotifications(t *testing.T) {
+	enabled := true
+	response := update(RequestBody{
+		Enabled:     &enabled,
+		Kinds:       map[string]bool{types.NotificationLeaderboardChange: false},
+		MutedGroups: []string{types.TestAuthProviderGroupID},
+		QuietHours:  &types.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Australia/Perth"},
+	})
+	assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+
+	preferences := types.NotificationPreferences{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &preferences))
+	assert.False(t, preferences.Kinds[types.NotificationLeaderboardChange])
+	assert.True(t, preferences.Kinds[types.NotificationSongPlayed])
+	assert.Equal(t, []string{types.TestAuthProviderGroupID}, preferences.MutedGroups)
+	assert.Equal(t, "Australia/Perth", preferences.QuietHours.TimeZone)
+	assert.NotNil(t, preferences.UpdatedAt)
+
+	// it's what's stored
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	stored, err := user.NotificationPreferences()
+	assert.Nil(t, err)
+	assert.True(t, stored.GroupMuted(types.TestAuthProviderGroupID))
+}
+
+func TestUpdateNotificationsInvalid(t *testing.T) {
+	for name, body := range map[string]RequestBody{
+		"unknown kind":      {Kinds: map[string]bool{"spam": true}},
+		"not their group":   {MutedGroups: []string{"someone-elses-group"}},
+		"bad time":          {QuietHours: &types.QuietHours{Start: "10pm", End: "07:00", TimeZone: "Australia/Perth"}},
+		"bad time zone":     {QuietHours: &types.QuietHours{Start: "22:00", End: "07:00", TimeZone: "Mars/Olympus"}},
+		"start same as end": {QuietHours: &types.QuietHours{Start: "22:00", End: "22:00", TimeZone: "Australia/Perth"}},
+	} {
+		assert.Equal(t, http.StatusBadRequest, update(body).StatusCode, name)
+	}
+}
+
+func TestUpdateNotificationsWithoutEnabled(t *testing.T) {
+	disabled := false
+	response := update(RequestBody{Enabled: &disabled})
+	assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+
+	// leaving enabled out changes the rest without turning them back on
+	response = update(RequestBody{Kinds: map[string]bool{types.NotificationGroupInvite: false}})
+	assert.Equal(t, http.StatusOK, response.StatusCode, response.Body)
+	preferences := types.NotificationPreferences{}
+	assert.Nil(t, json.Unmarshal([]byte(response.Body), &preferences))
+	assert.False(t, preferences.Enabled)
+	assert.False(t, preferences.Kinds[types.NotificationGroupInvite])
+
+	user := types.User{UserID: types.TestAuthProviderUserID}
+	stored, err := user.NotificationPreferences()
+	assert.Nil(t, err)
+	assert.False(t, stored.Enabled)
+}
@@ -1,4 +1,4 @@
-package main
+package updateUser
 
 import (
 	"encoding/json"
@@ -7,9 +7,15 @@ import (
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
+	"os"
 	"testing"
 )
 
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
 func TestUpdateUser(t *testing.T) {
 	bodyAsString, _ := json.Marshal(&RequestBody{
 		NickName: services.RandStringRunes(6),
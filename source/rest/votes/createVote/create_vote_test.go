@@ -0,0 +1,55 @@
+package createVote
+
+import (
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/types"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	types.UseTestStorage()
+	os.Exit(m.Run())
+}
+
+func voteRequest(songID string) events.APIGatewayProxyRequest {
+	rank := 2
+	body, _ := json.Marshal(requestBody{Upsert: []types.Song{{SongID: songID, Name: "Another Song", Artist: "Someone", Rank: &rank}}})
+	return events.APIGatewayProxyRequest{RequestContext: types.TestRequestContext, Body: string(body)}
+}
+
+func TestCreateVoteOutsideVotingWindow(t *testing.T) {
+	// the test countdown is live, so voting has closed
+	response, err := Handler(voteRequest("another-song"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusConflict, response.StatusCode)
+}
+
+func TestCreateVoteWhileVotingOpen(t *testing.T) {
+	countdown := types.Countdown{CountdownID: types.TestCountdownID}
+	_, err := countdown.Get()
+	assert.Nil(t, err)
+	previous := countdown
+	defer func() { _ = types.Store.PutCountdown(&previous) }()
+
+	// voting opened on schedule an hour ago
+	countdown.State = types.CountdownDraft
+	countdown.Schedule = types.CountdownSchedule{VotingOpens: time.Now().Add(-time.Hour).Format(time.RFC3339)}
+	assert.Nil(t, types.Store.PutCountdown(&countdown))
+
+	response, err := Handler(voteRequest("another-song"))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	votes, _ := types.Store.GetVotes(types.TestCountdownID, types.TestAuthProviderUserID)
+	assert.Len(t, votes, 2)
+
+	// and the scheduled transition was saved
+	_, err = countdown.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, types.CountdownVotingOpen, countdown.State)
+}
@@ -1,10 +1,9 @@
-package main
+package createVote
 
 import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 	"net/http"
@@ -34,6 +33,12 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 		return services.ReturnError(err, http.StatusBadRequest)
 	}
 
+	// votes are for the current countdown, while voting is open
+	countdownID, status, err := services.GetVotingCountdownID()
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
 	// validate
 	if len(reqBody.Upsert) > types.VoteLimit {
 		return services.ReturnError(
@@ -43,7 +48,7 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 
 	// delete votes first
 	for _, toDelete := range reqBody.Delete {
-		status, err = user.RemoveVote(&toDelete)
+		status, err = user.RemoveVote(countdownID, &toDelete)
 		if err != nil {
 			return services.ReturnError(err, status)
 		}
@@ -51,7 +56,7 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 
 	// add other votes
 	for _, toUpsert := range reqBody.Upsert {
-		status, err = user.AddVote(&toUpsert)
+		status, err = user.AddVote(countdownID, &toUpsert)
 		if err != nil {
 			return services.ReturnError(err, status)
 		}
@@ -59,7 +64,3 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
@@ -1,8 +1,7 @@
-package main
+package deleteVote
 
 import (
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambda"
 	"jjj.rflett.com/jjj-api/services"
 	"jjj.rflett.com/jjj-api/types"
 )
@@ -14,14 +13,16 @@ func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyRespo
 	// get songID from pathParameters
 	songID := request.PathParameters["songId"]
 
+	// votes are for the current countdown, while voting is open
+	countdownID, status, err := services.GetVotingCountdownID()
+	if err != nil {
+		return services.ReturnError(err, status)
+	}
+
 	// create
 	user := types.User{UserID: authContext.UserID}
-	if status, err := user.RemoveVote(&songID); err != nil {
+	if status, err = user.RemoveVote(countdownID, &songID); err != nil {
 		return services.ReturnError(err, status)
 	}
 	return services.ReturnNoContent()
 }
-
-func main() {
-	lambda.Start(Handler)
-}
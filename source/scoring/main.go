@@ -0,0 +1,111 @@
+package scoring
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the built-in rules, as they're named in a countdown's scoring
+const (
+	Flat            = "flat"
+	Position        = "position"
+	RankMatch       = "rank_match"
+	DistancePenalty = "distance_penalty"
+	Jackpot         = "jackpot"
+)
+
+// Default is how a countdown is scored when it hasn't set its own rules, a point for each place from the end
+var Default = []Config{{Rule: Position}}
+
+// Hit is a song a user voted for that was played in the countdown
+type Hit struct {
+	Position int // Position is where the song was played in the countdown
+	Length   int // Length is how many songs are in the countdown
+	Rank     int // Rank is where the user ranked the song in their votes, or 0 if they didn't
+}
+
+// Rule gives a hit its points
+type Rule interface {
+	Name() string
+	Points(h Hit) int
+}
+
+// Config is a rule and its settings as they're stored on a countdown. Points is what the rule is worth, each rule has
+// its own default when it's 0.
+type Config struct {
+	Rule   string `json:"rule"`
+	Points int    `json:"points,omitempty"`
+}
+
+// Award is the points a rule gave a hit
+type Award struct {
+	Rule   string `json:"rule"`
+	Points int    `json:"points"`
+}
+
+// New returns the rule for the config
+func New(c Config) (Rule, error) {
+	if c.Points < 0 {
+		return nil, errors.New("a rule's points can't be negative")
+	}
+	switch c.Rule {
+	case Flat:
+		return flat{points: orDefault(c.Points, 10)}, nil
+	case Position:
+		return position{multiplier: orDefault(c.Points, 1)}, nil
+	case RankMatch:
+		return rankMatch{bonus: orDefault(c.Points, 10)}, nil
+	case DistancePenalty:
+		return distancePenalty{perPlace: orDefault(c.Points, 1)}, nil
+	case Jackpot:
+		return jackpot{bonus: orDefault(c.Points, 50)}, nil
+	}
+	return nil, fmt.Errorf("%s is not a scoring rule", c.Rule)
+}
+
+// Validate checks every rule in the configs exists and is only used once
+func Validate(configs []Config) error {
+	seen := map[string]bool{}
+	for _, c := range configs {
+		if _, err := New(c); err != nil {
+			return err
+		}
+		if seen[c.Rule] {
+			return fmt.Errorf("the %s rule is used more than once", c.Rule)
+		}
+		seen[c.Rule] = true
+	}
+	return nil
+}
+
+// Score runs the hit through each rule in order and returns what each one awarded and the total. Penalties can't take
+// the total below 0, so a hit never costs a user points.
+func Score(configs []Config, h Hit) ([]Award, int, error) {
+	if len(configs) == 0 {
+		configs = Default
+	}
+
+	awards := make([]Award, 0, len(configs))
+	total := 0
+	for _, c := range configs {
+		rule, err := New(c)
+		if err != nil {
+			return nil, 0, err
+		}
+		points := rule.Points(h)
+		if total+points < 0 {
+			points = -total
+		}
+		total += points
+		awards = append(awards, Award{Rule: rule.Name(), Points: points})
+	}
+	return awards, total, nil
+}
+
+// orDefault returns the value, or the default when it isn't set
+func orDefault(value int, def int) int {
+	if value == 0 {
+		return def
+	}
+	return value
+}
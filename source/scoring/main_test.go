@@ -0,0 +1,45 @@
+package scoring
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScoreDefault(t *testing.T) {
+	awards, total, err := Score(nil, Hit{Position: 100, Length: 100, Rank: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []Award{{Rule: Position, Points: 1}}, awards)
+
+	_, total, _ = Score(nil, Hit{Position: 1, Length: 200})
+	assert.Equal(t, 200, total)
+}
+
+func TestScoreRules(t *testing.T) {
+	configs := []Config{{Rule: Flat, Points: 5}, {Rule: RankMatch}, {Rule: Jackpot}, {Rule: DistancePenalty}}
+
+	// ranked #1 and played at #1
+	awards, total, err := Score(configs, Hit{Position: 1, Length: 100, Rank: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 65, total)
+	assert.Equal(t, []Award{{Flat, 5}, {RankMatch, 10}, {Jackpot, 50}, {DistancePenalty, 0}}, awards)
+
+	// ranked #2 and played at #4
+	awards, total, _ = Score(configs, Hit{Position: 4, Length: 100, Rank: 2})
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []Award{{Flat, 5}, {RankMatch, 0}, {Jackpot, 0}, {DistancePenalty, -2}}, awards)
+}
+
+func TestScorePenaltyStopsAtZero(t *testing.T) {
+	awards, total, err := Score([]Config{{Rule: Flat}, {Rule: DistancePenalty}}, Hit{Position: 80, Length: 100, Rank: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, total)
+	assert.Equal(t, []Award{{Flat, 10}, {DistancePenalty, -10}}, awards)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate([]Config{{Rule: Position}, {Rule: Jackpot, Points: 100}}))
+	assert.NotNil(t, Validate([]Config{{Rule: "double_or_nothing"}}))
+	assert.NotNil(t, Validate([]Config{{Rule: Flat}, {Rule: Flat}}))
+	assert.NotNil(t, Validate([]Config{{Rule: Flat, Points: -1}}))
+}
@@ -0,0 +1,82 @@
+package scoring
+
+// flat gives the same points for every hit
+type flat struct {
+	points int
+}
+
+func (r flat) Name() string {
+	return Flat
+}
+
+func (r flat) Points(h Hit) int {
+	return r.points
+}
+
+// position gives a point for each place the song was from the end of the countdown, so #1 is worth the most
+type position struct {
+	multiplier int
+}
+
+func (r position) Name() string {
+	return Position
+}
+
+func (r position) Points(h Hit) int {
+	if h.Position < 1 || h.Position > h.Length {
+		return 0
+	}
+	return (h.Length - h.Position + 1) * r.multiplier
+}
+
+// rankMatch is a bonus for ranking the song where it was played
+type rankMatch struct {
+	bonus int
+}
+
+func (r rankMatch) Name() string {
+	return RankMatch
+}
+
+func (r rankMatch) Points(h Hit) int {
+	if h.Rank == 0 || h.Rank != h.Position {
+		return 0
+	}
+	return r.bonus
+}
+
+// distancePenalty takes points away for each place between the user's rank and where the song was played
+type distancePenalty struct {
+	perPlace int
+}
+
+func (r distancePenalty) Name() string {
+	return DistancePenalty
+}
+
+func (r distancePenalty) Points(h Hit) int {
+	if h.Rank == 0 {
+		return 0
+	}
+	distance := h.Rank - h.Position
+	if distance < 0 {
+		distance = -distance
+	}
+	return -distance * r.perPlace
+}
+
+// jackpot is a bonus for ranking the song #1 when it was played at #1
+type jackpot struct {
+	bonus int
+}
+
+func (r jackpot) Name() string {
+	return Jackpot
+}
+
+func (r jackpot) Points(h Hit) int {
+	if h.Rank != 1 || h.Position != 1 {
+		return 0
+	}
+	return r.bonus
+}
@@ -2,19 +2,17 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
-	"github.com/aws/aws-sdk-go/aws"
 	sentryGo "github.com/getsentry/sentry-go"
+	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/logger"
 	"jjj.rflett.com/jjj-api/types"
 	"math/rand"
@@ -45,18 +43,10 @@ func min(a, b int) int {
 	return b
 }
 
-// getPlayedSongIDs returns the IDs of the songs that have been played
-func getPlayedSongIDs(startIndex int, numItems int) (songIDs []string, err error) {
-	input := &dynamodb.GetItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			types.PartitionKey: &dbTypes.AttributeValueMemberS{Value: types.PlayedSongsPartitionKey},
-			types.SortKey:      &dbTypes.AttributeValueMemberS{Value: types.PlayedSongsSortKey},
-		},
-		TableName: &types.DynamoTable,
-	}
-
+// getPlayedSongIDs returns the IDs of the songs that have been played in the countdown
+func getPlayedSongIDs(countdownID string, startIndex int, numItems int) (songIDs []string, err error) {
 	// getItem
-	result, err := clients.DynamoClient.GetItem(context.TODO(), input)
+	playedSongIDs, err := types.Store.GetPlayedSongIDs(countdownID)
 
 	// handle errors
 	if err != nil {
@@ -64,16 +54,7 @@ func getPlayedSongIDs(startIndex int, numItems int) (songIDs []string, err error
 		return []string{}, err
 	}
 
-	playedSongs := types.PlayedSongs{}
-
-	// unmarshal item into struct
-	err = attributevalue.UnmarshalMap(result.Item, &playedSongs)
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("failed to unmarshal dynamo item to playedSongs")
-		return []string{}, err
-	}
-
-	playedCount := len(playedSongs.SongIDs)
+	playedCount := len(playedSongIDs)
 	logger.Log.Info().Msg(fmt.Sprintf("We have stored %d played songs", playedCount))
 
 	logger.Log.Info().Str("numItems", strconv.Itoa(numItems)).Str("startIndex", strconv.Itoa(startIndex)).Str("playedCount", strconv.Itoa(playedCount)).Msg(fmt.Sprintf("validating parameters"))
@@ -95,53 +76,22 @@ func getPlayedSongIDs(startIndex int, numItems int) (songIDs []string, err error
 		numItems = 0
 	}
 
-	return playedSongs.SongIDs[startIndex:min(startIndex+numItems, 100)], nil
+	return playedSongIDs[startIndex:min(startIndex+numItems, playedCount)], nil
 }
 
-// GetCurrentPlayCount looks up the current playCount item and returns its value. It should start at 1.
-func GetCurrentPlayCount() (int, error) {
-	pkCondition := expression.Key(types.PartitionKey).Equal(expression.Value(types.PlayCountPartitionKey))
-	skCondition := expression.Key(types.SortKey).Equal(expression.Value(types.PlayCountSortKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("value"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
+// GetCurrentPlayCount looks up the countdown's playCount item and returns its value. It should start at 1.
+func GetCurrentPlayCount(countdownID string) (int, error) {
+	playCount, err := types.Store.GetPlayCount(countdownID)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetCurrentPlayCount func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &types.DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
-	if err != nil || result.Count == 0 {
 		logger.Log.Error().Err(err).Msg("Unable to get the latest song position")
 		return 0, err
 	}
 
-	var pc = types.PlayCount{}
-	unmarshalErr := attributevalue.UnmarshalMap(result.Items[0], &pc)
-	if unmarshalErr != nil {
-		logger.Log.Error().Err(unmarshalErr).Msg("Unable to unmarshall query result to playCount")
-		return 0, unmarshalErr
-	}
-
-	playCountInt, err := strconv.Atoi(*pc.Value)
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to convert play count to int")
-	}
-
-	return playCountInt, nil
+	return playCount, nil
 }
 
-// GetRecentlyPlayed returns the songs that have been played
-func GetRecentlyPlayed(startIndex string, numItems string) ([]types.Song, error) {
+// GetRecentlyPlayed returns the songs that have been played in the countdown
+func GetRecentlyPlayed(countdownID string, startIndex string, numItems string) ([]types.Song, error) {
 	// input
 	startIndexInt, err := strconv.Atoi(startIndex)
 	if err != nil {
@@ -153,7 +103,7 @@ func GetRecentlyPlayed(startIndex string, numItems string) ([]types.Song, error)
 		return []types.Song{}, err
 	}
 
-	playedSongs, err := getPlayedSongIDs(startIndexInt, numItemsInt)
+	playedSongs, err := getPlayedSongIDs(countdownID, startIndexInt, numItemsInt)
 	if err != nil {
 		return []types.Song{}, err
 	}
@@ -163,41 +113,36 @@ func GetRecentlyPlayed(startIndex string, numItems string) ([]types.Song, error)
 		return []types.Song{}, err
 	}
 
-	var keys []map[string]dbTypes.AttributeValue
-	for _, songID := range playedSongs {
-		keys = append(keys, map[string]dbTypes.AttributeValue{
-			types.PartitionKey: &dbTypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", types.SongPartitionKey, songID)},
-			types.SortKey:      &dbTypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", types.SongSortKey, songID)},
-		})
-	}
-
-	input := &dynamodb.BatchGetItemInput{RequestItems: map[string]dbTypes.KeysAndAttributes{
-		types.DynamoTable: {
-			Keys: keys,
-		},
-	}}
-
-	result, err := clients.DynamoClient.BatchGetItem(context.TODO(), input)
+	songs, err := types.Store.GetSongs(playedSongs)
 
 	// handle errors
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("error getting played song ids from table")
+		logger.Log.Error().Err(err).Msg("error getting played songs from table")
 		return []types.Song{}, err
 	}
 
-	// unmarshal songs into slice
-	var songs []types.Song = nil
-	err = attributevalue.UnmarshalListOfMaps(result.Responses[types.DynamoTable], &songs)
-
-	// handle errors
+	// fill in when and where they were played
+	plays, err := types.Store.GetSongPlays(countdownID)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("error unmarshalling BatchGetItem to ListOfMaps")
+		logger.Log.Error().Err(err).Msg("error getting song plays from table")
 		return []types.Song{}, err
 	}
+	played := map[string]types.SongPlay{}
+	for _, p := range plays {
+		played[p.SongID] = p
+	}
+	for i := range songs {
+		p := played[songs[i].SongID]
+		songs[i].PlayedAt = &p.PlayedAt
+		songs[i].PlayOrder = &p.PlayOrder
+		if p.Position > 0 {
+			songs[i].PlayedPosition = &p.Position
+		}
+	}
 
-	// sort the songs by PlayedPosition asc
+	// sort the songs by the order they were played in
 	sort.Slice(songs, func(i, j int) bool {
-		return *songs[i].PlayedPosition < *songs[j].PlayedPosition
+		return *songs[i].PlayOrder < *songs[j].PlayOrder
 	})
 
 	// return
@@ -206,30 +151,8 @@ func GetRecentlyPlayed(startIndex string, numItems string) ([]types.Song, error)
 
 // GetGroupFromCode returns the groupID based on the group code
 func GetGroupFromCode(code string) (*types.Group, error) {
-	// input
-	pkCondition := expression.Key(types.PartitionKey).BeginsWith(fmt.Sprintf("%s#", types.GroupCodePartitionKey))
-	skCondition := expression.Key(types.SortKey).Equal(expression.Value(fmt.Sprintf("%s#%s", types.GroupCodeSortKey, code)))
-	keyCondition := expression.KeyAnd(skCondition, pkCondition)
-
-	projExpr := expression.NamesList(expression.Name("GroupID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetGroupFromCode func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &types.DynamoTable,
-		IndexName:                 aws.String(types.GSI),
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	gc, err := types.Store.GetGroupCodeByCode(code)
 
 	// handle errors
 	if err != nil {
@@ -238,21 +161,12 @@ func GetGroupFromCode(code string) (*types.Group, error) {
 	}
 
 	// code doesn't exist
-	if len(result.Items) == 0 {
+	if gc == nil {
 		codeNotExistErr := errors.New("Group code not found.")
 		logger.Log.Error().Err(codeNotExistErr).Str("code", code).Msg(" groupcode does not exist")
 		return &types.Group{}, codeNotExistErr
 	}
 
-	// unmarshal groupID into the Group struct
-	gc := types.GroupCode{}
-	err = attributevalue.UnmarshalMap(result.Items[0], &gc)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("code", code).Msg("error unmarshalling code to GroupCode")
-		fmt.Printf("Failed to unmarshal Record, %v", err)
-		return &types.Group{}, err
-	}
-
 	// get the group
 	g := &types.Group{GroupID: gc.GroupID}
 	_, getGroupErr := g.Get()
@@ -294,6 +208,26 @@ func GetOauthProvider(providerName string) (*types.OauthProvider, error) {
 	return provider, nil
 }
 
+// ParseToken validates a JWT with the verify key and returns its claims
+func ParseToken(token string) (*types.UserClaims, error) {
+	// decode the verification public key
+	verifyKey, _ := base64.StdEncoding.DecodeString(config.Values.JWTVerifyKey)
+
+	// validate and parse the token with our custom claims and key
+	parsedToken, err := jwt.ParseWithClaims(token, &types.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return jwt.ParseRSAPublicKeyFromPEM(verifyKey)
+	})
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to parse JWT with claims")
+		return nil, err
+	}
+	if !parsedToken.Valid {
+		logger.Log.Info().Msg("JWT token is not valid")
+		return nil, errors.New("Unauthorized")
+	}
+	return parsedToken.Claims.(*types.UserClaims), nil
+}
+
 // GetAuthorizerContext returns the AuthorizerContext from the APIGatewayProxyRequestContext
 func GetAuthorizerContext(ctx events.APIGatewayProxyRequestContext) *types.AuthorizerContext {
 	var AuthProvider = ctx.Authorizer["AuthProvider"].(string)
@@ -330,39 +264,14 @@ func GetPlatformEndpointAttributes(arn string) (map[string]string, error) {
 
 // UserIsInGroup returns whether a user is a member of a group
 func UserIsInGroup(userID string, groupID string) (bool, error) {
-	// input
-	pkCondition := expression.Key(types.PartitionKey).Equal(
-		expression.Value(fmt.Sprintf("%s#%s", types.GroupPartitionKey, groupID)),
-	)
-	skCondition := expression.Key(types.SortKey).Equal(
-		expression.Value(fmt.Sprintf("%s#%s", types.UserPartitionKey, userID)),
-	)
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("UserID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for UserIsInGroup func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &types.DynamoTable,
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		KeyConditionExpression:    expr.KeyCondition(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	isMember, err := types.Store.IsMember(groupID, userID)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("userID", userID).Str("groupID", groupID).Msg("Unable to check if user is in group")
 		return false, err
 	}
 
-	return len(result.Items) != 0, nil
+	return isMember, nil
 }
 
 // UsersAreInSameGroup returns whether two users are in the same group
@@ -433,89 +342,66 @@ func ReturnError(err error, status int) (events.APIGatewayProxyResponse, error)
 	return ReturnJSON(body, status)
 }
 
-// PurgeSongs removes all songs from the table
-func PurgeSongs() {
-	// input
-	pkFilter := expression.Name(types.PartitionKey).BeginsWith(fmt.Sprintf("%s#", types.SongPartitionKey))
-	expr, err := expression.NewBuilder().WithFilter(pkFilter).Build()
-
+// PurgePlays removes every play from the countdown so it can be played again, the songs and votes are kept
+func PurgePlays(countdownID string) {
+	plays, err := types.Store.GetSongPlays(countdownID)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetGroupFromCode func")
+		logger.Log.Error().Err(err).Msg("error listing plays to purge")
 	}
-
-	input := &dynamodb.ScanInput{
-		TableName:                 &types.DynamoTable,
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		FilterExpression:          expr.Filter(),
+	for _, p := range plays {
+		_ = types.Store.DeleteSongPlay(countdownID, p.SongID)
 	}
 
-	paginator := dynamodb.NewScanPaginator(clients.DynamoClient, input)
-
-	for paginator.HasMorePages() {
-		page, pageErr := paginator.NextPage(context.TODO())
-		if pageErr != nil {
-			logger.Log.Error().Err(pageErr).Msg("error getting NextPage from PurgeSongs paginator")
-			break
-		}
-
-		for _, item := range page.Items {
-			song := types.Song{}
-			unMarshErr := attributevalue.UnmarshalMap(item, &song)
-			if unMarshErr != nil {
-				logger.Log.Error().Err(unMarshErr).Msg("error unmarshalling item to song")
-			} else {
-				_ = song.Delete()
-			}
-		}
+	reviews, err := types.Store.GetPlayReviews(countdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("error listing reviews to purge")
+	}
+	for _, r := range reviews {
+		_ = types.Store.DeletePlayReview(countdownID, r.ReviewID)
 	}
 
-	setPlayCount("1")
-	resetPlayedList()
+	if err = types.Store.SetPlayCount(countdownID, 1); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to set the play count")
+	}
+	if err = types.Store.ResetPlayedSongIDs(countdownID); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to reset the playedList")
+	}
 }
 
-// setPlayCount sets the current playCount to a specific value
-func setPlayCount(val string) {
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#V": "value",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":val": &dbTypes.AttributeValueMemberN{Value: val},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			types.PartitionKey: &dbTypes.AttributeValueMemberS{Value: types.PlayCountPartitionKey},
-			types.SortKey:      &dbTypes.AttributeValueMemberS{Value: types.PlayCountSortKey},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &types.DynamoTable,
-		UpdateExpression: aws.String("SET #V = :val"),
-	}
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+// GetCurrentCountdownID returns the current countdown, with a not found status if there isn't one
+func GetCurrentCountdownID() (string, int, error) {
+	countdownID, err := types.CurrentCountdownID()
+	if err == types.ErrNoCurrentCountdown {
+		return "", http.StatusNotFound, err
+	}
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to set the play count")
+		return "", http.StatusInternalServerError, err
 	}
+	return countdownID, http.StatusOK, nil
 }
 
-// resetPlayedList resets the current playedList
-func resetPlayedList() {
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#S": "SongIDs",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":val": &dbTypes.AttributeValueMemberL{Value: []dbTypes.AttributeValue{}},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			types.PartitionKey: &dbTypes.AttributeValueMemberS{Value: types.PlayedSongsPartitionKey},
-			types.SortKey:      &dbTypes.AttributeValueMemberS{Value: types.PlayedSongsSortKey},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &types.DynamoTable,
-		UpdateExpression: aws.String("SET #S = :val"),
-	}
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+// GetVotingCountdownID returns the current countdown if it's open for voting, with a conflict status if it isn't
+func GetVotingCountdownID() (string, int, error) {
+	countdown, status, err := types.GetCurrentCountdown(time.Now())
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to reset the playedList")
+		return "", status, err
+	}
+	if countdown.State != types.CountdownVotingOpen {
+		return "", http.StatusConflict, types.ErrVotingClosed
+	}
+	return countdown.CountdownID, http.StatusOK, nil
+}
+
+// GetCountdownID returns the countdown in the request's countdownId query string, or the current countdown
+func GetCountdownID(request events.APIGatewayProxyRequest) (string, int, error) {
+	countdownID := request.QueryStringParameters["countdownId"]
+	if countdownID == "" {
+		return GetCurrentCountdownID()
+	}
+
+	countdown := types.Countdown{CountdownID: countdownID}
+	if status, err := countdown.Get(); err != nil {
+		return "", status, err
 	}
+	return countdownID, http.StatusOK, nil
 }
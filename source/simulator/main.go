@@ -0,0 +1,287 @@
+package simulator
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	chuneMachine "jjj.rflett.com/jjj-api/lambda/chune-machine"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/nowplaying"
+	"jjj.rflett.com/jjj-api/pipeline"
+	"jjj.rflett.com/jjj-api/queue"
+	"jjj.rflett.com/jjj-api/types"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"sort"
+	"strings"
+	"time"
+)
+
+// replayTail is how long the replay keeps running after the last response when it has no next_updated time
+const replayTail = time.Minute
+
+// PlayedSong is a song that was marked as played during the replay, its Position is 0 if it was outside the countdown
+type PlayedSong struct {
+	PlayOrder int    `json:"playOrder"`
+	Position  int    `json:"position"`
+	SongID    string `json:"songID"`
+	Name      string `json:"name"`
+	Artist    string `json:"artist"`
+	PlayedAt  string `json:"playedAt"`
+}
+
+// UserPoints are the points a user earned during the replay
+type UserPoints struct {
+	UserID string `json:"userID"`
+	Name   string `json:"name"`
+	Points int    `json:"points"`
+}
+
+// Report is the outcome of a replay
+type Report struct {
+	Songs  []PlayedSong `json:"songs"`
+	Points []UserPoints `json:"points"`
+}
+
+// recordedResponse is a recorded response and when it was served by JJJ
+type recordedResponse struct {
+	at       time.Time
+	response jjj.ResponseBody
+}
+
+// recording is a nowplaying.Source that serves the recorded responses as if they were JJJ, using the clock to decide which one is current
+type recording struct {
+	clock     queue.Clock
+	responses []recordedResponse
+}
+
+// newRecording orders the responses by their last_updated time, which every response needs to have
+func newRecording(clock queue.Clock, responses []jjj.ResponseBody) (*recording, error) {
+	r := &recording{clock: clock}
+	for i, response := range responses {
+		at, err := time.Parse(time.RFC3339, response.LastUpdated)
+		if err != nil {
+			return nil, fmt.Errorf("response %d has an invalid last_updated time: %w", i, err)
+		}
+		r.responses = append(r.responses, recordedResponse{at: at, response: response})
+	}
+	sort.SliceStable(r.responses, func(i, j int) bool {
+		return r.responses[i].at.Before(r.responses[j].at)
+	})
+	return r, nil
+}
+
+// NowPlaying returns the latest response that JJJ would have been serving at the clock's time
+func (r *recording) NowPlaying() (*jjj.ResponseBody, error) {
+	now := r.clock.Now()
+	var current *jjj.ResponseBody
+	for i := range r.responses {
+		if r.responses[i].at.After(now) {
+			break
+		}
+		current = &r.responses[i].response
+	}
+	if current == nil {
+		return nil, errors.New("no recorded response at " + now.Format(time.RFC3339))
+	}
+	return current, nil
+}
+
+// RecentPlays returns the plays from the responses JJJ would have served by the clock's time
+func (r *recording) RecentPlays(since time.Time) ([]jjj.Play, error) {
+	now := r.clock.Now()
+	var served []jjj.ResponseBody
+	for _, recorded := range r.responses {
+		if recorded.at.After(now) {
+			break
+		}
+		served = append(served, recorded.response)
+	}
+	return nowplaying.Plays(served, since), nil
+}
+
+// start is when the first response was served
+func (r *recording) start() time.Time {
+	return r.responses[0].at
+}
+
+// end is when JJJ would have updated after the last response
+func (r *recording) end() time.Time {
+	last := r.responses[len(r.responses)-1]
+	nextUpdated, err := time.Parse(time.RFC3339, last.response.NextUpdated)
+	if err != nil || !nextUpdated.After(last.at) {
+		return last.at.Add(replayTail)
+	}
+	return nextUpdated
+}
+
+// lookupStored finds the song in the Store by its name and artist so the votes for it are counted, otherwise it gives
+// the song an ID made from its name and artist so replays don't need Spotify
+func lookupStored(s *types.Song, play *jjj.Play) error {
+	songs, err := types.Store.ListSongs()
+	if err != nil {
+		return err
+	}
+
+	for _, stored := range songs {
+		if strings.EqualFold(stored.Name, s.Name) && strings.EqualFold(stored.Artist, s.Artist) {
+			s.SongID = stored.SongID
+			return nil
+		}
+	}
+
+	sum := sha1.Sum([]byte(strings.ToLower(s.SearchString())))
+	s.SongID = "sim" + hex.EncodeToString(sum[:])[:19]
+	return nil
+}
+
+// currentCountdownID returns the current countdown in the Store, creating a live one if there isn't one yet
+func currentCountdownID() (string, error) {
+	countdownID, err := types.CurrentCountdownID()
+	if err != types.ErrNoCurrentCountdown {
+		return countdownID, err
+	}
+
+	countdown := types.Countdown{Name: "Simulation", State: types.CountdownLive, Current: true}
+	if _, err = countdown.Create(); err != nil {
+		return "", err
+	}
+	return countdown.CountdownID, nil
+}
+
+// userPoints returns the points in the countdown of every user who has voted for a song in the Store
+func userPoints(countdownID string) (map[string]types.User, error) {
+	songs, err := types.Store.ListSongs()
+	if err != nil {
+		return nil, err
+	}
+
+	users := map[string]types.User{}
+	for _, song := range songs {
+		voters, err := song.Voters(countdownID)
+		if err != nil {
+			return nil, err
+		}
+		for _, userID := range voters {
+			if _, ok := users[userID]; ok {
+				continue
+			}
+			user, err := types.Store.GetUser(userID)
+			if err != nil {
+				return nil, err
+			}
+			if user == nil {
+				user = &types.User{UserID: userID}
+			}
+			if user.Points, err = types.Store.GetUserPoints(countdownID, userID); err != nil {
+				return nil, err
+			}
+			users[userID] = *user
+		}
+	}
+	return users, nil
+}
+
+// Replay runs the responses through the chune-machine, bean-counter and score-taker with a virtual clock that starts
+// at the first response, and reports the songs that were played and the points each user earned. It uses the Store
+// as it is, so it should be a MemoryStorage or a copy of the table.
+func Replay(ctx context.Context, responses []jjj.ResponseBody) (*Report, error) {
+	if len(responses) == 0 {
+		return nil, errors.New("there are no responses to replay")
+	}
+
+	clock := queue.NewVirtualClock(time.Time{})
+	rec, err := newRecording(clock, responses)
+	if err != nil {
+		return nil, err
+	}
+	clock.Set(rec.start())
+
+	// swap the chune-machine's JJJ and Spotify lookups for the recording
+	source, lookupSong, chuneClock := nowplaying.Current, chuneMachine.LookupSong, chuneMachine.Clock
+	nowplaying.Current, chuneMachine.LookupSong, chuneMachine.Clock = rec, lookupStored, clock
+	defer func() {
+		nowplaying.Current, chuneMachine.LookupSong, chuneMachine.Clock = source, lookupSong, chuneClock
+	}()
+
+	countdownID, err := currentCountdownID()
+	if err != nil {
+		return nil, err
+	}
+
+	// the chune-machine only credits plays while the countdown is live
+	countdown := types.Countdown{CountdownID: countdownID}
+	if _, err = countdown.Get(); err != nil {
+		return nil, err
+	}
+	if state := countdown.StateAt(clock.Now()); state != types.CountdownLive {
+		return nil, fmt.Errorf("the current countdown is %s, it needs to be live to replay plays", state)
+	}
+
+	before, err := userPoints(countdownID)
+	if err != nil {
+		return nil, err
+	}
+	playedBefore, err := types.Store.GetPlayedSongIDs(countdownID)
+	if err != nil {
+		return nil, err
+	}
+
+	broker := pipeline.New(clock)
+	if err = queue.ChuneRefresh.Send(types.ChuneRefreshBody{}, 0); err != nil {
+		return nil, err
+	}
+	delivered := broker.RunUntil(ctx, rec.end())
+	logger.Log.Info().Int("responses", len(responses)).Int("delivered", delivered).Msg("Finished replaying the recording")
+
+	return report(countdownID, before, len(playedBefore))
+}
+
+// report compares the users points to what they were before the replay and lists the songs played during it
+func report(countdownID string, before map[string]types.User, playedBefore int) (*Report, error) {
+	playedSongIDs, err := types.Store.GetPlayedSongIDs(countdownID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Report{Songs: []PlayedSong{}, Points: []UserPoints{}}
+	for _, songID := range playedSongIDs[playedBefore:] {
+		song, err := types.Store.GetSong(songID)
+		if err != nil {
+			return nil, err
+		}
+		if song == nil {
+			continue
+		}
+		if err = song.GetPlay(countdownID); err != nil {
+			return nil, err
+		}
+		if song.PlayOrder == nil {
+			continue
+		}
+		played := PlayedSong{PlayOrder: *song.PlayOrder, SongID: song.SongID, Name: song.Name, Artist: song.Artist}
+		if song.PlayedPosition != nil {
+			played.Position = *song.PlayedPosition
+		}
+		if song.PlayedAt != nil {
+			played.PlayedAt = *song.PlayedAt
+		}
+		r.Songs = append(r.Songs, played)
+	}
+
+	after, err := userPoints(countdownID)
+	if err != nil {
+		return nil, err
+	}
+	for userID, user := range after {
+		r.Points = append(r.Points, UserPoints{UserID: userID, Name: user.Name, Points: user.Points - before[userID].Points})
+	}
+	sort.Slice(r.Points, func(i, j int) bool {
+		if r.Points[i].Points != r.Points[j].Points {
+			return r.Points[i].Points > r.Points[j].Points
+		}
+		return r.Points[i].UserID < r.Points[j].UserID
+	})
+	return r, nil
+}
@@ -0,0 +1,45 @@
+package simulator
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/nowplaying"
+	"jjj.rflett.com/jjj-api/types"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	types.UseTestStorage()
+	responses, err := nowplaying.Load("testdata/countdown.jsonl")
+	assert.Nil(t, err)
+
+	report, err := Replay(context.Background(), responses)
+	assert.Nil(t, err)
+
+	if assert.Len(t, report.Songs, 2) {
+		assert.Equal(t, "Other Song", report.Songs[0].Name)
+		assert.Equal(t, 1, report.Songs[0].PlayOrder)
+		assert.Equal(t, 100, report.Songs[0].Position)
+		assert.Equal(t, types.TestSongID, report.Songs[1].SongID)
+		assert.Equal(t, 2, report.Songs[1].PlayOrder)
+		assert.Equal(t, 99, report.Songs[1].Position)
+		assert.Equal(t, "2022-01-22T12:02:45+11:00", report.Songs[1].PlayedAt)
+	}
+	assert.Equal(t, []UserPoints{{UserID: types.TestAuthProviderUserID, Name: types.TestAuthProviderName, Points: 2}}, report.Points)
+}
+
+func TestReplayWithVotes(t *testing.T) {
+	types.UseTestStorage()
+	votes, err := LoadVotes("testdata/votes.json")
+	assert.Nil(t, err)
+	assert.Nil(t, SeedVotes(votes))
+
+	responses, _ := nowplaying.Load("testdata/countdown.jsonl")
+	report, err := Replay(context.Background(), responses)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []UserPoints{
+		{UserID: "c3f1a8e2-5b7d-4e9a-8c2f-1d6b0e4a7f93", Points: 3},
+		{UserID: types.TestAuthProviderUserID, Name: types.TestAuthProviderName, Points: 2},
+	}, report.Points)
+}
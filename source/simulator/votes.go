@@ -0,0 +1,45 @@
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/types"
+)
+
+// LoadVotes reads a JSON file of userIDs and the songs they voted for, with the same fields as createVote
+func LoadVotes(path string) (map[string][]types.Song, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := map[string][]types.Song{}
+	if err = json.Unmarshal(data, &votes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return votes, nil
+}
+
+// SeedVotes adds the votes to the Store's current countdown, creating the songs that don't exist yet
+func SeedVotes(votes map[string][]types.Song) error {
+	countdownID, err := currentCountdownID()
+	if err != nil {
+		return err
+	}
+
+	for userID, songs := range votes {
+		user := types.User{UserID: userID}
+		for i := range songs {
+			song := songs[i]
+			if song.Rank == nil {
+				rank := i + 1
+				song.Rank = &rank
+			}
+			if _, err := user.AddVote(countdownID, &song); err != nil {
+				return fmt.Errorf("unable to add vote for %s by %s: %w", song.SongID, userID, err)
+			}
+		}
+	}
+	return nil
+}
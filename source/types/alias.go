@@ -0,0 +1,303 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"net/http"
+	"time"
+)
+
+// SongAlias is another ID a song is known by, like the ID of a different Spotify version of the song or its ISRC
+type SongAlias struct {
+	PK        string `json:"-" dynamodbav:"PK"`
+	SK        string `json:"-" dynamodbav:"SK"`
+	SongID    string `json:"songID"`
+	Provider  string `json:"provider"`
+	AliasID   string `json:"aliasID"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// return the partition key value for an alias, which is the song it belongs to
+func (a *SongAlias) PKVal() string {
+	return fmt.Sprintf("%s#%s", SongPartitionKey, a.SongID)
+}
+
+// return the sort key value for an alias
+func (a *SongAlias) SKVal() string {
+	return AliasSortKey(a.Provider, a.AliasID)
+}
+
+// AliasSortKey is the sort key of an alias, it's the partition key of the GSI so aliases can be looked up
+func AliasSortKey(provider string, aliasID string) string {
+	return fmt.Sprintf("%s#%s#%s", SongAliasSortKey, provider, aliasID)
+}
+
+// ResolveSongID returns the ID of the song the alias belongs to, or an empty string if it doesn't belong to one
+func ResolveSongID(provider string, aliasID string) (string, error) {
+	if aliasID == "" {
+		return "", nil
+	}
+
+	alias, err := Store.GetSongAlias(provider, aliasID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("provider", provider).Str("aliasID", aliasID).Msg("Unable to look up song alias")
+		return "", err
+	}
+	if alias == nil {
+		return "", nil
+	}
+	return alias.SongID, nil
+}
+
+// Resolve swaps the SongID for the canonical song's ID if the SongID is the Spotify ID of another version of a song
+func (s *Song) Resolve() error {
+	songID, err := ResolveSongID(AliasProviderSpotify, s.SongID)
+	if err != nil {
+		return err
+	}
+	if songID != "" && songID != s.SongID {
+		logger.Log.Info().Str("songID", s.SongID).Str("canonicalID", songID).Msg("Resolved song to its canonical song")
+		s.SongID = songID
+	}
+	return nil
+}
+
+// AddAlias adds another ID the song is known by to its Aliases, they're stored with SaveAliases
+func (s *Song) AddAlias(provider string, aliasID string) {
+	if aliasID == "" {
+		return
+	}
+	for _, a := range s.Aliases {
+		if a.Provider == provider && a.AliasID == aliasID {
+			return
+		}
+	}
+	s.Aliases = append(s.Aliases, SongAlias{SongID: s.SongID, Provider: provider, AliasID: aliasID})
+}
+
+// SaveAliases stores the song's Aliases, aliases that already belong to a different song are left alone
+func (s *Song) SaveAliases() error {
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, a := range s.Aliases {
+		existing, err := ResolveSongID(a.Provider, a.AliasID)
+		if err != nil {
+			return err
+		}
+		if existing != "" {
+			if existing != s.SongID {
+				logger.Log.Warn().Str("songID", s.SongID).Str("existingSongID", existing).Str("aliasID", a.AliasID).Msg("Alias already belongs to another song")
+			}
+			continue
+		}
+
+		alias := SongAlias{SongID: s.SongID, Provider: a.Provider, AliasID: a.AliasID, CreatedAt: now}
+		alias.PK = alias.PKVal()
+		alias.SK = alias.SKVal()
+		if err = Store.PutSongAlias(&alias); err != nil {
+			logger.Log.Error().Err(err).Str("songID", s.SongID).Str("aliasID", a.AliasID).Msg("Unable to add song alias")
+			return err
+		}
+	}
+	return nil
+}
+
+// Voters returns the IDs of the users who voted for the song or any other Spotify version of it in the countdown
+func (s *Song) Voters(countdownID string) ([]string, error) {
+	votes, err := s.votes(countdownID)
+	if err != nil {
+		return nil, err
+	}
+	voters := make([]string, 0, len(votes))
+	for _, v := range votes {
+		voters = append(voters, v.UserID)
+	}
+	return voters, nil
+}
+
+// votes returns the votes for the song or any other Spotify version of it in the countdown, a user who voted for more
+// than one version keeps their best rank
+func (s *Song) votes(countdownID string) ([]songVote, error) {
+	songIDs := []string{s.SongID}
+	aliases, err := Store.GetSongAliases(s.SongID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range aliases {
+		if a.Provider == AliasProviderSpotify && a.AliasID != s.SongID {
+			songIDs = append(songIDs, a.AliasID)
+		}
+	}
+
+	seen := map[string]int{}
+	var votes []songVote
+	for _, songID := range songIDs {
+		songVotes, err := Store.GetVoters(countdownID, songID)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range songVotes {
+			i, ok := seen[v.UserID]
+			if !ok {
+				seen[v.UserID] = len(votes)
+				votes = append(votes, v)
+			} else if v.Rank > 0 && (votes[i].Rank == 0 || v.Rank < votes[i].Rank) {
+				votes[i].Rank = v.Rank
+			}
+		}
+	}
+	return votes, nil
+}
+
+// mergedCountdown is who voted for each version of a merged song in a countdown, and where each was played
+type mergedCountdown struct {
+	countdown       Countdown
+	voters          []string
+	duplicateVoters []string
+	play            *SongPlay
+	duplicatePlay   *SongPlay
+}
+
+// Merge makes the duplicate song an alias of this one by moving its aliases across. In each countdown where only one
+// of the songs has been played this song takes the played position, and the voters who missed out on points because
+// they voted for the other version are returned with the points they're owed. Songs that have both been played in a
+// countdown can't be merged.
+func (s *Song) Merge(duplicateID string) (owed []ScoreTakerBody, status int, error error) {
+	if duplicateID == "" || duplicateID == s.SongID {
+		return nil, http.StatusBadRequest, errors.New("a song can't be merged with itself")
+	}
+	if err := s.Get(); err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	duplicate := Song{SongID: duplicateID}
+	if err := duplicate.Get(); err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	if s.MergedInto != nil || duplicate.MergedInto != nil {
+		return nil, http.StatusConflict, errors.New("the song has already been merged")
+	}
+
+	// who voted for each version in every countdown before the aliases are moved
+	countdowns, err := Store.GetCountdowns()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	merged := make([]mergedCountdown, 0, len(countdowns))
+	for _, c := range countdowns {
+		m := mergedCountdown{countdown: c}
+		if m.voters, err = s.Voters(c.CountdownID); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if m.duplicateVoters, err = duplicate.Voters(c.CountdownID); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if m.play, err = Store.GetSongPlay(c.CountdownID, s.SongID); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		if m.duplicatePlay, err = Store.GetSongPlay(c.CountdownID, duplicateID); err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		// the voters of both were scored for each play, which merging can't settle
+		if m.play != nil && m.duplicatePlay != nil {
+			return nil, http.StatusConflict, fmt.Errorf("both songs have been played in %s", c.Name)
+		}
+		merged = append(merged, m)
+	}
+
+	// move the duplicate's aliases, and the duplicate itself, across to this song
+	removed, err := Store.GetSongAliases(duplicateID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	s.Aliases = nil
+	s.AddAlias(AliasProviderSpotify, duplicateID)
+	for _, a := range removed {
+		s.AddAlias(a.Provider, a.AliasID)
+	}
+	added := make([]SongAlias, 0, len(s.Aliases))
+	for _, a := range s.Aliases {
+		alias := SongAlias{SongID: s.SongID, Provider: a.Provider, AliasID: a.AliasID, CreatedAt: now}
+		alias.PK = alias.PKVal()
+		alias.SK = alias.SKVal()
+		added = append(added, alias)
+	}
+	err = Store.MergeSong(duplicateID, s.SongID, removed, added)
+	if err == ErrConditionalCheckFailed {
+		return nil, http.StatusConflict, errors.New("the song has already been merged")
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Str("songID", duplicateID).Str("canonicalID", s.SongID).Msg("Unable to merge the songs")
+		return nil, http.StatusInternalServerError, err
+	}
+
+	// the voters of whichever version wasn't played are owed the points
+	for _, m := range merged {
+		var play *SongPlay
+		var missedOut []string
+		switch {
+		case m.play == nil && m.duplicatePlay != nil:
+			copied := *m.duplicatePlay
+			copied.SK, copied.SongID = songPlaySK(s.SongID), s.SongID
+			if err = Store.SetSongPlayed(&copied); err != nil {
+				logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Unable to copy the played position to the merged song")
+				return nil, http.StatusInternalServerError, err
+			}
+			play, missedOut = &copied, difference(m.voters, m.duplicateVoters)
+		case m.play != nil && m.duplicatePlay == nil:
+			play, missedOut = m.play, difference(m.duplicateVoters, m.voters)
+		}
+		if len(missedOut) == 0 {
+			continue
+		}
+
+		// score the play with the votes for both versions, now they're the same song
+		played := Song{SongID: s.SongID}
+		played.setPlay(play)
+		awards, err := m.countdown.Awards(&played)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		owedUsers := map[string]bool{}
+		for _, userID := range missedOut {
+			owedUsers[userID] = true
+		}
+		for i := range awards {
+			if owedUsers[awards[i].UserID] {
+				a := awards[i]
+				owed = append(owed, ScoreTakerBody{CountdownID: a.CountdownID, UserID: a.UserID, Points: a.Points, Award: &a})
+			}
+		}
+	}
+	logger.Log.Info().Str("songID", s.SongID).Str("duplicateID", duplicateID).Int("owed", len(owed)).Msg("Merged songs")
+	return owed, http.StatusOK, nil
+}
+
+// DeleteAliases deletes every alias of the song
+func (s *Song) DeleteAliases() error {
+	aliases, err := Store.GetSongAliases(s.SongID)
+	if err != nil {
+		return err
+	}
+	for _, a := range aliases {
+		if err = Store.DeleteSongAlias(&a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// difference returns the IDs in a that aren't in b
+func difference(a []string, b []string) []string {
+	inB := map[string]bool{}
+	for _, id := range b {
+		inB[id] = true
+	}
+	var diff []string
+	for _, id := range a {
+		if !inB[id] {
+			diff = append(diff, id)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,152 @@
+package types
+
+import (
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/scoring"
+	"time"
+)
+
+// Award is the points a user got for a song they voted for that was played in a countdown, and the rules that gave them.
+// It's the user's ledger entry for the song, their points change when a new Revision of it is recorded.
+type Award struct {
+	PK          string          `json:"-" dynamodbav:"PK"`
+	SK          string          `json:"-" dynamodbav:"SK"`
+	CountdownID string          `json:"countdownID"`
+	UserID      string          `json:"userID"`
+	SongID      string          `json:"songID"`
+	Position    int             `json:"position"`
+	Rank        int             `json:"rank"`
+	Points      int             `json:"points"`
+	Rules       []scoring.Award `json:"rules"`
+	AwardedAt   string          `json:"awardedAt"`
+	Revision    int             `json:"revision"`
+	Correction  int             `json:"correction,omitempty"` // Correction is how many times the play's position had been corrected when it was scored
+}
+
+// return the partition key value for an award
+func (a *Award) PKVal() string {
+	return fmt.Sprintf("%s#%s", UserPartitionKey, a.UserID)
+}
+
+// return the sort key value for an award, without the SongID it's the prefix of every award in the countdown
+func (a *Award) SKVal() string {
+	return fmt.Sprintf("%s#%s#%s", AwardSortKey, a.CountdownID, a.SongID)
+}
+
+// Record saves the award and updates the user's points by the difference from the previous revision, exactly once. It
+// returns false when the award has already been recorded, like when the scorer's message is delivered twice, and an
+// error when the revision before it hasn't been recorded yet so the message is retried. A correction's revision is the
+// one after the latest recorded award, unless that was scored for the same or a later correction.
+func (a *Award) Record() (bool, error) {
+	if a.Correction > 0 {
+		return a.recordCorrection()
+	}
+	if a.Revision < 1 {
+		a.Revision = 1
+	}
+	a.PK = a.PKVal()
+	a.SK = a.SKVal()
+
+	previousPoints := 0
+	if a.Revision > 1 {
+		previous, err := Store.GetAward(a.CountdownID, a.UserID, a.SongID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("userID", a.UserID).Str("songID", a.SongID).Msg("Unable to get the previous award")
+			return false, err
+		}
+		if previous != nil && previous.Revision >= a.Revision {
+			logger.Log.Info().Str("userID", a.UserID).Str("songID", a.SongID).Int("revision", a.Revision).Msg("The award has already been recorded")
+			return false, nil
+		}
+		if previous == nil || previous.Revision != a.Revision-1 {
+			return false, fmt.Errorf("revision %d of the award can't be recorded before the one before it", a.Revision)
+		}
+		previousPoints = previous.Points
+	}
+
+	err := Store.RecordAward(a, a.Points-previousPoints)
+	if err == ErrConditionalCheckFailed {
+		logger.Log.Info().Str("userID", a.UserID).Str("songID", a.SongID).Int("revision", a.Revision).Msg("The award has already been recorded")
+		return false, nil
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", a.UserID).Str("songID", a.SongID).Msg("Unable to record the award")
+		return false, err
+	}
+	return true, nil
+}
+
+// recordCorrection saves the corrected award as the next revision of the latest one and updates the user's points by
+// the difference. Corrections can be queued before the ones before them are recorded, so they're only ordered by when
+// they were made and one that's older than the recorded award is skipped.
+func (a *Award) recordCorrection() (bool, error) {
+	a.PK = a.PKVal()
+	a.SK = a.SKVal()
+	previous, err := Store.GetAward(a.CountdownID, a.UserID, a.SongID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", a.UserID).Str("songID", a.SongID).Msg("Unable to get the previous award")
+		return false, err
+	}
+	if previous != nil && previous.Correction >= a.Correction {
+		logger.Log.Info().Str("userID", a.UserID).Str("songID", a.SongID).Int("correction", a.Correction).Msg("The correction has already been recorded")
+		return false, nil
+	}
+
+	previousPoints := 0
+	a.Revision = 1
+	if previous != nil {
+		previousPoints = previous.Points
+		a.Revision = previous.Revision + 1
+	}
+
+	// an award recorded since it was got fails the condition, so the message is retried against it
+	if err = Store.RecordAward(a, a.Points-previousPoints); err != nil {
+		logger.Log.Error().Err(err).Str("userID", a.UserID).Str("songID", a.SongID).Int("revision", a.Revision).Msg("Unable to record the corrected award")
+		return false, err
+	}
+	return true, nil
+}
+
+// ScoringRules returns the rules the countdown is scored with
+func (c *Countdown) ScoringRules() []scoring.Config {
+	if len(c.Scoring) == 0 {
+		return scoring.Default
+	}
+	return c.Scoring
+}
+
+// Awards scores the song's play for everyone who voted for it, or any other version of it, in the countdown. Songs
+// played outside of the countdown don't score anything.
+func (c *Countdown) Awards(s *Song) ([]Award, error) {
+	if s.PlayedPosition == nil {
+		return nil, nil
+	}
+	votes, err := s.votes(c.CountdownID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	awards := make([]Award, 0, len(votes))
+	for _, v := range votes {
+		hit := scoring.Hit{Position: *s.PlayedPosition, Length: c.length(), Rank: v.Rank}
+		rules, points, err := scoring.Score(c.ScoringRules(), hit)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Unable to score the play")
+			return nil, err
+		}
+		awards = append(awards, Award{
+			CountdownID: c.CountdownID,
+			UserID:      v.UserID,
+			SongID:      s.SongID,
+			Position:    hit.Position,
+			Rank:        v.Rank,
+			Points:      points,
+			Rules:       rules,
+			AwardedAt:   now,
+			Revision:    1,
+		})
+	}
+	return awards, nil
+}
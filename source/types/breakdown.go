@@ -0,0 +1,189 @@
+package types
+
+import (
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/scoring"
+	"sort"
+)
+
+// VotedSong is one of a user's votes and what it has scored them
+type VotedSong struct {
+	SongID    string          `json:"songID"`
+	Name      string          `json:"name"`
+	Artist    string          `json:"artist"`
+	Rank      int             `json:"rank"`
+	Played    bool            `json:"played"`
+	PlayOrder *int            `json:"playOrder"`
+	Position  *int            `json:"position"` // Position is nil when the song hasn't been played, or was played outside the countdown
+	Points    int             `json:"points"`
+	Rules     []scoring.Award `json:"rules"`
+	MaxPoints *int            `json:"maxPoints,omitempty"` // MaxPoints is the most the song can still score, when it hasn't been played
+}
+
+// PointsBreakdown is where a user's points in a countdown came from, and how many more they could still get
+type PointsBreakdown struct {
+	UserID        string      `json:"userID"`
+	CountdownID   string      `json:"countdownID"`
+	Points        int         `json:"points"`
+	MaxAchievable int         `json:"maxAchievable"` // MaxAchievable is the most their unplayed votes can score between them
+	Songs         []VotedSong `json:"songs"`
+}
+
+// maxAchievable is the most the unplayed votes can score between them when each is played in a different open position.
+// scores has each vote's points at each open position. Votes are limited by VoteLimit, so every combination of them is
+// tried one position at a time.
+func maxAchievable(scores [][]int) int {
+	if len(scores) == 0 {
+		return 0
+	}
+	best := make([]int, 1<<len(scores)) // best is the most each set of votes can score in the positions so far
+	for set := 1; set < len(best); set++ {
+		best[set] = -1
+	}
+	for p := range scores[0] {
+		// larger sets are updated from smaller ones, so going down uses each position once
+		for set := len(best) - 1; set >= 0; set-- {
+			if best[set] < 0 {
+				continue
+			}
+			for v := range scores {
+				with := set | 1<<v
+				if with != set && best[set]+scores[v][p] > best[with] {
+					best[with] = best[set] + scores[v][p]
+				}
+			}
+		}
+	}
+
+	most := 0
+	for _, points := range best {
+		if points > most {
+			most = points
+		}
+	}
+	return most
+}
+
+// PointsBreakdown lists the user's votes in the countdown with what each has scored them. The most their unplayed
+// votes can still score is worked out with the countdown's current rules in the positions that haven't been played.
+func (u *User) PointsBreakdown(countdownID string) (*PointsBreakdown, error) {
+	countdown := Countdown{CountdownID: countdownID}
+	if _, err := countdown.Get(); err != nil {
+		return nil, err
+	}
+	votes, err := Store.GetVotes(countdownID, u.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to get the user's votes")
+		return nil, err
+	}
+	awards, err := Store.GetAwards(countdownID, u.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to get the user's awards")
+		return nil, err
+	}
+	plays, err := Store.GetSongPlays(countdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", countdownID).Msg("Unable to get the countdown's plays")
+		return nil, err
+	}
+
+	sort.SliceStable(votes, func(i, j int) bool {
+		return votes[i].Rank < votes[j].Rank
+	})
+
+	songIDs := make([]string, 0, len(votes))
+	for _, v := range votes {
+		songIDs = append(songIDs, v.SongID)
+	}
+	songs, err := Store.GetSongs(songIDs)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to get the songs the user voted for")
+		return nil, err
+	}
+	songsByID := map[string]Song{}
+	for _, s := range songs {
+		songsByID[s.SongID] = s
+	}
+	awardsByID := map[string]Award{}
+	for _, a := range awards {
+		awardsByID[a.SongID] = a
+	}
+	playsByID := map[string]SongPlay{}
+	taken := map[int]bool{}
+	for _, p := range plays {
+		playsByID[p.SongID] = p
+		taken[p.Position] = true
+	}
+	var open []int
+	for position := 1; position <= countdown.length(); position++ {
+		if !taken[position] {
+			open = append(open, position)
+		}
+	}
+
+	breakdown := &PointsBreakdown{UserID: u.UserID, CountdownID: countdownID, Songs: make([]VotedSong, 0, len(votes))}
+	var unplayed [][]int
+	for _, v := range votes {
+		s := songsByID[v.SongID]
+		vs := VotedSong{SongID: v.SongID, Name: s.Name, Artist: s.Artist, Rank: v.Rank, Rules: []scoring.Award{}}
+
+		// a duplicate song is played and scored as the song it was merged into
+		playedID := v.SongID
+		if s.MergedInto != nil {
+			playedID = *s.MergedInto
+		}
+		if a, ok := awardsByID[playedID]; ok {
+			vs.Points, vs.Rules = a.Points, a.Rules
+			delete(awardsByID, playedID)
+		}
+		if p, ok := playsByID[playedID]; ok {
+			vs.Played = true
+			playOrder := p.PlayOrder
+			vs.PlayOrder = &playOrder
+			if p.Position > 0 {
+				position := p.Position
+				vs.Position = &position
+			}
+		} else {
+			scores := make([]int, len(open))
+			most := 0
+			for i, position := range open {
+				if _, scores[i], err = scoring.Score(countdown.ScoringRules(), scoring.Hit{Position: position, Length: countdown.length(), Rank: v.Rank}); err != nil {
+					logger.Log.Error().Err(err).Str("countdownID", countdownID).Msg("Unable to score the vote")
+					return nil, err
+				}
+				if scores[i] > most {
+					most = scores[i]
+				}
+			}
+			vs.MaxPoints = &most
+			unplayed = append(unplayed, scores)
+		}
+		breakdown.Points += vs.Points
+		breakdown.Songs = append(breakdown.Songs, vs)
+	}
+
+	// the rest were for another version of a song they voted for, which was played instead
+	for _, a := range awards {
+		if _, ok := awardsByID[a.SongID]; !ok {
+			continue
+		}
+		vs := VotedSong{SongID: a.SongID, Rank: a.Rank, Played: true, Points: a.Points, Rules: a.Rules}
+		s, err := Store.GetSong(a.SongID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("songID", a.SongID).Msg("Unable to get the song")
+			return nil, err
+		}
+		if s != nil {
+			vs.Name, vs.Artist = s.Name, s.Artist
+		}
+		if p, ok := playsByID[a.SongID]; ok {
+			playOrder, position := p.PlayOrder, p.Position
+			vs.PlayOrder, vs.Position = &playOrder, &position
+		}
+		breakdown.Points += vs.Points
+		breakdown.Songs = append(breakdown.Songs, vs)
+	}
+	breakdown.MaxAchievable = maxAchievable(unplayed)
+	return breakdown, nil
+}
@@ -0,0 +1,519 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"hash/fnv"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/scoring"
+	"net/http"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrNoCurrentCountdown is returned when a countdown hasn't been made the current one yet
+	ErrNoCurrentCountdown = errors.New("there is no current countdown")
+	// ErrVotingClosed is returned when votes are changed outside of the countdown's voting window
+	ErrVotingClosed = errors.New("voting is not open for the countdown")
+)
+
+// countdownStates are the states of a countdown in order
+var countdownStates = []string{CountdownDraft, CountdownVotingOpen, CountdownVotingClosed, CountdownLive, CountdownFinished}
+
+// countdownTransitions are the states an admin can move a countdown to from each state, voting can be reopened
+var countdownTransitions = map[string][]string{
+	CountdownDraft:        {CountdownVotingOpen},
+	CountdownVotingOpen:   {CountdownVotingClosed},
+	CountdownVotingClosed: {CountdownVotingOpen, CountdownLive},
+	CountdownLive:         {CountdownFinished},
+}
+
+// countdownNextState is the state a countdown's schedule moves it to from each state
+var countdownNextState = map[string]string{
+	CountdownDraft:        CountdownVotingOpen,
+	CountdownVotingOpen:   CountdownVotingClosed,
+	CountdownVotingClosed: CountdownLive,
+	CountdownLive:         CountdownFinished,
+}
+
+// CountdownSchedule is when a countdown moves to each state by itself, as RFC3339 times
+type CountdownSchedule struct {
+	VotingOpens  string `json:"votingOpens,omitempty"`
+	VotingCloses string `json:"votingCloses,omitempty"`
+	Live         string `json:"live,omitempty"`
+	Finished     string `json:"finished,omitempty"`
+}
+
+// Countdown is a countdown like the Hottest 100 of a year, it scopes the votes, plays and points
+type Countdown struct {
+	PK          string            `json:"-" dynamodbav:"PK"`
+	SK          string            `json:"-" dynamodbav:"SK"`
+	CountdownID string            `json:"countdownID"`
+	Name        string            `json:"name"`
+	Year        int               `json:"year"`
+	State       string            `json:"state"`
+	Schedule    CountdownSchedule `json:"schedule"`
+	Length      int               `json:"length"`         // Length is how many songs are counted down, like 100 or 200
+	Direction   string            `json:"direction"`      // Direction is whether the positions count down to #1 or up from it
+	Offset      int               `json:"positionOffset"` // Offset moves the positions of the next plays when JJJ skips or repeats one
+	Scoring     []scoring.Config  `json:"scoring"`        // Scoring is the rules the countdown is scored with, see ScoringRules
+	Current     bool              `json:"current" dynamodbav:"-"`
+	CreatedAt   string            `json:"createdAt"`
+}
+
+// currentCountdown is the item that points at the countdown that's being voted on or played
+type currentCountdown struct {
+	PK          string `dynamodbav:"PK"`
+	SK          string `dynamodbav:"SK"`
+	CountdownID string `dynamodbav:"CountdownID"`
+}
+
+// SongPlay is when and where a song was played in a countdown. PlayOrder is the order it was played in, and Position is
+// its place in the countdown, which is 0 for plays outside of it.
+type SongPlay struct {
+	PK          string `json:"-" dynamodbav:"PK"`
+	SK          string `json:"-" dynamodbav:"SK"`
+	CountdownID string `json:"countdownID"`
+	SongID      string `json:"songID"`
+	PlayedAt    string `json:"playedAt"`
+	PlayOrder   int    `json:"playOrder"`
+	Position    int    `json:"position"`
+	Corrections int    `json:"corrections"` // Corrections is how many times its position has been corrected
+}
+
+// userPoints are the points a user has in a countdown
+type userPoints struct {
+	PK          string `dynamodbav:"PK"`
+	SK          string `dynamodbav:"SK"`
+	CountdownID string `dynamodbav:"CountdownID"`
+	UserID      string `dynamodbav:"UserID"`
+	Points      int    `dynamodbav:"Points"`
+	Hits        int    `dynamodbav:"Hits"` // Hits is how many of the user's songs have been played
+
+	// LeaderboardPK puts the points in a shard of the countdown's leaderboard, sorted by the LeaderboardGSI
+	LeaderboardPK string `dynamodbav:"LeaderboardPK,omitempty"`
+}
+
+// return the partition key value for a countdown
+func (c *Countdown) PKVal() string {
+	return CountdownPartitionKey
+}
+
+// return the sort key value for a countdown
+func (c *Countdown) SKVal() string {
+	return fmt.Sprintf("%s#%s", CountdownSortKey, c.CountdownID)
+}
+
+// countdownPK returns the partition key of the items a countdown scopes, like its plays and play count
+func countdownPK(countdownID string) string {
+	return fmt.Sprintf("%s#%s", CountdownPartitionKey, countdownID)
+}
+
+// songPlaySK returns the sort key of a song's play in a countdown
+func songPlaySK(songID string) string {
+	return fmt.Sprintf("%s#%s", SongPlaySortKey, songID)
+}
+
+// voteSK returns the sort key of a user's vote for a song in a countdown
+func voteSK(countdownID string, songID string) string {
+	return fmt.Sprintf("%s#%s#%s#%s", CountdownPartitionKey, countdownID, SongPartitionKey, songID)
+}
+
+// pointsSK returns the sort key of a user's points in a countdown
+func pointsSK(countdownID string) string {
+	return fmt.Sprintf("%s#%s", UserPointsSortKey, countdownID)
+}
+
+// leaderboardShards is how many partitions a countdown's leaderboard is split over, so the points of everyone who voted
+// for a song can be written at once
+const leaderboardShards = 10
+
+// return the partition key of a shard of a countdown's leaderboard
+func leaderboardShardPK(countdownID string, shard int) string {
+	return fmt.Sprintf("%s#%s#%d", LeaderboardPartitionKey, countdownID, shard)
+}
+
+// return the partition key of the leaderboard shard a user's points are in
+func leaderboardPK(countdownID string, userID string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(userID))
+	return leaderboardShardPK(countdownID, int(h.Sum32()%leaderboardShards))
+}
+
+// Create the countdown and save it to the database, making it the current countdown if it's Current
+func (c *Countdown) Create() (status int, error error) {
+	// set fields
+	c.CountdownID = uuid.NewString()
+	c.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	if c.State == "" {
+		c.State = CountdownDraft
+	}
+	if c.Length == 0 {
+		c.Length = DefaultCountdownLength
+	}
+	if c.Direction == "" {
+		c.Direction = CountdownDescending
+	}
+	if c.Length < 0 {
+		return http.StatusBadRequest, errors.New("the countdown length can't be negative")
+	}
+	if c.Direction != CountdownDescending && c.Direction != CountdownAscending {
+		return http.StatusBadRequest, fmt.Errorf("%s is not a countdown direction", c.Direction)
+	}
+	if err := scoring.Validate(c.Scoring); err != nil {
+		return http.StatusBadRequest, err
+	}
+	if err := c.Schedule.validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	// add to table
+	if status, err := c.save(); err != nil {
+		return status, err
+	}
+
+	if c.Current {
+		if status, err := c.MakeCurrent(); err != nil {
+			return status, err
+		}
+	}
+
+	logger.Log.Info().Str("countdownID", c.CountdownID).Msg("Successfully added countdown to table")
+	return http.StatusCreated, nil
+}
+
+// Get the countdown from the table
+func (c *Countdown) Get() (status int, error error) {
+	result, err := Store.GetCountdown(c.CountdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Error getting countdown from table")
+		return http.StatusInternalServerError, err
+	}
+	if result == nil {
+		return http.StatusNotFound, errors.New("countdown not found")
+	}
+
+	currentID, err := Store.GetCurrentCountdownID()
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	*c = *result
+	c.Current = currentID == c.CountdownID
+	return http.StatusOK, nil
+}
+
+// MakeCurrent makes the countdown the one that's voted on and played, the previous countdowns are left as they are
+func (c *Countdown) MakeCurrent() (status int, error error) {
+	if err := Store.SetCurrentCountdownID(c.CountdownID); err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Unable to make the countdown current")
+		return http.StatusInternalServerError, err
+	}
+	c.Current = true
+	logger.Log.Info().Str("countdownID", c.CountdownID).Msg("Made the countdown current")
+	return http.StatusNoContent, nil
+}
+
+// CurrentCountdownID returns the ID of the current countdown, or ErrNoCurrentCountdown if there isn't one
+func CurrentCountdownID() (string, error) {
+	countdownID, err := Store.GetCurrentCountdownID()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the current countdown")
+		return "", err
+	}
+	if countdownID == "" {
+		return "", ErrNoCurrentCountdown
+	}
+	return countdownID, nil
+}
+
+// GetCountdowns returns every countdown in the state it's in now, newest first
+func GetCountdowns() ([]Countdown, error) {
+	countdowns, err := Store.GetCountdowns()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the countdowns")
+		return nil, err
+	}
+	currentID, err := Store.GetCurrentCountdownID()
+	if err != nil {
+		return nil, err
+	}
+
+	// show the state they're in now, the scheduled transitions are saved when the countdown is next used
+	now := time.Now()
+	for i := range countdowns {
+		countdowns[i].Current = countdowns[i].CountdownID == currentID
+		countdowns[i].State = countdowns[i].StateAt(now)
+	}
+	sort.SliceStable(countdowns, func(i, j int) bool {
+		if countdowns[i].Year != countdowns[j].Year {
+			return countdowns[i].Year > countdowns[j].Year
+		}
+		return countdowns[i].CreatedAt > countdowns[j].CreatedAt
+	})
+	return countdowns, nil
+}
+
+// at returns the time the schedule moves a countdown to the state, or nil if it isn't scheduled
+func (s *CountdownSchedule) at(state string) *time.Time {
+	var value string
+	switch state {
+	case CountdownVotingOpen:
+		value = s.VotingOpens
+	case CountdownVotingClosed:
+		value = s.VotingCloses
+	case CountdownLive:
+		value = s.Live
+	case CountdownFinished:
+		value = s.Finished
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// clear removes the scheduled time for the state
+func (s *CountdownSchedule) clear(state string) {
+	switch state {
+	case CountdownVotingOpen:
+		s.VotingOpens = ""
+	case CountdownVotingClosed:
+		s.VotingCloses = ""
+	case CountdownLive:
+		s.Live = ""
+	case CountdownFinished:
+		s.Finished = ""
+	}
+}
+
+// validate checks the scheduled times can be parsed and are in the order of the states
+func (s *CountdownSchedule) validate() error {
+	for _, value := range []string{s.VotingOpens, s.VotingCloses, s.Live, s.Finished} {
+		if _, err := time.Parse(time.RFC3339, value); value != "" && err != nil {
+			return fmt.Errorf("%s is not an RFC3339 time", value)
+		}
+	}
+
+	var previous *time.Time
+	for _, state := range countdownStates {
+		t := s.at(state)
+		if t == nil {
+			continue
+		}
+		if previous != nil && !t.After(*previous) {
+			return fmt.Errorf("the countdown is scheduled to be %s before the state before it", state)
+		}
+		previous = t
+	}
+	return nil
+}
+
+// StateAt returns the state the countdown is in at the time, after any scheduled transitions that are due
+func (c *Countdown) StateAt(now time.Time) string {
+	state := c.State
+	if state == "" {
+		state = CountdownDraft
+	}
+	for {
+		next, ok := countdownNextState[state]
+		if !ok {
+			return state
+		}
+		at := c.Schedule.at(next)
+		if at == nil || at.After(now) {
+			return state
+		}
+		state = next
+	}
+}
+
+// length returns how many songs are in the countdown, countdowns from before it could be set have 100
+func (c *Countdown) length() int {
+	if c.Length <= 0 {
+		return DefaultCountdownLength
+	}
+	return c.Length
+}
+
+// expectedPosition is the position of the play in the order it was played, without checking it's in the countdown
+func (c *Countdown) expectedPosition(playOrder int) int {
+	if c.Direction == CountdownAscending {
+		return playOrder + c.Offset
+	}
+	return c.length() - playOrder + 1 + c.Offset
+}
+
+// PositionOf returns the countdown position of the play in the order it was played, or 0 if it's outside the countdown
+func (c *Countdown) PositionOf(playOrder int) int {
+	position := c.expectedPosition(playOrder)
+	if position < 1 || position > c.length() {
+		return 0
+	}
+	return position
+}
+
+// PositionPoints returns the points for a song played at the position, the closer to #1 the more it's worth
+func (c *Countdown) PositionPoints(position int) int {
+	if position < 1 || position > c.length() {
+		return 0
+	}
+	return c.length() - position + 1
+}
+
+// CorrectPosition moves a song's play to the right position when JJJ skipped or repeated one. Correcting the most
+// recent play moves the positions of the plays after it as well. The voters are returned with their new awards and the
+// difference in points from what's recorded now, which is negative if they're worth less. The awards get their
+// revision when they're recorded, so a correction made before the last one was recorded still follows on from it.
+func (c *Countdown) CorrectPosition(songID string, position int) (owed []ScoreTakerBody, status int, error error) {
+	play, err := Store.GetSongPlay(c.CountdownID, songID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("songID", songID).Str("countdownID", c.CountdownID).Msg("Error getting song play from table")
+		return nil, http.StatusInternalServerError, err
+	}
+	if play == nil {
+		return nil, http.StatusNotFound, errors.New("the song hasn't been played in the countdown")
+	}
+	if position < 1 || position > c.length() {
+		return nil, http.StatusBadRequest, fmt.Errorf("the position needs to be between 1 and %d", c.length())
+	}
+
+	playCount, err := Store.GetPlayCount(c.CountdownID)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	if play.PlayOrder == playCount-1 {
+		c.Offset += position - c.expectedPosition(play.PlayOrder)
+		if status, err := c.save(); err != nil {
+			return nil, status, err
+		}
+	}
+
+	play.Position = position
+	play.Corrections++
+	if err = Store.PutSongPlay(play); err != nil {
+		logger.Log.Error().Err(err).Str("songID", songID).Msg("Unable to correct the play's position")
+		return nil, http.StatusInternalServerError, err
+	}
+	logger.Log.Info().Str("songID", songID).Str("countdownID", c.CountdownID).Int("position", position).Msg("Corrected the play's position")
+
+	// rescore the play, the score-taker settles the difference with the latest award when it records the correction
+	song := Song{SongID: songID}
+	song.setPlay(play)
+	awards, err := c.Awards(&song)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	for i := range awards {
+		a := awards[i]
+		previous, err := Store.GetAward(c.CountdownID, a.UserID, songID)
+		if err != nil {
+			return nil, http.StatusInternalServerError, err
+		}
+		points := a.Points
+		if previous != nil {
+			points -= previous.Points
+		}
+		a.Revision, a.Correction = 0, play.Corrections
+		owed = append(owed, ScoreTakerBody{CountdownID: c.CountdownID, UserID: a.UserID, Points: points, Award: &a})
+	}
+	return owed, http.StatusOK, nil
+}
+
+// save the countdown to the table
+func (c *Countdown) save() (status int, error error) {
+	c.PK = c.PKVal()
+	c.SK = c.SKVal()
+	if err := Store.PutCountdown(c); err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Error saving countdown to table")
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// Advance applies the scheduled transitions that are due and saves the countdown if its state changed
+func (c *Countdown) Advance(now time.Time) (status int, error error) {
+	state := c.StateAt(now)
+	if state == c.State {
+		return http.StatusOK, nil
+	}
+
+	logger.Log.Info().Str("countdownID", c.CountdownID).Str("from", c.State).Str("to", state).Msg("Countdown moved on schedule")
+	c.State = state
+	return c.save()
+}
+
+// Transition moves the countdown to the state if it's allowed from its current state. The scheduled transitions that
+// are already due are cleared so they don't undo it, like when voting is reopened after it was scheduled to close.
+func (c *Countdown) Transition(state string, now time.Time) (status int, error error) {
+	known := false
+	for _, s := range countdownStates {
+		known = known || s == state
+	}
+	if !known {
+		return http.StatusBadRequest, fmt.Errorf("%s is not a countdown state", state)
+	}
+
+	from := c.StateAt(now)
+	allowed := false
+	for _, to := range countdownTransitions[from] {
+		allowed = allowed || to == state
+	}
+	if !allowed {
+		return http.StatusConflict, fmt.Errorf("the countdown can't go from %s to %s", from, state)
+	}
+
+	for _, s := range countdownStates {
+		if at := c.Schedule.at(s); at != nil && !at.After(now) {
+			c.Schedule.clear(s)
+		}
+	}
+	c.State = state
+	if status, err := c.save(); err != nil {
+		return status, err
+	}
+
+	logger.Log.Info().Str("countdownID", c.CountdownID).Str("from", from).Str("to", state).Msg("Countdown transitioned")
+	return http.StatusOK, nil
+}
+
+// SetScoring replaces the rules the countdown is scored with, plays that have already been scored keep their points
+func (c *Countdown) SetScoring(rules []scoring.Config) (status int, error error) {
+	if err := scoring.Validate(rules); err != nil {
+		return http.StatusBadRequest, err
+	}
+	c.Scoring = rules
+	return c.save()
+}
+
+// SetSchedule replaces the countdown's scheduled transitions
+func (c *Countdown) SetSchedule(schedule CountdownSchedule) (status int, error error) {
+	if err := schedule.validate(); err != nil {
+		return http.StatusBadRequest, err
+	}
+	c.Schedule = schedule
+	return c.save()
+}
+
+// GetCurrentCountdown returns the current countdown with its scheduled transitions applied up to now
+func GetCurrentCountdown(now time.Time) (*Countdown, int, error) {
+	countdownID, err := CurrentCountdownID()
+	if err == ErrNoCurrentCountdown {
+		return nil, http.StatusNotFound, err
+	}
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+
+	countdown := &Countdown{CountdownID: countdownID}
+	if status, err := countdown.Get(); err != nil {
+		return nil, status, err
+	}
+	if status, err := countdown.Advance(now); err != nil {
+		return nil, status, err
+	}
+	return countdown, http.StatusOK, nil
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"jjj.rflett.com/jjj-api/logger"
+	"sort"
+)
+
+// SongPlayedBody is the message telling a voter their song was played and what it scored them
+func SongPlayedBody(a *Award, s *Song) CrierBody {
+	return CrierBody{
+		UserID:       a.UserID,
+		CountdownID:  a.CountdownID,
+		SongID:       a.SongID,
+		Position:     a.Position,
+		Points:       a.Points,
+		Notification: SongPlayedNotification(a, s),
+	}
+}
+
+// GroupAlerts returns a message for everyone who shares a group with the song's voters, saying whose pick it was. Each
+// member gets one message however many of their groups the voters are in, and voters are left out because they hear
+// about it with SongPlayedBody. Voters are only named to members through groups they haven't muted.
+func GroupAlerts(s *Song, awards []Award) ([]CrierBody, error) {
+	if s.PlayedPosition == nil || len(awards) == 0 {
+		return nil, nil
+	}
+
+	voterIDs := make([]string, 0, len(awards))
+	voted := map[string]bool{}
+	for _, a := range awards {
+		voterIDs = append(voterIDs, a.UserID)
+		voted[a.UserID] = true
+	}
+	voters, err := Store.GetUsers(voterIDs)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Unable to get the song's voters")
+		return nil, err
+	}
+
+	// the groups the voters are in, so a group a few of them share is only read once
+	var groupIDs []string
+	groupVoters := map[string][]User{}
+	for _, voter := range voters {
+		ids, err := Store.GetGroupIDs(voter.UserID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("userID", voter.UserID).Msg("Unable to get the user's groups")
+			return nil, err
+		}
+		for _, groupID := range ids {
+			if _, ok := groupVoters[groupID]; !ok {
+				groupIDs = append(groupIDs, groupID)
+			}
+			groupVoters[groupID] = append(groupVoters[groupID], voter)
+		}
+	}
+
+	// the voters each member shares a group with, each member's preferences are only got once
+	var memberIDs []string
+	pickedBy := map[string]map[string]string{} // member -> voter -> their name
+	preferences := map[string]*NotificationPreferences{}
+	for _, groupID := range groupIDs {
+		members, err := Store.GetMemberIDs(groupID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("groupID", groupID).Msg("Unable to get the group's members")
+			return nil, err
+		}
+		for _, memberID := range members {
+			if voted[memberID] {
+				continue
+			}
+			if _, ok := preferences[memberID]; !ok {
+				member := User{UserID: memberID}
+				if preferences[memberID], err = member.NotificationPreferences(); err != nil {
+					return nil, err
+				}
+			}
+			if preferences[memberID].GroupMuted(groupID) || !preferences[memberID].KindEnabled(NotificationGroupPick) {
+				continue
+			}
+			if _, ok := pickedBy[memberID]; !ok {
+				pickedBy[memberID] = map[string]string{}
+				memberIDs = append(memberIDs, memberID)
+			}
+			for _, voter := range groupVoters[groupID] {
+				pickedBy[memberID][voter.UserID] = voter.Name
+			}
+		}
+	}
+
+	bodies := make([]CrierBody, 0, len(memberIDs))
+	for _, memberID := range memberIDs {
+		names := make([]string, 0, len(pickedBy[memberID]))
+		for _, name := range pickedBy[memberID] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		bodies = append(bodies, CrierBody{
+			UserID:       memberID,
+			CountdownID:  awards[0].CountdownID,
+			SongID:       s.SongID,
+			Position:     *s.PlayedPosition,
+			Notification: GroupPickNotification(names, s, awards[0].CountdownID),
+		})
+	}
+	return bodies, nil
+}
@@ -0,0 +1,1752 @@
+package types
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go/aws"
+	"jjj.rflett.com/jjj-api/logger"
+	"strconv"
+	"sync"
+)
+
+// transactionLimit is the most items DynamoDB allows in a transaction
+const transactionLimit = 25
+
+// DynamoStorage is the Storage backed by the single DynamoDB table
+type DynamoStorage struct {
+	Client *dynamodb.Client
+	Table  string
+}
+
+// NewDynamoStorage returns a DynamoStorage using the client and table
+func NewDynamoStorage(client *dynamodb.Client, table string) *DynamoStorage {
+	return &DynamoStorage{Client: client, Table: table}
+}
+
+// itemKey returns the primary key of an item
+func itemKey(pk string, sk string) map[string]dbTypes.AttributeValue {
+	return map[string]dbTypes.AttributeValue{
+		PartitionKey: &dbTypes.AttributeValueMemberS{Value: pk},
+		SortKey:      &dbTypes.AttributeValueMemberS{Value: sk},
+	}
+}
+
+// conditionalErr converts a failed dynamo condition into ErrConditionalCheckFailed
+func conditionalErr(err error) error {
+	var crf *dbTypes.ConditionalCheckFailedException
+	if errors.As(err, &crf) {
+		return ErrConditionalCheckFailed
+	}
+
+	// a transaction is cancelled when one of its conditions fails
+	var tce *dbTypes.TransactionCanceledException
+	if errors.As(err, &tce) {
+		for _, reason := range tce.CancellationReasons {
+			if reason.Code != nil && *reason.Code == "ConditionalCheckFailed" {
+				return ErrConditionalCheckFailed
+			}
+		}
+	}
+	return err
+}
+
+// getItem gets a single item by its key and unmarshals it into out, returning whether it was found
+func (d *DynamoStorage) getItem(pk string, sk string, out interface{}) (bool, error) {
+	input := &dynamodb.GetItemInput{
+		Key:       itemKey(pk, sk),
+		TableName: &d.Table,
+	}
+
+	result, err := d.Client.GetItem(context.TODO(), input)
+	if err != nil {
+		return false, err
+	}
+
+	if len(result.Item) == 0 {
+		return false, nil
+	}
+
+	if err = attributevalue.UnmarshalMap(result.Item, out); err != nil {
+		logger.Log.Error().Err(err).Str("pk", pk).Str("sk", sk).Msg("failed to unmarshal dynamo item")
+		return false, err
+	}
+	return true, nil
+}
+
+// putItem marshals an item and puts it in the table
+func (d *DynamoStorage) putItem(item interface{}) error {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:    &d.Table,
+		Item:         av,
+		ReturnValues: dbTypes.ReturnValueNone,
+	}
+	_, err = d.Client.PutItem(context.TODO(), input)
+	return err
+}
+
+// deleteItem deletes an item by its key
+func (d *DynamoStorage) deleteItem(pk string, sk string) error {
+	input := &dynamodb.DeleteItemInput{
+		Key:       itemKey(pk, sk),
+		TableName: &d.Table,
+	}
+	_, err := d.Client.DeleteItem(context.TODO(), input)
+	return err
+}
+
+// batchGet gets the items by their keys, missing items are skipped and the rest aren't in any particular order
+func (d *DynamoStorage) batchGet(keys []map[string]dbTypes.AttributeValue) ([]map[string]dbTypes.AttributeValue, error) {
+	var items []map[string]dbTypes.AttributeValue
+
+	// BatchGetItem accepts a maximum of 100 keys
+	for i := 0; i < len(keys); i += 100 {
+		j := i + 100
+		if j > len(keys) {
+			j = len(keys)
+		}
+
+		requests := map[string]dbTypes.KeysAndAttributes{d.Table: {Keys: keys[i:j]}}
+		for len(requests) > 0 {
+			result, err := d.Client.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{RequestItems: requests})
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, result.Responses[d.Table]...)
+
+			// keys dynamo didn't get to are retried
+			requests = result.UnprocessedKeys
+		}
+	}
+	return items, nil
+}
+
+// query runs a key condition query, optionally against the GSI, and returns all the pages of items
+func (d *DynamoStorage) query(keyCondition expression.KeyConditionBuilder, projection []string, gsi bool) ([]map[string]dbTypes.AttributeValue, error) {
+	builder := expression.NewBuilder().WithKeyCondition(keyCondition)
+	if len(projection) > 0 {
+		projExpr := expression.NamesList(expression.Name(projection[0]))
+		for _, name := range projection[1:] {
+			projExpr = projExpr.AddNames(expression.Name(name))
+		}
+		builder = builder.WithProjection(projExpr)
+	}
+
+	expr, err := builder.Build()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("error building query expression")
+		return nil, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:                 &d.Table,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ProjectionExpression:      expr.Projection(),
+	}
+	if gsi {
+		input.IndexName = aws.String(GSI)
+	}
+
+	var items []map[string]dbTypes.AttributeValue
+	paginator := dynamodb.NewQueryPaginator(d.Client, input)
+	for paginator.HasMorePages() {
+		page, pageErr := paginator.NextPage(context.TODO())
+		if pageErr != nil {
+			return nil, pageErr
+		}
+		items = append(items, page.Items...)
+	}
+	return items, nil
+}
+
+// beginsWith returns a key condition on an exact partition key and a sort key prefix
+func beginsWith(pk string, skPrefix string) expression.KeyConditionBuilder {
+	return expression.KeyAnd(
+		expression.Key(PartitionKey).Equal(expression.Value(pk)),
+		expression.Key(SortKey).BeginsWith(skPrefix),
+	)
+}
+
+// inverted returns a GSI key condition on an exact sort key and a partition key prefix
+func inverted(pkPrefix string, sk string) expression.KeyConditionBuilder {
+	return expression.KeyAnd(
+		expression.Key(SortKey).Equal(expression.Value(sk)),
+		expression.Key(PartitionKey).BeginsWith(pkPrefix),
+	)
+}
+
+// GetUser gets a user by their ID
+func (d *DynamoStorage) GetUser(userID string) (*User, error) {
+	u := &User{UserID: userID}
+	found, err := d.getItem(u.PKVal(), u.SKVal(), u)
+	if !found {
+		return nil, err
+	}
+	return u, nil
+}
+
+// GetUsers batch gets users by their IDs, missing users are skipped
+func (d *DynamoStorage) GetUsers(userIDs []string) ([]User, error) {
+	keys := make([]map[string]dbTypes.AttributeValue, 0, len(userIDs))
+	for _, userID := range userIDs {
+		u := User{UserID: userID}
+		keys = append(keys, itemKey(u.PKVal(), u.SKVal()))
+	}
+	items, err := d.batchGet(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []User
+	err = attributevalue.UnmarshalListOfMaps(items, &users)
+	return users, err
+}
+
+// PutUser puts the user item
+func (d *DynamoStorage) PutUser(u *User) error {
+	return d.putItem(u)
+}
+
+// UpdateUser updates the user's nickname
+func (d *DynamoStorage) UpdateUser(u *User) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#NN": "NickName",
+			"#UA": "UpdatedAt",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":nn": &dbTypes.AttributeValueMemberS{Value: *u.NickName},
+			":ua": &dbTypes.AttributeValueMemberS{Value: *u.UpdatedAt},
+		},
+		Key:              itemKey(u.PKVal(), u.SKVal()),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #NN = :nn, #UA = :ua"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// SetUserAvatarUrl sets the user's avatar url
+func (d *DynamoStorage) SetUserAvatarUrl(userID string, avatarUrl string) error {
+	u := User{UserID: userID}
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#A": "AvatarUrl",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":a": &dbTypes.AttributeValueMemberS{Value: avatarUrl},
+		},
+		Key:              itemKey(u.PKVal(), u.SKVal()),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #A = :a"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// GetUserPoints returns the user's points in a countdown
+func (d *DynamoStorage) GetUserPoints(countdownID string, userID string) (int, error) {
+	u := User{UserID: userID}
+	up := userPoints{}
+	_, err := d.getItem(u.PKVal(), pointsSK(countdownID), &up)
+	return up.Points, err
+}
+
+// leaderboardKey is the key of the last points read from a shard of the leaderboard
+type leaderboardKey struct {
+	PK     string `json:"pk"`
+	SK     string `json:"sk"`
+	Points int    `json:"points"`
+}
+
+// leaderboardCursor is where each shard of the leaderboard was read up to, and how many users are ahead of it
+type leaderboardCursor struct {
+	Offset int                    `json:"offset"`
+	Shards map[int]leaderboardKey `json:"shards,omitempty"`
+	Done   map[int]bool           `json:"done,omitempty"`
+}
+
+// leaderboardShard is the points read from a shard of the leaderboard, and whether it has any after them
+type leaderboardShard struct {
+	points []userPoints
+	more   bool
+}
+
+// rankedPoints is a user's points merged from a shard of the leaderboard
+type rankedPoints struct {
+	userPoints
+	shard int
+}
+
+// eachLeaderboardShard calls the function for every shard of the leaderboard at once, and returns the first error
+func eachLeaderboardShard(f func(shard int) error) error {
+	errs := make([]error, leaderboardShards)
+	var wg sync.WaitGroup
+	for shard := 0; shard < leaderboardShards; shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			errs[shard] = f(shard)
+		}(shard)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leaderboardCondition is the key condition on a leaderboard shard, with an optional condition on the points
+func leaderboardCondition(pk string, points func(expression.KeyBuilder) expression.KeyConditionBuilder) expression.KeyConditionBuilder {
+	condition := expression.Key("LeaderboardPK").Equal(expression.Value(pk))
+	if points == nil {
+		return condition
+	}
+	return expression.KeyAnd(condition, points(expression.Key("Points")))
+}
+
+// queryLeaderboard reads up to limit points from each shard of the countdown's leaderboard, highest first unless it's
+// ascending. Each shard is read from where the cursor is up to, and shards it's done with are skipped.
+func (d *DynamoStorage) queryLeaderboard(
+	countdownID string,
+	points func(expression.KeyBuilder) expression.KeyConditionBuilder,
+	ascending bool,
+	limit int,
+	cursor *leaderboardCursor,
+) ([]leaderboardShard, error) {
+	shards := make([]leaderboardShard, leaderboardShards)
+	err := eachLeaderboardShard(func(shard int) error {
+		if cursor != nil && cursor.Done[shard] {
+			return nil
+		}
+		pk := leaderboardShardPK(countdownID, shard)
+		expr, err := expression.NewBuilder().WithKeyCondition(leaderboardCondition(pk, points)).Build()
+		if err != nil {
+			return err
+		}
+
+		input := &dynamodb.QueryInput{
+			TableName:                 &d.Table,
+			IndexName:                 aws.String(LeaderboardGSI),
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			ScanIndexForward:          aws.Bool(ascending),
+			Limit:                     aws.Int32(int32(limit)),
+		}
+		if cursor != nil {
+			if key, ok := cursor.Shards[shard]; ok {
+				input.ExclusiveStartKey = map[string]dbTypes.AttributeValue{
+					PartitionKey:    &dbTypes.AttributeValueMemberS{Value: key.PK},
+					SortKey:         &dbTypes.AttributeValueMemberS{Value: key.SK},
+					"LeaderboardPK": &dbTypes.AttributeValueMemberS{Value: pk},
+					"Points":        &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(key.Points)},
+				}
+			}
+		}
+		result, err := d.Client.Query(context.TODO(), input)
+		if err != nil {
+			return err
+		}
+		shards[shard].more = result.LastEvaluatedKey != nil
+		return attributevalue.UnmarshalListOfMaps(result.Items, &shards[shard].points)
+	})
+	return shards, err
+}
+
+// mergeLeaderboard merges up to limit points from the shards, highest first unless it's ascending. Equal points are
+// ordered by their userID, backwards when it's ascending so they're in order once they're reversed.
+func mergeLeaderboard(shards []leaderboardShard, ascending bool, limit int) []rankedPoints {
+	next := make([]int, len(shards))
+	var merged []rankedPoints
+	for len(merged) < limit {
+		best := -1
+		for shard := range shards {
+			if next[shard] >= len(shards[shard].points) {
+				continue
+			}
+			if best == -1 {
+				best = shard
+				continue
+			}
+			up, bestUp := shards[shard].points[next[shard]], shards[best].points[next[best]]
+			if (up.Points != bestUp.Points && (up.Points > bestUp.Points) != ascending) ||
+				(up.Points == bestUp.Points && (up.UserID < bestUp.UserID) != ascending) {
+				best = shard
+			}
+		}
+		if best == -1 {
+			break
+		}
+		merged = append(merged, rankedPoints{userPoints: shards[best].points[next[best]], shard: best})
+		next[best]++
+	}
+	return merged
+}
+
+// GetLeaderboard returns a page of the countdown's leaderboard, the cursor is where each of its shards was read up to
+func (d *DynamoStorage) GetLeaderboard(countdownID string, cursor string, limit int) (*leaderboardPage, error) {
+	c := leaderboardCursor{}
+	if cursor != "" {
+		data, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err == nil {
+			err = json.Unmarshal(data, &c)
+		}
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	// read one past the page so it's known who's first on the next one
+	shards, err := d.queryLeaderboard(countdownID, nil, false, limit+1, &c)
+	if err != nil {
+		return nil, err
+	}
+	merged := mergeLeaderboard(shards, false, limit+1)
+
+	page := &leaderboardPage{Offset: c.Offset}
+	taken := make([]int, len(shards))
+	for i := 0; i < len(merged) && i < limit; i++ {
+		page.Points = append(page.Points, merged[i].userPoints)
+		taken[merged[i].shard]++
+	}
+	if len(merged) <= limit {
+		return page, nil
+	}
+	page.Following = &merged[limit].userPoints
+
+	next := leaderboardCursor{Offset: c.Offset + limit, Shards: map[int]leaderboardKey{}, Done: map[int]bool{}}
+	for shard := range shards {
+		if c.Done[shard] || (taken[shard] == len(shards[shard].points) && !shards[shard].more) {
+			next.Done[shard] = true
+			continue
+		}
+		if taken[shard] == 0 {
+			if key, ok := c.Shards[shard]; ok {
+				next.Shards[shard] = key
+			}
+			continue
+		}
+		last := shards[shard].points[taken[shard]-1]
+		next.Shards[shard] = leaderboardKey{PK: last.PK, SK: last.SK, Points: last.Points}
+	}
+	data, err := json.Marshal(next)
+	if err != nil {
+		return nil, err
+	}
+	page.Next = base64.RawURLEncoding.EncodeToString(data)
+	return page, nil
+}
+
+// GetLeaderboardAround returns up to limit of the users with the fewest points more than the points, and the first
+// limit users with the points or less, both in order
+func (d *DynamoStorage) GetLeaderboardAround(countdownID string, points int, limit int) ([]userPoints, []userPoints, error) {
+	shards, err := d.queryLeaderboard(countdownID, func(k expression.KeyBuilder) expression.KeyConditionBuilder {
+		return k.GreaterThan(expression.Value(points))
+	}, true, limit, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged := mergeLeaderboard(shards, true, limit)
+	above := make([]userPoints, 0, len(merged))
+	for i := len(merged) - 1; i >= 0; i-- {
+		above = append(above, merged[i].userPoints)
+	}
+
+	shards, err = d.queryLeaderboard(countdownID, func(k expression.KeyBuilder) expression.KeyConditionBuilder {
+		return k.LessThanEqual(expression.Value(points))
+	}, false, limit, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged = mergeLeaderboard(shards, false, limit)
+	below := make([]userPoints, 0, len(merged))
+	for _, rp := range merged {
+		below = append(below, rp.userPoints)
+	}
+	return above, below, nil
+}
+
+// CountPointsAbove returns how many users have more than the points in a countdown
+func (d *DynamoStorage) CountPointsAbove(countdownID string, points int) (int, error) {
+	counts := make([]int, leaderboardShards)
+	err := eachLeaderboardShard(func(shard int) error {
+		condition := leaderboardCondition(leaderboardShardPK(countdownID, shard), func(k expression.KeyBuilder) expression.KeyConditionBuilder {
+			return k.GreaterThan(expression.Value(points))
+		})
+		expr, err := expression.NewBuilder().WithKeyCondition(condition).Build()
+		if err != nil {
+			return err
+		}
+
+		input := &dynamodb.QueryInput{
+			TableName:                 &d.Table,
+			IndexName:                 aws.String(LeaderboardGSI),
+			KeyConditionExpression:    expr.KeyCondition(),
+			ExpressionAttributeNames:  expr.Names(),
+			ExpressionAttributeValues: expr.Values(),
+			Select:                    dbTypes.SelectCount,
+		}
+		paginator := dynamodb.NewQueryPaginator(d.Client, input)
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(context.TODO())
+			if err != nil {
+				return err
+			}
+			counts[shard] += int(page.Count)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	return total, nil
+}
+
+// GetUsersPoints batch gets the points of each of the users in a countdown, users without points are skipped
+func (d *DynamoStorage) GetUsersPoints(countdownID string, userIDs []string) ([]userPoints, error) {
+	keys := make([]map[string]dbTypes.AttributeValue, 0, len(userIDs))
+	for _, userID := range userIDs {
+		u := User{UserID: userID}
+		keys = append(keys, itemKey(u.PKVal(), pointsSK(countdownID)))
+	}
+	items, err := d.batchGet(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []userPoints
+	err = attributevalue.UnmarshalListOfMaps(items, &points)
+	return points, err
+}
+
+// GetCountdownPoints returns the points of every user with points in a countdown
+func (d *DynamoStorage) GetCountdownPoints(countdownID string) (map[string]userPoints, error) {
+	items, err := d.query(inverted(fmt.Sprintf("%s#", UserPartitionKey), pointsSK(countdownID)), nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	points := map[string]userPoints{}
+	for _, item := range items {
+		up := userPoints{}
+		if err = attributevalue.UnmarshalMap(item, &up); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal user points")
+			continue
+		}
+		points[up.UserID] = up
+	}
+	return points, nil
+}
+
+// addPointsUpdate is the update that adds points and songs hit to the user's score in a countdown
+func (d *DynamoStorage) addPointsUpdate(countdownID string, userID string, points int, hits int) *dbTypes.Update {
+	u := User{UserID: userID}
+	return &dbTypes.Update{
+		ExpressionAttributeNames: map[string]string{
+			"#P": "Points",
+			"#H": "Hits",
+			"#C": "CountdownID",
+			"#U": "UserID",
+			"#L": "LeaderboardPK",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":p": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(points)},
+			":h": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(hits)},
+			":c": &dbTypes.AttributeValueMemberS{Value: countdownID},
+			":u": &dbTypes.AttributeValueMemberS{Value: userID},
+			":l": &dbTypes.AttributeValueMemberS{Value: leaderboardPK(countdownID, userID)},
+		},
+		Key:              itemKey(u.PKVal(), pointsSK(countdownID)),
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #C = :c, #U = :u, #L = :l ADD #P :p, #H :h"),
+	}
+}
+
+// GetUserIDByAuthProvider looks up a user ID by their auth provider and the ID they have with it
+func (d *DynamoStorage) GetUserIDByAuthProvider(provider string, providerID string) (string, error) {
+	items, err := d.query(
+		inverted(fmt.Sprintf("%s#", UserAuthProviderPartitionKey), fmt.Sprintf("%s#%s#%s", UserAuthProviderSortKey, provider, providerID)),
+		[]string{"UserID"},
+		true,
+	)
+	if err != nil || len(items) == 0 {
+		return "", err
+	}
+
+	uap := userAuthProvider{}
+	if err = attributevalue.UnmarshalMap(items[0], &uap); err != nil {
+		return "", err
+	}
+	return uap.UserID, nil
+}
+
+// PutAuthProvider maps a user to their auth provider
+func (d *DynamoStorage) PutAuthProvider(userID string, provider string, providerID string) error {
+	return d.putItem(userAuthProvider{
+		PK:             fmt.Sprintf("%s#%s", UserAuthProviderPartitionKey, userID),
+		SK:             fmt.Sprintf("%s#%s#%s", UserAuthProviderSortKey, provider, providerID),
+		UserID:         userID,
+		AuthProviderId: providerID,
+		AuthProvider:   provider,
+	})
+}
+
+// GetVotes returns a user's votes in a countdown
+func (d *DynamoStorage) GetVotes(countdownID string, userID string) ([]songVote, error) {
+	u := User{UserID: userID}
+	items, err := d.query(beginsWith(u.PKVal(), voteSK(countdownID, "")), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []songVote
+	for _, item := range items {
+		vote := songVote{}
+		if err = attributevalue.UnmarshalMap(item, &vote); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal vote to songVote")
+			continue
+		}
+		votes = append(votes, vote)
+	}
+	return votes, nil
+}
+
+// CountVotes returns the number of votes a user has in a countdown
+func (d *DynamoStorage) CountVotes(countdownID string, userID string) (int, error) {
+	u := User{UserID: userID}
+	items, err := d.query(beginsWith(u.PKVal(), voteSK(countdownID, "")), []string{"SongID"}, false)
+	return len(items), err
+}
+
+// PutVote puts a user's vote for a song
+func (d *DynamoStorage) PutVote(vote *songVote) error {
+	return d.putItem(vote)
+}
+
+// DeleteVote removes a user's vote for a song in a countdown
+func (d *DynamoStorage) DeleteVote(countdownID string, userID string, songID string) error {
+	return d.deleteItem(fmt.Sprintf("%s#%s", UserPartitionKey, userID), voteSK(countdownID, songID))
+}
+
+// GetVoters returns the votes for a song in a countdown
+func (d *DynamoStorage) GetVoters(countdownID string, songID string) ([]songVote, error) {
+	items, err := d.query(
+		inverted(fmt.Sprintf("%s#", UserPartitionKey), voteSK(countdownID, songID)),
+		[]string{"UserID", "SongID", "Rank"},
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var voters []songVote
+	for _, item := range items {
+		vote := songVote{}
+		if err = attributevalue.UnmarshalMap(item, &vote); err != nil {
+			logger.Log.Error().Err(err).Msg("error unmarshalling voter to songVote")
+			continue
+		}
+		if vote.UserID != "" {
+			voters = append(voters, vote)
+		}
+	}
+	return voters, nil
+}
+
+// RecordAward saves the award and adds the points to the user's score in one transaction. The award has to be the next
+// revision of the stored one, or the first, and ErrConditionalCheckFailed is returned if it isn't.
+func (d *DynamoStorage) RecordAward(award *Award, points int) error {
+	av, err := attributevalue.MarshalMap(award)
+	if err != nil {
+		return err
+	}
+
+	put := &dbTypes.Put{
+		Item:                av,
+		TableName:           &d.Table,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	}
+	hits := 1
+	if award.Revision > 1 {
+		hits = 0
+		put.ConditionExpression = aws.String("#R = :r")
+		put.ExpressionAttributeNames = map[string]string{"#R": "Revision"}
+		put.ExpressionAttributeValues = map[string]dbTypes.AttributeValue{
+			":r": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(award.Revision - 1)},
+		}
+	}
+
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dbTypes.TransactWriteItem{
+			{Put: put},
+			{Update: d.addPointsUpdate(award.CountdownID, award.UserID, points, hits)},
+		},
+	}
+	_, err = d.Client.TransactWriteItems(context.TODO(), input)
+	return conditionalErr(err)
+}
+
+// ResetUserScores replaces each user's points and songs hit in a countdown, saving their change's awards and deleting
+// its removed ones, all in one transaction so the points and the awards never disagree. The points are only replaced
+// if they're still the change's previous points.
+func (d *DynamoStorage) ResetUserScores(countdownID string, changes []ScoreChange) error {
+	var items []dbTypes.TransactWriteItem
+	for _, change := range changes {
+		u := User{UserID: change.UserID}
+		up, err := attributevalue.MarshalMap(userPoints{
+			PK:            u.PKVal(),
+			SK:            pointsSK(countdownID),
+			CountdownID:   countdownID,
+			UserID:        change.UserID,
+			Points:        change.Points,
+			Hits:          change.Hits,
+			LeaderboardPK: leaderboardPK(countdownID, change.UserID),
+		})
+		if err != nil {
+			return err
+		}
+		items = append(items, dbTypes.TransactWriteItem{
+			Put: &dbTypes.Put{
+				Item:                     up,
+				TableName:                &d.Table,
+				ConditionExpression:      aws.String("attribute_not_exists(PK) OR #P = :p"),
+				ExpressionAttributeNames: map[string]string{"#P": "Points"},
+				ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+					":p": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(change.Previous)},
+				},
+			},
+		})
+		for i := range change.Awards {
+			av, err := attributevalue.MarshalMap(change.Awards[i])
+			if err != nil {
+				return err
+			}
+			items = append(items, dbTypes.TransactWriteItem{Put: &dbTypes.Put{Item: av, TableName: &d.Table}})
+		}
+		for _, a := range change.Removed {
+			items = append(items, dbTypes.TransactWriteItem{Delete: &dbTypes.Delete{Key: itemKey(a.PKVal(), a.SKVal()), TableName: &d.Table}})
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	if len(items) > transactionLimit {
+		return ErrTransactionTooBig
+	}
+
+	_, err := d.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	return conditionalErr(err)
+}
+
+// GetAward returns the points a user got for a song in a countdown
+func (d *DynamoStorage) GetAward(countdownID string, userID string, songID string) (*Award, error) {
+	a := &Award{CountdownID: countdownID, UserID: userID, SongID: songID}
+	found, err := d.getItem(a.PKVal(), a.SKVal(), a)
+	if !found {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAwards returns the points a user got for each song in a countdown
+func (d *DynamoStorage) GetAwards(countdownID string, userID string) ([]Award, error) {
+	prefix := Award{CountdownID: countdownID, UserID: userID}
+	items, err := d.query(beginsWith(prefix.PKVal(), prefix.SKVal()), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var awards []Award
+	for _, item := range items {
+		a := Award{}
+		if err = attributevalue.UnmarshalMap(item, &a); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal award")
+			continue
+		}
+		awards = append(awards, a)
+	}
+	return awards, nil
+}
+
+// GetSongAwards returns the points each voter got for a song in a countdown
+func (d *DynamoStorage) GetSongAwards(countdownID string, songID string) ([]Award, error) {
+	a := Award{CountdownID: countdownID, SongID: songID}
+	items, err := d.query(inverted(fmt.Sprintf("%s#", UserPartitionKey), a.SKVal()), nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var awards []Award
+	err = attributevalue.UnmarshalListOfMaps(items, &awards)
+	return awards, err
+}
+
+// GetCountdown gets a countdown by its ID
+func (d *DynamoStorage) GetCountdown(countdownID string) (*Countdown, error) {
+	c := &Countdown{CountdownID: countdownID}
+	found, err := d.getItem(c.PKVal(), c.SKVal(), c)
+	if !found {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCountdowns returns every countdown
+func (d *DynamoStorage) GetCountdowns() ([]Countdown, error) {
+	items, err := d.query(beginsWith(CountdownPartitionKey, fmt.Sprintf("%s#", CountdownSortKey)), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var countdowns []Countdown
+	for _, item := range items {
+		c := Countdown{}
+		if err = attributevalue.UnmarshalMap(item, &c); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal countdown to Countdown")
+			continue
+		}
+		countdowns = append(countdowns, c)
+	}
+	return countdowns, nil
+}
+
+// PutCountdown puts the countdown item
+func (d *DynamoStorage) PutCountdown(c *Countdown) error {
+	return d.putItem(c)
+}
+
+// GetCurrentCountdownID returns the ID of the current countdown, or an empty string if there isn't one
+func (d *DynamoStorage) GetCurrentCountdownID() (string, error) {
+	cc := currentCountdown{}
+	_, err := d.getItem(CountdownPartitionKey, CurrentCountdownKey, &cc)
+	return cc.CountdownID, err
+}
+
+// SetCurrentCountdownID makes the countdown the current one
+func (d *DynamoStorage) SetCurrentCountdownID(countdownID string) error {
+	return d.putItem(currentCountdown{PK: CountdownPartitionKey, SK: CurrentCountdownKey, CountdownID: countdownID})
+}
+
+// GetGroup gets a group by its ID
+func (d *DynamoStorage) GetGroup(groupID string) (*Group, error) {
+	g := &Group{GroupID: groupID}
+	found, err := d.getItem(g.PKVal(), g.SKVal(), g)
+	if !found {
+		return nil, err
+	}
+	return g, nil
+}
+
+// PutGroup puts the group item
+func (d *DynamoStorage) PutGroup(g *Group) error {
+	return d.putItem(g)
+}
+
+// UpdateGroup updates the group's name, the group's OwnerID must match the stored owner
+func (d *DynamoStorage) UpdateGroup(g *Group) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#N":  "Name",
+			"#UA": "UpdatedAt",
+			"#O":  "OwnerID",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":ua": &dbTypes.AttributeValueMemberS{Value: *g.UpdatedAt},
+			":n":  &dbTypes.AttributeValueMemberS{Value: g.Name},
+			":o":  &dbTypes.AttributeValueMemberS{Value: g.OwnerID},
+		},
+		Key:                 itemKey(g.PKVal(), g.SKVal()),
+		ReturnValues:        dbTypes.ReturnValueNone,
+		TableName:           &d.Table,
+		ConditionExpression: aws.String("#O = :o"),
+		UpdateExpression:    aws.String("SET #N = :n, #UA = :ua"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return conditionalErr(err)
+}
+
+// SetGroupOwner sets the owner of the group
+func (d *DynamoStorage) SetGroupOwner(groupID string, ownerID string, updatedAt string) error {
+	g := Group{GroupID: groupID}
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#UA": "UpdatedAt",
+			"#O":  "OwnerID",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":ua": &dbTypes.AttributeValueMemberS{Value: updatedAt},
+			":o":  &dbTypes.AttributeValueMemberS{Value: ownerID},
+		},
+		Key:              itemKey(g.PKVal(), g.SKVal()),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #O = :o, #UA = :ua"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// DeleteGroup deletes the group item
+func (d *DynamoStorage) DeleteGroup(groupID string) error {
+	g := Group{GroupID: groupID}
+	return d.deleteItem(g.PKVal(), g.SKVal())
+}
+
+// GetGroupCode returns the code for a group
+func (d *DynamoStorage) GetGroupCode(groupID string) (*GroupCode, error) {
+	g := Group{GroupID: groupID}
+	items, err := d.query(beginsWith(g.PKVal(), fmt.Sprintf("%s#", GroupCodeSortKey)), []string{"GroupID", "Code"}, false)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	gc := &GroupCode{}
+	if err = attributevalue.UnmarshalMap(items[0], gc); err != nil {
+		return nil, err
+	}
+	return gc, nil
+}
+
+// GetGroupCodeByCode looks up a group code by the code itself
+func (d *DynamoStorage) GetGroupCodeByCode(code string) (*GroupCode, error) {
+	items, err := d.query(
+		inverted(fmt.Sprintf("%s#", GroupCodePartitionKey), fmt.Sprintf("%s#%s", GroupCodeSortKey, code)),
+		[]string{"GroupID", "Code"},
+		true,
+	)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	gc := &GroupCode{}
+	if err = attributevalue.UnmarshalMap(items[0], gc); err != nil {
+		return nil, err
+	}
+	return gc, nil
+}
+
+// PutGroupCode puts the group code item
+func (d *DynamoStorage) PutGroupCode(gc *GroupCode) error {
+	return d.putItem(gc)
+}
+
+// DeleteGroupCode deletes a group code
+func (d *DynamoStorage) DeleteGroupCode(groupID string, code string) error {
+	g := Group{GroupID: groupID}
+	return d.deleteItem(g.PKVal(), fmt.Sprintf("%s#%s", GroupCodeSortKey, code))
+}
+
+// PutMembership adds a user to a group
+func (d *DynamoStorage) PutMembership(groupID string, userID string, createdAt string) error {
+	g := Group{GroupID: groupID}
+	return d.putItem(groupMember{
+		PK:        g.PKVal(),
+		SK:        fmt.Sprintf("%s#%s", UserPartitionKey, userID),
+		GroupID:   groupID,
+		UserID:    userID,
+		CreatedAt: createdAt,
+	})
+}
+
+// DeleteMembership removes a user from a group
+func (d *DynamoStorage) DeleteMembership(groupID string, userID string) error {
+	g := Group{GroupID: groupID}
+	return d.deleteItem(g.PKVal(), fmt.Sprintf("%s#%s", UserPartitionKey, userID))
+}
+
+// IsMember returns whether a user is a member of a group
+func (d *DynamoStorage) IsMember(groupID string, userID string) (bool, error) {
+	g := Group{GroupID: groupID}
+	m := groupMember{}
+	return d.getItem(g.PKVal(), fmt.Sprintf("%s#%s", UserPartitionKey, userID), &m)
+}
+
+// GetGroupIDs returns the IDs of the groups a user is a member of
+func (d *DynamoStorage) GetGroupIDs(userID string) ([]string, error) {
+	u := User{UserID: userID}
+	items, err := d.query(inverted(fmt.Sprintf("%s#", GroupPartitionKey), u.PKVal()), []string{"GroupID"}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var groupIDs []string
+	for _, item := range items {
+		m := groupMember{}
+		if err = attributevalue.UnmarshalMap(item, &m); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal membership to groupMember")
+			continue
+		}
+		groupIDs = append(groupIDs, m.GroupID)
+	}
+	return groupIDs, nil
+}
+
+// GetMemberIDs returns the IDs of the users in a group
+func (d *DynamoStorage) GetMemberIDs(groupID string) ([]string, error) {
+	g := Group{GroupID: groupID}
+	items, err := d.query(beginsWith(g.PKVal(), fmt.Sprintf("%s#", UserPartitionKey)), []string{"UserID"}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []string
+	for _, item := range items {
+		m := groupMember{}
+		if err = attributevalue.UnmarshalMap(item, &m); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal group member to groupMember")
+			continue
+		}
+		userIDs = append(userIDs, m.UserID)
+	}
+	return userIDs, nil
+}
+
+// GetGame gets a game in a group
+func (d *DynamoStorage) GetGame(groupID string, gameID string) (*Game, error) {
+	g := &Game{GroupID: groupID, GameID: gameID}
+	found, err := d.getItem(g.PKVal(), g.SKVal(), g)
+	if !found {
+		return nil, err
+	}
+	return g, nil
+}
+
+// GetGames returns the games in a group
+func (d *DynamoStorage) GetGames(groupID string) ([]Game, error) {
+	g := Group{GroupID: groupID}
+	items, err := d.query(beginsWith(g.PKVal(), fmt.Sprintf("%s#", GameSortKey)), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var games []Game
+	for _, item := range items {
+		game := Game{}
+		if err = attributevalue.UnmarshalMap(item, &game); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal groupGame to game")
+			continue
+		}
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// PutGame puts the game item
+func (d *DynamoStorage) PutGame(g *Game) error {
+	return d.putItem(g)
+}
+
+// UpdateGame updates the game's name and description
+func (d *DynamoStorage) UpdateGame(g *Game) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#N":  "Name",
+			"#D":  "Description",
+			"#UA": "UpdatedAt",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":ua": &dbTypes.AttributeValueMemberS{Value: *g.UpdatedAt},
+			":n":  &dbTypes.AttributeValueMemberS{Value: g.Name},
+			":d":  &dbTypes.AttributeValueMemberS{Value: g.Description},
+		},
+		Key:              itemKey(g.PKVal(), g.SKVal()),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #N = :n, #UA = :ua, #D = :d"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// DeleteGame deletes a game from a group
+func (d *DynamoStorage) DeleteGame(groupID string, gameID string) error {
+	g := Game{GroupID: groupID, GameID: gameID}
+	return d.deleteItem(g.PKVal(), g.SKVal())
+}
+
+// PutSnapshot puts the snapshot, replacing any taken after the same play
+func (d *DynamoStorage) PutSnapshot(s *Snapshot) error {
+	return d.putItem(s)
+}
+
+// GetSnapshots returns the group's snapshots in the countdown in the order of their plays
+func (d *DynamoStorage) GetSnapshots(groupID string, countdownID string) ([]Snapshot, error) {
+	g := Group{GroupID: groupID}
+	items, err := d.query(beginsWith(g.PKVal(), snapshotSKPrefix(countdownID)), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	err = attributevalue.UnmarshalListOfMaps(items, &snapshots)
+	return snapshots, err
+}
+
+// GetSong gets a song by its ID
+func (d *DynamoStorage) GetSong(songID string) (*Song, error) {
+	s := &Song{SongID: songID}
+	found, err := d.getItem(s.PKVal(), s.SKVal(), s)
+	if !found {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetSongs batch gets songs by their IDs, missing songs are skipped
+func (d *DynamoStorage) GetSongs(songIDs []string) ([]Song, error) {
+	keys := make([]map[string]dbTypes.AttributeValue, 0, len(songIDs))
+	for _, songID := range songIDs {
+		s := Song{SongID: songID}
+		keys = append(keys, itemKey(s.PKVal(), s.SKVal()))
+	}
+	items, err := d.batchGet(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var songs []Song
+	err = attributevalue.UnmarshalListOfMaps(items, &songs)
+	return songs, err
+}
+
+// ListSongs scans the table for every song
+func (d *DynamoStorage) ListSongs() ([]Song, error) {
+	pkFilter := expression.Name(PartitionKey).BeginsWith(fmt.Sprintf("%s#", SongPartitionKey))
+	skFilter := expression.Name(SortKey).BeginsWith(fmt.Sprintf("%s#", SongSortKey))
+	expr, err := expression.NewBuilder().WithFilter(expression.And(pkFilter, skFilter)).Build()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("error building expression for ListSongs func")
+		return nil, err
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 &d.Table,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+	}
+
+	var songs []Song
+	paginator := dynamodb.NewScanPaginator(d.Client, input)
+	for paginator.HasMorePages() {
+		page, pageErr := paginator.NextPage(context.TODO())
+		if pageErr != nil {
+			return songs, pageErr
+		}
+
+		for _, item := range page.Items {
+			song := Song{}
+			if unMarshErr := attributevalue.UnmarshalMap(item, &song); unMarshErr != nil {
+				logger.Log.Error().Err(unMarshErr).Msg("error unmarshalling item to song")
+				continue
+			}
+			songs = append(songs, song)
+		}
+	}
+	return songs, nil
+}
+
+// PutSong puts the song item
+func (d *DynamoStorage) PutSong(s *Song) error {
+	return d.putItem(s)
+}
+
+// DeleteSong deletes a song
+func (d *DynamoStorage) DeleteSong(songID string) error {
+	s := Song{SongID: songID}
+	return d.deleteItem(s.PKVal(), s.SKVal())
+}
+
+// SetSongPlayed records when and where a song was played in a countdown, but only if it hasn't been played already
+func (d *DynamoStorage) SetSongPlayed(play *SongPlay) error {
+	av, err := attributevalue.MarshalMap(play)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           &d.Table,
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	}
+	_, err = d.Client.PutItem(context.TODO(), input)
+	return conditionalErr(err)
+}
+
+// PutSongPlay saves a song's play in a countdown, replacing it if it exists
+func (d *DynamoStorage) PutSongPlay(play *SongPlay) error {
+	return d.putItem(play)
+}
+
+// GetSongPlay returns when and where a song was played in a countdown
+func (d *DynamoStorage) GetSongPlay(countdownID string, songID string) (*SongPlay, error) {
+	p := &SongPlay{}
+	found, err := d.getItem(countdownPK(countdownID), songPlaySK(songID), p)
+	if !found {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSongPlays returns every song that was played in a countdown
+func (d *DynamoStorage) GetSongPlays(countdownID string) ([]SongPlay, error) {
+	items, err := d.query(beginsWith(countdownPK(countdownID), songPlaySK("")), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var plays []SongPlay
+	for _, item := range items {
+		p := SongPlay{}
+		if err = attributevalue.UnmarshalMap(item, &p); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal play to SongPlay")
+			continue
+		}
+		plays = append(plays, p)
+	}
+	return plays, nil
+}
+
+// DeleteSongPlay removes a song's play from a countdown
+func (d *DynamoStorage) DeleteSongPlay(countdownID string, songID string) error {
+	return d.deleteItem(countdownPK(countdownID), songPlaySK(songID))
+}
+
+// GetPlayCount returns the current play count of a countdown, which starts at 1
+func (d *DynamoStorage) GetPlayCount(countdownID string) (int, error) {
+	pc := PlayCount{}
+	found, err := d.getItem(countdownPK(countdownID), PlayCountSortKey, &pc)
+	if err != nil {
+		return 0, err
+	}
+	if !found || pc.Value == nil {
+		return 1, nil
+	}
+	return strconv.Atoi(*pc.Value)
+}
+
+// IncrementPlayCount increments the current play count of a countdown by one
+func (d *DynamoStorage) IncrementPlayCount(countdownID string) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#V": "value",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":inc":   &dbTypes.AttributeValueMemberN{Value: "1"},
+			":start": &dbTypes.AttributeValueMemberN{Value: "1"},
+		},
+		Key:              itemKey(countdownPK(countdownID), PlayCountSortKey),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #V = if_not_exists(#V, :start) + :inc"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// SetPlayCount sets the current play count of a countdown to a specific value
+func (d *DynamoStorage) SetPlayCount(countdownID string, count int) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#V": "value",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":val": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(count)},
+		},
+		Key:              itemKey(countdownPK(countdownID), PlayCountSortKey),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #V = :val"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// GetPlayedSongIDs returns the IDs of the songs played in a countdown in the order they were played
+func (d *DynamoStorage) GetPlayedSongIDs(countdownID string) ([]string, error) {
+	playedSongs := PlayedSongs{}
+	_, err := d.getItem(countdownPK(countdownID), PlayedSongsSortKey, &playedSongs)
+	return playedSongs.SongIDs, err
+}
+
+// AddPlayedSongID appends a song to the played list of a countdown
+func (d *DynamoStorage) AddPlayedSongID(countdownID string, songID string) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#S": "SongIDs",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":s": &dbTypes.AttributeValueMemberL{Value: []dbTypes.AttributeValue{
+				&dbTypes.AttributeValueMemberS{Value: songID},
+			}},
+			":empty": &dbTypes.AttributeValueMemberL{Value: []dbTypes.AttributeValue{}},
+		},
+		Key:              itemKey(countdownPK(countdownID), PlayedSongsSortKey),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #S = list_append(if_not_exists(#S, :empty), :s)"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// ResetPlayedSongIDs empties the played list of a countdown
+func (d *DynamoStorage) ResetPlayedSongIDs(countdownID string) error {
+	input := &dynamodb.UpdateItemInput{
+		ExpressionAttributeNames: map[string]string{
+			"#S": "SongIDs",
+		},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":val": &dbTypes.AttributeValueMemberL{Value: []dbTypes.AttributeValue{}},
+		},
+		Key:              itemKey(countdownPK(countdownID), PlayedSongsSortKey),
+		ReturnValues:     dbTypes.ReturnValueNone,
+		TableName:        &d.Table,
+		UpdateExpression: aws.String("SET #S = :val"),
+	}
+	_, err := d.Client.UpdateItem(context.TODO(), input)
+	return err
+}
+
+// scan returns every item in the table that matches the filter
+func (d *DynamoStorage) scan(filter expression.ConditionBuilder) ([]map[string]dbTypes.AttributeValue, error) {
+	expr, err := expression.NewBuilder().WithFilter(filter).Build()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("error building scan expression")
+		return nil, err
+	}
+
+	input := &dynamodb.ScanInput{
+		TableName:                 &d.Table,
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		FilterExpression:          expr.Filter(),
+	}
+
+	var items []map[string]dbTypes.AttributeValue
+	paginator := dynamodb.NewScanPaginator(d.Client, input)
+	for paginator.HasMorePages() {
+		page, pageErr := paginator.NextPage(context.TODO())
+		if pageErr != nil {
+			return items, pageErr
+		}
+		items = append(items, page.Items...)
+	}
+	return items, nil
+}
+
+// legacySong is a song item from before countdowns, when its play order and time were kept on it
+type legacySong struct {
+	SongID         string
+	PlayedPosition *int
+	PlayedAt       *string
+}
+
+// GetLegacyVotes returns the votes from before countdowns, which were keyed by the song alone
+func (d *DynamoStorage) GetLegacyVotes() ([]songVote, error) {
+	items, err := d.scan(expression.And(
+		expression.Name(PartitionKey).BeginsWith(fmt.Sprintf("%s#", UserPartitionKey)),
+		expression.Name(SortKey).BeginsWith(fmt.Sprintf("%s#", SongPartitionKey)),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []songVote
+	for _, item := range items {
+		v := songVote{}
+		if err = attributevalue.UnmarshalMap(item, &v); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal legacy vote to songVote")
+			continue
+		}
+		v.CountdownID = ""
+		votes = append(votes, v)
+	}
+	return votes, nil
+}
+
+// GetLegacyPlays returns the plays from before countdowns, which were kept on the songs
+func (d *DynamoStorage) GetLegacyPlays() ([]SongPlay, error) {
+	items, err := d.scan(expression.And(
+		expression.Name(PartitionKey).BeginsWith(fmt.Sprintf("%s#", SongPartitionKey)),
+		expression.Name(SortKey).BeginsWith(fmt.Sprintf("%s#", SongSortKey)),
+		expression.Name("PlayedPosition").AttributeType(expression.Number),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var plays []SongPlay
+	for _, item := range items {
+		s := legacySong{}
+		if err = attributevalue.UnmarshalMap(item, &s); err != nil || s.PlayedPosition == nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal legacy song to legacySong")
+			continue
+		}
+		play := SongPlay{SongID: s.SongID, PlayOrder: *s.PlayedPosition}
+		if s.PlayedAt != nil {
+			play.PlayedAt = *s.PlayedAt
+		}
+		plays = append(plays, play)
+	}
+	return plays, nil
+}
+
+// GetLegacyPlayCount returns the play count from before countdowns
+func (d *DynamoStorage) GetLegacyPlayCount() (int, error) {
+	pc := PlayCount{}
+	found, err := d.getItem(LegacyPlayCountPartitionKey, LegacyCurrentSortKey, &pc)
+	if !found || pc.Value == nil {
+		return 0, err
+	}
+	return strconv.Atoi(*pc.Value)
+}
+
+// GetLegacyPlayedSongIDs returns the played list from before countdowns
+func (d *DynamoStorage) GetLegacyPlayedSongIDs() ([]string, error) {
+	playedSongs := PlayedSongs{}
+	_, err := d.getItem(LegacyPlayedSongsPartitionKey, LegacyCurrentSortKey, &playedSongs)
+	return playedSongs.SongIDs, err
+}
+
+// GetSongAlias looks up an alias by its provider and ID
+func (d *DynamoStorage) GetSongAlias(provider string, aliasID string) (*SongAlias, error) {
+	items, err := d.query(inverted(fmt.Sprintf("%s#", SongPartitionKey), AliasSortKey(provider, aliasID)), nil, true)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+
+	alias := SongAlias{}
+	if err = attributevalue.UnmarshalMap(items[0], &alias); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to unmarshal alias to SongAlias")
+		return nil, err
+	}
+	return &alias, nil
+}
+
+// GetSongAliases returns every alias of a song
+func (d *DynamoStorage) GetSongAliases(songID string) ([]SongAlias, error) {
+	s := Song{SongID: songID}
+	items, err := d.query(beginsWith(s.PKVal(), fmt.Sprintf("%s#", SongAliasSortKey)), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var aliases []SongAlias
+	for _, item := range items {
+		alias := SongAlias{}
+		if err = attributevalue.UnmarshalMap(item, &alias); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal alias to SongAlias")
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, nil
+}
+
+// PutSongAlias puts the alias item
+func (d *DynamoStorage) PutSongAlias(a *SongAlias) error {
+	return d.putItem(a)
+}
+
+// DeleteSongAlias deletes the alias item
+func (d *DynamoStorage) DeleteSongAlias(a *SongAlias) error {
+	return d.deleteItem(a.PKVal(), a.SKVal())
+}
+
+// MergeSong moves the duplicate song's aliases to the canonical song and marks it as merged in one transaction
+func (d *DynamoStorage) MergeSong(duplicateID string, canonicalID string, removed []SongAlias, added []SongAlias) error {
+	if len(removed)+len(added) >= transactionLimit {
+		return fmt.Errorf("the song has too many aliases to merge at once")
+	}
+
+	s := Song{SongID: duplicateID}
+	items := []dbTypes.TransactWriteItem{{
+		Update: &dbTypes.Update{
+			ExpressionAttributeNames: map[string]string{
+				"#MI": "MergedInto",
+			},
+			ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+				":mi": &dbTypes.AttributeValueMemberS{Value: canonicalID},
+			},
+			Key:                 itemKey(s.PKVal(), s.SKVal()),
+			TableName:           &d.Table,
+			ConditionExpression: aws.String("attribute_exists(PK) AND attribute_not_exists(#MI)"),
+			UpdateExpression:    aws.String("SET #MI = :mi"),
+		},
+	}}
+	for _, a := range removed {
+		items = append(items, dbTypes.TransactWriteItem{Delete: &dbTypes.Delete{Key: itemKey(a.PKVal(), a.SKVal()), TableName: &d.Table}})
+	}
+	for i := range added {
+		av, err := attributevalue.MarshalMap(added[i])
+		if err != nil {
+			return err
+		}
+		items = append(items, dbTypes.TransactWriteItem{Put: &dbTypes.Put{Item: av, TableName: &d.Table}})
+	}
+
+	_, err := d.Client.TransactWriteItems(context.TODO(), &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	return conditionalErr(err)
+}
+
+// GetPlayReview gets a play in a countdown that's waiting for review
+func (d *DynamoStorage) GetPlayReview(countdownID string, reviewID string) (*PlayReview, error) {
+	r := &PlayReview{CountdownID: countdownID, ReviewID: reviewID}
+	found, err := d.getItem(r.PKVal(), r.SKVal(), r)
+	if !found {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetPlayReviews returns every play in a countdown that's waiting for review
+func (d *DynamoStorage) GetPlayReviews(countdownID string) ([]PlayReview, error) {
+	items, err := d.query(beginsWith(countdownPK(countdownID), fmt.Sprintf("%s#", PlayReviewSortKey)), nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var reviews []PlayReview
+	for _, item := range items {
+		r := PlayReview{}
+		if err = attributevalue.UnmarshalMap(item, &r); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal review to PlayReview")
+			continue
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, nil
+}
+
+// PutPlayReview puts the review item if there isn't one for the play already
+func (d *DynamoStorage) PutPlayReview(r *PlayReview) error {
+	av, err := attributevalue.MarshalMap(r)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           &d.Table,
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	}
+	_, err = d.Client.PutItem(context.TODO(), input)
+	return conditionalErr(err)
+}
+
+// DeletePlayReview removes a play from the review list of a countdown
+func (d *DynamoStorage) DeletePlayReview(countdownID string, reviewID string) error {
+	r := PlayReview{CountdownID: countdownID, ReviewID: reviewID}
+	return d.deleteItem(r.PKVal(), r.SKVal())
+}
+
+// GetEndpoints returns the device endpoints a user has
+func (d *DynamoStorage) GetEndpoints(userID string) ([]PlatformEndpoint, error) {
+	u := User{UserID: userID}
+	items, err := d.query(beginsWith(u.PKVal(), fmt.Sprintf("%s#", EndpointSortKey)), []string{"Arn", "Platform", "Token"}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []PlatformEndpoint
+	for _, item := range items {
+		endpoint := PlatformEndpoint{}
+		if err = attributevalue.UnmarshalMap(item, &endpoint); err != nil {
+			logger.Log.Error().Err(err).Msg("Unable to unmarshal item to PlatformEndpoint")
+			continue
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+// PutEndpoint puts the endpoint item
+func (d *DynamoStorage) PutEndpoint(p *PlatformEndpoint) error {
+	return d.putItem(p)
+}
+
+// DeleteEndpoint deletes the endpoint item
+func (d *DynamoStorage) DeleteEndpoint(p *PlatformEndpoint) error {
+	return d.deleteItem(p.PKVal(), p.SKVal())
+}
+
+// GetNotificationPreferences returns the user's notification preferences item
+func (d *DynamoStorage) GetNotificationPreferences(userID string) (*NotificationPreferences, error) {
+	p := &NotificationPreferences{UserID: userID}
+	found, err := d.getItem(p.PKVal(), p.SKVal(), p)
+	if !found {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PutNotificationPreferences puts the user's notification preferences item
+func (d *DynamoStorage) PutNotificationPreferences(p *NotificationPreferences) error {
+	return d.putItem(p)
+}
+
+// unreadUpdate adds to the count of the user's unread inbox items
+func (d *DynamoStorage) unreadUpdate(userID string, add int) *dbTypes.Update {
+	u := User{UserID: userID}
+	return &dbTypes.Update{
+		Key:                      itemKey(u.PKVal(), InboxUnreadSortKey),
+		TableName:                &d.Table,
+		UpdateExpression:         aws.String("ADD #U :u"),
+		ExpressionAttributeNames: map[string]string{"#U": "Unread"},
+		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+			":u": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(add)},
+		},
+	}
+}
+
+// AddInboxItem puts the item in the user's inbox and counts it as unread in one transaction
+func (d *DynamoStorage) AddInboxItem(item *InboxItem) error {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return err
+	}
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dbTypes.TransactWriteItem{
+			{Put: &dbTypes.Put{Item: av, TableName: &d.Table, ConditionExpression: aws.String("attribute_not_exists(PK)")}},
+			{Update: d.unreadUpdate(item.UserID, 1)},
+		},
+	}
+	_, err = d.Client.TransactWriteItems(context.TODO(), input)
+	return conditionalErr(err)
+}
+
+// GetInboxItem returns an item in the user's inbox
+func (d *DynamoStorage) GetInboxItem(userID string, notificationID string) (*InboxItem, error) {
+	item := &InboxItem{UserID: userID, NotificationID: notificationID}
+	found, err := d.getItem(item.PKVal(), item.SKVal(), item)
+	if !found {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetInbox returns a page of the user's inbox, the cursor is the ID of the last item on the page before
+func (d *DynamoStorage) GetInbox(userID string, cursor string, limit int) (*inboxPage, error) {
+	after, err := decodeInboxCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	u := User{UserID: userID}
+	expr, err := expression.NewBuilder().WithKeyCondition(beginsWith(u.PKVal(), fmt.Sprintf("%s#", InboxSortKey))).Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// read one past the page so it's known if there's another
+	input := &dynamodb.QueryInput{
+		TableName:                 &d.Table,
+		KeyConditionExpression:    expr.KeyCondition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ScanIndexForward:          aws.Bool(false),
+		Limit:                     aws.Int32(int32(limit + 1)),
+	}
+	if after != "" {
+		last := InboxItem{UserID: userID, NotificationID: after}
+		input.ExclusiveStartKey = itemKey(last.PKVal(), last.SKVal())
+	}
+	result, err := d.Client.Query(context.TODO(), input)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &inboxPage{}
+	if err = attributevalue.UnmarshalListOfMaps(result.Items, &page.Items); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to unmarshal items to InboxItem")
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.Next = encodeInboxCursor(page.Items[limit-1].NotificationID)
+	}
+	return page, nil
+}
+
+// GetUnreadInboxIDs returns the IDs of the unread items in the user's inbox
+func (d *DynamoStorage) GetUnreadInboxIDs(userID string) ([]string, error) {
+	u := User{UserID: userID}
+	items, err := d.query(beginsWith(u.PKVal(), fmt.Sprintf("%s#", InboxSortKey)), []string{"NotificationID", "Read"}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var read []InboxItem
+	if err = attributevalue.UnmarshalListOfMaps(items, &read); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to unmarshal items to InboxItem")
+		return nil, err
+	}
+	var ids []string
+	for _, item := range read {
+		if !item.Read {
+			ids = append(ids, item.NotificationID)
+		}
+	}
+	return ids, nil
+}
+
+// MarkInboxItemRead marks the item in the user's inbox as read and takes it off the unread count in one transaction
+func (d *DynamoStorage) MarkInboxItemRead(userID string, notificationID string, readAt string) error {
+	item := InboxItem{UserID: userID, NotificationID: notificationID}
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []dbTypes.TransactWriteItem{
+			{Update: &dbTypes.Update{
+				Key:                      itemKey(item.PKVal(), item.SKVal()),
+				TableName:                &d.Table,
+				UpdateExpression:         aws.String("SET #R = :r, #A = :a"),
+				ConditionExpression:      aws.String("attribute_exists(PK) AND #R = :u"),
+				ExpressionAttributeNames: map[string]string{"#R": "Read", "#A": "ReadAt"},
+				ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
+					":r": &dbTypes.AttributeValueMemberBOOL{Value: true},
+					":u": &dbTypes.AttributeValueMemberBOOL{Value: false},
+					":a": &dbTypes.AttributeValueMemberS{Value: readAt},
+				},
+			}},
+			{Update: d.unreadUpdate(userID, -1)},
+		},
+	}
+	_, err := d.Client.TransactWriteItems(context.TODO(), input)
+	return conditionalErr(err)
+}
+
+// CountUnread returns the count of the user's unread inbox items
+func (d *DynamoStorage) CountUnread(userID string) (int, error) {
+	u := User{UserID: userID}
+	unread := inboxUnread{}
+	if _, err := d.getItem(u.PKVal(), InboxUnreadSortKey, &unread); err != nil {
+		return 0, err
+	}
+	if unread.Unread < 0 {
+		return 0, nil
+	}
+	return unread.Unread, nil
+}
@@ -1,15 +1,8 @@
 package types
 
 import (
-	"context"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/google/uuid"
-	"jjj.rflett.com/jjj-api/clients"
 	"jjj.rflett.com/jjj-api/logger"
 	"net/http"
 	"time"
@@ -43,18 +36,8 @@ func (g *Game) Create() (status int, error error) {
 	g.SK = g.SKVal()
 	g.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 
-	// create item
-	av, _ := attributevalue.MarshalMap(g)
-
-	// create input
-	input := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-
 	// add to table
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutGame(g)
 
 	// handle errors
 	if err != nil {
@@ -72,28 +55,8 @@ func (g *Game) Update() (status int, error error) {
 	updatedAt := time.Now().UTC().Format(time.RFC3339)
 	g.UpdatedAt = &updatedAt
 
-	// update query
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#N":  "Name",
-			"#D":  "Description",
-			"#UA": "UpdatedAt",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":ua": &dbTypes.AttributeValueMemberS{Value: *g.UpdatedAt},
-			":n":  &dbTypes.AttributeValueMemberS{Value: g.Name},
-			":d":  &dbTypes.AttributeValueMemberS{Value: g.Description},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: g.SKVal()},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("SET #N = :n, #UA = :ua, #D = :d"),
-	}
-
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+	// update the item
+	err := Store.UpdateGame(g)
 
 	// handle errors
 	if err != nil {
@@ -106,17 +69,8 @@ func (g *Game) Update() (status int, error error) {
 
 // Delete removes the game from the database
 func (g *Game) Delete() (status int, error error) {
-	// delete query
-	input := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: g.SKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// delete from table
-	_, err := clients.DynamoClient.DeleteItem(context.TODO(), input)
+	err := Store.DeleteGame(g.GroupID, g.GameID)
 
 	// handle errors
 	if err != nil {
@@ -130,29 +84,8 @@ func (g *Game) Delete() (status int, error error) {
 
 // Exists checks to see if the Game exists in the table already
 func (g *Game) Exists() (bool, error) {
-	// input
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(g.PKVal()))
-	skCondition := expression.Key(SortKey).Equal(expression.Value(g.SKVal()))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("GameID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for voteCount func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	result, err := Store.GetGame(g.GroupID, g.GameID)
 
 	// handle errors
 	if err != nil {
@@ -161,7 +94,7 @@ func (g *Game) Exists() (bool, error) {
 	}
 
 	// game doesn't exist
-	if len(result.Items) == 0 {
+	if result == nil {
 		logger.Log.Info().Str("groupID", g.GroupID).Str("gameID", g.GameID).Msg("Game does not exist in table")
 		return false, nil
 	}
@@ -1,19 +1,12 @@
 package types
 
 import (
-	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/aws/aws-sdk-go/aws"
 	"github.com/dchest/uniuri"
 	"github.com/google/uuid"
 	"github.com/skip2/go-qrcode"
-	"jjj.rflett.com/jjj-api/clients"
 	"jjj.rflett.com/jjj-api/logger"
 	"net/http"
 	"time"
@@ -64,18 +57,8 @@ func (g *Group) Create() (status int, error error) {
 	g.SK = g.SKVal()
 	g.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 
-	// create item
-	av, _ := attributevalue.MarshalMap(g)
-
-	// create input
-	input := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-
 	// add to table
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutGroup(g)
 
 	// handle errors
 	if err != nil {
@@ -106,29 +89,8 @@ func (g *Group) Update() (status int, error error) {
 	updatedAt := time.Now().UTC().Format(time.RFC3339)
 	g.UpdatedAt = &updatedAt
 
-	// update query
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#N":  "Name",
-			"#UA": "UpdatedAt",
-			"#O":  "OwnerID",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":ua": &dbTypes.AttributeValueMemberS{Value: *g.UpdatedAt},
-			":n":  &dbTypes.AttributeValueMemberS{Value: g.Name},
-			":o":  &dbTypes.AttributeValueMemberS{Value: g.OwnerID},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: g.SKVal()},
-		},
-		ReturnValues:        dbTypes.ReturnValueNone,
-		TableName:           &DynamoTable,
-		ConditionExpression: aws.String("#O = :o"),
-		UpdateExpression:    aws.String("SET #N = :n, #UA = :ua"),
-	}
-
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+	// update the item, only if the owner is still the owner
+	err := Store.UpdateGroup(g)
 
 	// handle errors
 	if err != nil {
@@ -145,26 +107,8 @@ func (g *Group) NominateOwner(userID string) (status int, error error) {
 	updatedAt := time.Now().UTC().Format(time.RFC3339)
 	g.UpdatedAt = &updatedAt
 
-	// update query
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#UA": "UpdatedAt",
-			"#O":  "OwnerID",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":ua": &dbTypes.AttributeValueMemberS{Value: *g.UpdatedAt},
-			":o":  &dbTypes.AttributeValueMemberS{Value: userID},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: g.SKVal()},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("SET #O = :o, #UA = :ua"),
-	}
-
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+	// update the item
+	err := Store.SetGroupOwner(g.GroupID, userID, updatedAt)
 
 	// handle errors
 	if err != nil {
@@ -177,17 +121,8 @@ func (g *Group) NominateOwner(userID string) (status int, error error) {
 
 // Get the group from the table
 func (g *Group) Get() (status int, error error) {
-	// get query
-	input := &dynamodb.GetItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: g.SKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// getItem
-	result, err := clients.DynamoClient.GetItem(context.TODO(), input)
+	result, err := Store.GetGroup(g.GroupID)
 
 	// handle errors
 	if err != nil {
@@ -195,15 +130,10 @@ func (g *Group) Get() (status int, error error) {
 		return http.StatusInternalServerError, err
 	}
 
-	if len(result.Item) == 0 {
+	if result == nil {
 		return http.StatusNotFound, nil
 	}
-
-	// unmarshal item into struct
-	err = attributevalue.UnmarshalMap(result.Item, &g)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("failed to unmarshal dynamo item to group")
-	}
+	*g = *result
 
 	// get the group code
 	g.Code, _ = g.GetCode()
@@ -215,29 +145,13 @@ func (g *Group) Delete() (status int, error error) {
 	owner := User{UserID: g.OwnerID}
 	_, _ = owner.LeaveGroup(g.GroupID)
 
-	// inputs
-	deleteGroupCodeInput := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", GroupCodeSortKey, g.Code)},
-		},
-		TableName: &DynamoTable,
-	}
-	deleteGroupInput := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: g.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: g.SKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// delete code from table
-	if _, err := clients.DynamoClient.DeleteItem(context.TODO(), deleteGroupCodeInput); err != nil {
+	if err := Store.DeleteGroupCode(g.GroupID, g.Code); err != nil {
 		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("error deleting group code item")
 	}
 
 	// delete group from table
-	if _, err := clients.DynamoClient.DeleteItem(context.TODO(), deleteGroupInput); err != nil {
+	if err := Store.DeleteGroup(g.GroupID); err != nil {
 		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("error deleting group item")
 		return http.StatusInternalServerError, err
 	}
@@ -249,13 +163,6 @@ func (g *Group) Delete() (status int, error error) {
 // AddUser a user to a group
 func (g *Group) AddUser(userID string) (status int, err error) {
 	user := User{UserID: userID}
-	member := groupMember{
-		PK:        g.PKVal(),
-		SK:        fmt.Sprintf("%s#%s", UserPartitionKey, userID),
-		GroupID:   g.GroupID,
-		UserID:    userID,
-		CreatedAt: time.Now().UTC().Format(time.RFC3339),
-	}
 
 	// get the users groups
 	var groups []Group
@@ -279,13 +186,7 @@ func (g *Group) AddUser(userID string) (status int, err error) {
 	}
 
 	// create the new group membership
-	av, _ := attributevalue.MarshalMap(member)
-	putMemberInput := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-	_, err = clients.DynamoClient.PutItem(context.TODO(), putMemberInput)
+	err = Store.PutMembership(g.GroupID, userID, time.Now().UTC().Format(time.RFC3339))
 	if err != nil {
 		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Str("userID", userID).Msg("Error adding user to group")
 		return http.StatusInternalServerError, err
@@ -298,29 +199,8 @@ func (g *Group) AddUser(userID string) (status int, err error) {
 
 // GetCode returns the code for a group
 func (g *Group) GetCode() (string, error) {
-	// input
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(g.PKVal()))
-	skCondition := expression.Key(SortKey).BeginsWith(fmt.Sprintf("%s#", GroupCodeSortKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("Code"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetCode func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	gc, err := Store.GetGroupCode(g.GroupID)
 
 	// handle errors
 	if err != nil {
@@ -328,13 +208,10 @@ func (g *Group) GetCode() (string, error) {
 		return "", err
 	}
 
-	// unmarshal groupID into the Group struct
-	gc := GroupCode{}
-	err = attributevalue.UnmarshalMap(result.Items[0], &gc)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("groupId", g.GroupID).Msg("error unmarshalling groupId to GroupCode")
-		fmt.Printf("Failed to unmarshal Record, %v", err)
-		return "", err
+	if gc == nil {
+		codeNotFoundErr := errors.New("group code not found")
+		logger.Log.Error().Err(codeNotFoundErr).Str("groupId", g.GroupID).Msg("group doesn't have a code")
+		return "", codeNotFoundErr
 	}
 	return gc.Code, nil
 }
@@ -369,7 +246,7 @@ func (g *Group) NewCode() error {
 	}
 
 	// return the error if we couldn't create the code
-	if &code == nil {
+	if code == "" {
 		newCodeError := errors.New("unable to generate new code")
 		logger.Log.Error().Err(newCodeError).Str("groupID", g.GroupID)
 		return newCodeError
@@ -383,13 +260,7 @@ func (g *Group) NewCode() error {
 	}
 
 	// add the code to the table
-	av, _ := attributevalue.MarshalMap(gc)
-	input := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutGroupCode(&gc)
 
 	// handle errors
 	if err != nil {
@@ -403,50 +274,26 @@ func (g *Group) NewCode() error {
 	return nil
 }
 
-// GetMembers returns all the members of a group
-func (g *Group) GetMembers(withVotes bool) ([]User, error) {
+// GetMembers returns all the members of a group with their points in the countdown
+func (g *Group) GetMembers(countdownID string, withVotes bool) ([]User, error) {
 	// get the users in the group
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(g.PKVal()))
-	skCondition := expression.Key(SortKey).BeginsWith(fmt.Sprintf("%s#", UserPartitionKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("UserID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetMembers func")
-	}
-
-	// input
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
-	groupMembers, err := clients.DynamoClient.Query(context.TODO(), input)
+	userIDs, err := Store.GetMemberIDs(g.GroupID)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("error getting group members")
 		return []User{}, err
 	}
 
 	var users []User = nil
-	for _, member := range groupMembers.Items {
-		user := User{}
-		if err = attributevalue.UnmarshalMap(member, &user); err != nil {
-			logger.Log.Error().Err(err).Msg("Unable to unmarshal group member to user")
-			continue
-		}
+	for _, userID := range userIDs {
+		user := User{UserID: userID}
 		if _, err = user.GetByUserID(); err != nil {
 			logger.Log.Error().Err(err).Msg("Unable to get user")
 			continue
 		}
+		_ = user.GetPoints(countdownID)
 		if withVotes {
 			// get the members votes
-			votes, voteErr := user.GetVotes()
+			votes, voteErr := user.GetVotes(countdownID)
 			if voteErr == nil {
 				user.Votes = &votes
 			}
@@ -458,26 +305,8 @@ func (g *Group) GetMembers(withVotes bool) ([]User, error) {
 
 // GetGames returns the games in a group
 func (g *Group) GetGames() ([]Game, error) {
-	// get the users in the group
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(g.PKVal()))
-	skCondition := expression.Key(SortKey).BeginsWith(fmt.Sprintf("%s#", GameSortKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetGames func")
-	}
-
-	// input
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-	}
-
-	groupsGames, err := clients.DynamoClient.Query(context.TODO(), input)
+	// get the games in the group
+	groupsGames, err := Store.GetGames(g.GroupID)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("error getting groups games")
 		return []Game{}, err
@@ -485,44 +314,14 @@ func (g *Group) GetGames() ([]Game, error) {
 
 	//goland:noinspection GoPreferNilSlice
 	games := []Game{}
-	for _, groupGame := range groupsGames.Items {
-		game := Game{}
-		if err = attributevalue.UnmarshalMap(groupGame, &game); err != nil {
-			logger.Log.Error().Err(err).Msg("Unable to unmarshal groupGame to game")
-			continue
-		}
-		games = append(games, game)
-	}
+	games = append(games, groupsGames...)
 	return games, nil
 }
 
 // ValidateCode checks if a code already exists against a group and returns an error if it does
 func validateGroupCode(code string) error {
-	// input
-	pkCondition := expression.Key(PartitionKey).BeginsWith(fmt.Sprintf("%s#", GroupCodePartitionKey))
-	skCondition := expression.Key(SortKey).Equal(expression.Value(fmt.Sprintf("%s#%s", GroupCodeSortKey, code)))
-	keyCondition := expression.KeyAnd(skCondition, pkCondition)
-
-	projExpr := expression.NamesList(expression.Name("Code"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for validateGroupCode func")
-	}
-
-	// input
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		IndexName:                 aws.String(GSI),
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	gc, err := Store.GetGroupCodeByCode(code)
 
 	// handle errors
 	if err != nil {
@@ -531,7 +330,7 @@ func validateGroupCode(code string) error {
 	}
 
 	// code doesn't exist
-	if len(result.Items) == 0 {
+	if gc == nil {
 		logger.Log.Info().Str("code", code).Msg("code does not exist")
 		return nil
 	}
@@ -0,0 +1,189 @@
+package types
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"net/http"
+	"time"
+)
+
+// inboxIDLayout starts every inbox ID so they sort in the order they were sent
+const inboxIDLayout = "20060102T150405.000Z"
+
+// InboxItem is a notification kept in a user's inbox, whether or not it was pushed to them
+type InboxItem struct {
+	PK             string       `json:"-" dynamodbav:"PK"`
+	SK             string       `json:"-" dynamodbav:"SK"`
+	UserID         string       `json:"-"`
+	NotificationID string       `json:"notificationID"`
+	Kind           string       `json:"kind"`
+	Notification   Notification `json:"notification"`
+	Read           bool         `json:"read"`
+	ReadAt         *string      `json:"readAt"`
+	CreatedAt      string       `json:"createdAt"`
+}
+
+// Inbox is a page of a user's inbox, newest first
+type Inbox struct {
+	UserID string      `json:"userID"`
+	Unread int         `json:"unread"` // Unread is how many are unread in the whole inbox, it's the app's badge
+	Items  []InboxItem `json:"items"`
+	Next   string      `json:"next,omitempty"` // Next is the cursor of the page after this one
+}
+
+// InboxCount is how many notifications in a user's inbox are unread, the app sets its badge to it
+type InboxCount struct {
+	Unread int `json:"unread"`
+}
+
+// inboxPage is a page of a user's inbox from a Storage
+type inboxPage struct {
+	Items []InboxItem
+	Next  string // Next is the cursor of the next page, it's empty on the last page
+}
+
+// inboxUnread is the count of a user's unread inbox items
+type inboxUnread struct {
+	PK     string `dynamodbav:"PK"`
+	SK     string `dynamodbav:"SK"`
+	Unread int
+}
+
+// return the partition key value for an inbox item
+func (i *InboxItem) PKVal() string {
+	return fmt.Sprintf("%s#%s", UserPartitionKey, i.UserID)
+}
+
+// return the sort key value for an inbox item
+func (i *InboxItem) SKVal() string {
+	return fmt.Sprintf("%s#%s", InboxSortKey, i.NotificationID)
+}
+
+// inboxID is the ID of a notification sent at the time. The key makes it unique, and is the same when a message is
+// delivered again so it's only kept once.
+func inboxID(sentAt time.Time, key string) string {
+	sum := sha1.Sum([]byte(key))
+	return fmt.Sprintf("%s-%x", sentAt.UTC().Format(inboxIDLayout), sum[:4])
+}
+
+// encodeInboxCursor is the cursor of the page after the item
+func encodeInboxCursor(notificationID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(notificationID))
+}
+
+// decodeInboxCursor returns the ID of the item the cursor's page starts after
+func decodeInboxCursor(cursor string) (string, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", ErrInvalidCursor
+	}
+	return string(id), nil
+}
+
+// AddToInbox keeps the notification sent at the time in the user's inbox as unread. The key is unique to the message
+// it came in, and a notification with the same time and key is only kept once.
+func (u *User) AddToInbox(n Notification, sentAt time.Time, key string) (*InboxItem, error) {
+	notificationID := inboxID(sentAt, key)
+	item := &InboxItem{
+		UserID:         u.UserID,
+		NotificationID: notificationID,
+		Kind:           n.Kind,
+		Notification:   n,
+		CreatedAt:      sentAt.UTC().Format(time.RFC3339),
+	}
+	item.Notification.Badge = nil
+	item.PK = item.PKVal()
+	item.SK = item.SKVal()
+
+	err := Store.AddInboxItem(item)
+	if err == ErrConditionalCheckFailed {
+		logger.Log.Info().Str("userID", u.UserID).Str("notificationID", notificationID).Msg("Notification is already in the inbox")
+		return item, nil
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Str("notificationID", notificationID).Msg("Unable to add the notification to the inbox")
+		return nil, err
+	}
+	return item, nil
+}
+
+// UnreadCount returns how many notifications in the user's inbox haven't been read
+func (u *User) UnreadCount() (int, error) {
+	unread, err := Store.CountUnread(u.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to count the user's unread notifications")
+		return 0, err
+	}
+	return unread, nil
+}
+
+// GetInbox returns a page of the user's inbox starting from the newest, or from the cursor of the page before it
+func (u *User) GetInbox(cursor string, limit int) (*Inbox, error) {
+	page, err := Store.GetInbox(u.UserID, cursor, limit)
+	if err != nil {
+		if err != ErrInvalidCursor {
+			logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to get the user's inbox")
+		}
+		return nil, err
+	}
+	unread, err := u.UnreadCount()
+	if err != nil {
+		return nil, err
+	}
+
+	inbox := &Inbox{UserID: u.UserID, Unread: unread, Items: page.Items, Next: page.Next}
+	if inbox.Items == nil {
+		inbox.Items = []InboxItem{}
+	}
+	return inbox, nil
+}
+
+// MarkRead marks a notification in the user's inbox as read, marking one that's already read does nothing
+func (u *User) MarkRead(notificationID string) (status int, error error) {
+	item, err := Store.GetInboxItem(u.UserID, notificationID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Str("notificationID", notificationID).Msg("Unable to get the inbox item")
+		return http.StatusInternalServerError, err
+	}
+	if item == nil {
+		return http.StatusNotFound, errors.New("the notification isn't in the inbox")
+	}
+	if item.Read {
+		return http.StatusOK, nil
+	}
+
+	// it could have been read since it was got
+	err = Store.MarkInboxItemRead(u.UserID, notificationID, time.Now().UTC().Format(time.RFC3339))
+	if err != nil && err != ErrConditionalCheckFailed {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Str("notificationID", notificationID).Msg("Unable to mark the notification as read")
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
+
+// MarkAllRead marks every notification in the user's inbox as read and returns how many there were
+func (u *User) MarkAllRead() (int, error) {
+	ids, err := Store.GetUnreadInboxIDs(u.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to get the user's unread notifications")
+		return 0, err
+	}
+
+	readAt := time.Now().UTC().Format(time.RFC3339)
+	marked := 0
+	for _, id := range ids {
+		err = Store.MarkInboxItemRead(u.UserID, id, readAt)
+		if err == ErrConditionalCheckFailed {
+			continue
+		}
+		if err != nil {
+			logger.Log.Error().Err(err).Str("userID", u.UserID).Str("notificationID", id).Msg("Unable to mark the notification as read")
+			return marked, err
+		}
+		marked++
+	}
+	return marked, nil
+}
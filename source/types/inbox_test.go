@@ -0,0 +1,70 @@
+package types
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestInbox(t *testing.T) {
+	UseTestStorage()
+	user := User{UserID: TestAuthProviderUserID}
+	sent := time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC)
+
+	badge := 3
+	for i := 0; i < 5; i++ {
+		n := Notification{Kind: NotificationSongPlayed, Title: fmt.Sprintf("#%d", i), Badge: &badge}
+		_, err := user.AddToInbox(n, sent.Add(time.Duration(i)*time.Minute), fmt.Sprintf("message-%d", i))
+		assert.Nil(t, err)
+	}
+
+	// the same message again is only kept once
+	_, err := user.AddToInbox(Notification{Kind: NotificationSongPlayed, Title: "#4"}, sent.Add(4*time.Minute), "message-4")
+	assert.Nil(t, err)
+	unread, err := user.UnreadCount()
+	assert.Nil(t, err)
+	assert.Equal(t, 5, unread)
+
+	// newest first, a page at a time
+	first, err := user.GetInbox("", 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, first.Unread)
+	if assert.Len(t, first.Items, 3) {
+		assert.Equal(t, "#4", first.Items[0].Notification.Title)
+		assert.Equal(t, "#2", first.Items[2].Notification.Title)
+		assert.Nil(t, first.Items[0].Notification.Badge)
+		assert.Equal(t, "2026-01-26T12:04:00Z", first.Items[0].CreatedAt)
+	}
+	assert.NotEmpty(t, first.Next)
+	second, err := user.GetInbox(first.Next, 3)
+	assert.Nil(t, err)
+	if assert.Len(t, second.Items, 2) {
+		assert.Equal(t, "#1", second.Items[0].Notification.Title)
+	}
+	assert.Empty(t, second.Next)
+	_, err = user.GetInbox("not a cursor!", 3)
+	assert.Equal(t, ErrInvalidCursor, err)
+
+	// reading one twice only counts it once
+	status, err := user.MarkRead(first.Items[0].NotificationID)
+	assert.Nil(t, err, status)
+	_, err = user.MarkRead(first.Items[0].NotificationID)
+	assert.Nil(t, err)
+	unread, _ = user.UnreadCount()
+	assert.Equal(t, 4, unread)
+	status, err = user.MarkRead("missing")
+	assert.Equal(t, 404, status)
+	assert.NotNil(t, err)
+
+	marked, err := user.MarkAllRead()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, marked)
+	unread, _ = user.UnreadCount()
+	assert.Equal(t, 0, unread)
+	inbox, _ := user.GetInbox("", 10)
+	for _, item := range inbox.Items {
+		assert.True(t, item.Read)
+		assert.NotNil(t, item.ReadAt)
+	}
+}
@@ -87,3 +87,33 @@ type ResponseBody struct {
 	Now         *Play  `json:"now"`
 	Prev        *Play  `json:"prev"`
 }
+
+// PlaysResponse is a page of the plays search, the most recent plays on a service
+type PlaysResponse struct {
+	Total  int    `json:"total"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Items  []Play `json:"items"`
+}
+
+// ArtistName is the name of the play's first artist, from the release or the recording if there's no release
+func (p *Play) ArtistName() string {
+	if p.Release != nil && len(p.Release.Artists) > 0 {
+		return p.Release.Artists[0].Name
+	}
+	if len(p.Recording.Artists) > 0 {
+		return p.Recording.Artists[0].Name
+	}
+	return ""
+}
+
+// ReleaseTitle is the title of the play's release, or the recording's first release if there isn't one
+func (p *Play) ReleaseTitle() string {
+	if p.Release != nil {
+		return p.Release.Title
+	}
+	if len(p.Recording.Releases) > 0 {
+		return p.Recording.Releases[0].Title
+	}
+	return ""
+}
@@ -0,0 +1,316 @@
+package types
+
+import (
+	"jjj.rflett.com/jjj-api/logger"
+	"sort"
+)
+
+// LeaderboardEntry is a user's place on a leaderboard, users with the same points share a rank. A group's Leaderboard
+// ranks them densely (1, 2, 2, 3) and the GlobalLeaderboard skips ahead after a tie (1, 2, 2, 4). Movement is how many
+// places they've gone up in a group since before the last song was played, it's negative when they've gone down.
+type LeaderboardEntry struct {
+	Rank      int     `json:"rank"`
+	Tied      bool    `json:"tied"`
+	Movement  *int    `json:"movement,omitempty"`
+	UserID    string  `json:"userID"`
+	Name      string  `json:"name"`
+	NickName  *string `json:"nickName"`
+	AvatarUrl *string `json:"avatarUrl"`
+	Points    int     `json:"points"`
+	SongsHit  int     `json:"songsHit"`
+}
+
+// Leaderboard is the members of a group ordered by their points in a countdown. Their ranks are dense, the next rank
+// follows straight on from a tie, unlike the GlobalLeaderboard's.
+type Leaderboard struct {
+	CountdownID string             `json:"countdownID"`
+	LastSongID  *string            `json:"lastSongID"` // LastSongID is the last song played, which Movement is measured from
+	Entries     []LeaderboardEntry `json:"entries"`
+}
+
+// leaderboardPage is a page of a countdown's leaderboard from a Storage
+type leaderboardPage struct {
+	Points    []userPoints
+	Offset    int         // Offset is how many users are ahead of the page
+	Following *userPoints // Following is the first user on the next page
+	Next      string      // Next is the cursor of the next page, it's empty on the last page
+}
+
+// denseRanks ranks the points, which are in descending order, so equal points share a rank and there are no gaps
+func denseRanks(points []int) []int {
+	ranks := make([]int, len(points))
+	for i := range points {
+		switch {
+		case i == 0:
+			ranks[i] = 1
+		case points[i] == points[i-1]:
+			ranks[i] = ranks[i-1]
+		default:
+			ranks[i] = ranks[i-1] + 1
+		}
+	}
+	return ranks
+}
+
+// rankEntries orders the entries by their points, then their name, and fills in their ranks. The previous points are
+// what each user had before the last song, and are used for their movement.
+func rankEntries(entries []LeaderboardEntry, previous map[string]int) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Points != entries[j].Points {
+			return entries[i].Points > entries[j].Points
+		}
+		if entries[i].Name != entries[j].Name {
+			return entries[i].Name < entries[j].Name
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	points := make([]int, len(entries))
+	for i := range entries {
+		points[i] = entries[i].Points
+	}
+	ranks := denseRanks(points)
+	for i := range entries {
+		entries[i].Rank = ranks[i]
+		entries[i].Tied = (i > 0 && points[i] == points[i-1]) || (i < len(points)-1 && points[i] == points[i+1])
+	}
+
+	// rank everyone again on what they had before the last song
+	before := make([]int, len(entries))
+	for i := range entries {
+		before[i] = previous[entries[i].UserID]
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(before)))
+	previousRanks := denseRanks(before)
+	rankOf := map[int]int{}
+	for i := range before {
+		if _, ok := rankOf[before[i]]; !ok {
+			rankOf[before[i]] = previousRanks[i]
+		}
+	}
+	for i := range entries {
+		movement := rankOf[previous[entries[i].UserID]] - entries[i].Rank
+		entries[i].Movement = &movement
+	}
+}
+
+// lastPlayedAwards returns the last song played in the countdown and the awards it gave out
+func lastPlayedAwards(countdownID string) (*string, []Award, error) {
+	played, err := Store.GetPlayedSongIDs(countdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", countdownID).Msg("Unable to get the played songs")
+		return nil, nil, err
+	}
+	if len(played) == 0 {
+		return nil, nil, nil
+	}
+	songID := played[len(played)-1]
+	awards, err := Store.GetSongAwards(countdownID, songID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("songID", songID).Msg("Unable to get the song's awards")
+		return nil, nil, err
+	}
+	return &songID, awards, nil
+}
+
+// newLeaderboard ranks the users with their points in the countdown
+func newLeaderboard(countdownID string, users []User, points []userPoints) (*Leaderboard, error) {
+	lastSongID, lastAwards, err := lastPlayedAwards(countdownID)
+	if err != nil {
+		return nil, err
+	}
+
+	byUser := map[string]userPoints{}
+	for _, up := range points {
+		byUser[up.UserID] = up
+	}
+	previous := map[string]int{}
+	for _, a := range lastAwards {
+		previous[a.UserID] -= a.Points
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(users))
+	for _, u := range users {
+		up := byUser[u.UserID]
+		previous[u.UserID] += up.Points
+		entries = append(entries, LeaderboardEntry{
+			UserID:    u.UserID,
+			Name:      u.Name,
+			NickName:  u.NickName,
+			AvatarUrl: u.AvatarUrl,
+			Points:    up.Points,
+			SongsHit:  up.Hits,
+		})
+	}
+	rankEntries(entries, previous)
+	return &Leaderboard{CountdownID: countdownID, LastSongID: lastSongID, Entries: entries}, nil
+}
+
+// Leaderboard ranks the group's members by their points in the countdown. Each member, their points and the last
+// song's awards are got in a handful of batched reads, however many members there are.
+func (g *Group) Leaderboard(countdownID string) (*Leaderboard, error) {
+	userIDs, err := Store.GetMemberIDs(g.GroupID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("Unable to get the group's members")
+		return nil, err
+	}
+	users, err := Store.GetUsers(userIDs)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("Unable to get the group's members")
+		return nil, err
+	}
+	points, err := Store.GetUsersPoints(countdownID, userIDs)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("Unable to get the group's points")
+		return nil, err
+	}
+	return newLeaderboard(countdownID, users, points)
+}
+
+// GlobalLeaderboard is a page of everyone in a countdown ordered by their points. Users with the same points share a
+// rank and the rank after a tie skips ahead, so a user's rank is one more than how many users have more points.
+type GlobalLeaderboard struct {
+	CountdownID string             `json:"countdownID"`
+	Entries     []LeaderboardEntry `json:"entries"`
+	Next        string             `json:"next,omitempty"` // Next is the cursor of the page after this one
+}
+
+// countAhead returns how many users have more than the points on the countdown's leaderboard
+func countAhead(countdownID string, points int) (int, error) {
+	ahead, err := Store.CountPointsAbove(countdownID, points)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", countdownID).Msg("Unable to count the users ahead on the leaderboard")
+	}
+	return ahead, err
+}
+
+// globalEntries ranks a run of users from the leaderboard, who are in order of their points and start at the position
+// (counting from 0). The first user's rank is given, it's at or before their position when they're tied with users
+// before the run. The user after the run tells whether the last one is tied.
+func globalEntries(points []userPoints, position int, rank int, after *userPoints) ([]LeaderboardEntry, error) {
+	entries := make([]LeaderboardEntry, 0, len(points))
+	if len(points) == 0 {
+		return entries, nil
+	}
+
+	userIDs := make([]string, 0, len(points))
+	for _, up := range points {
+		userIDs = append(userIDs, up.UserID)
+	}
+	users, err := Store.GetUsers(userIDs)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the users on the leaderboard")
+		return nil, err
+	}
+	byID := map[string]User{}
+	for _, u := range users {
+		byID[u.UserID] = u
+	}
+
+	for i, up := range points {
+		u := byID[up.UserID]
+		entry := LeaderboardEntry{
+			Rank:      position + i + 1,
+			UserID:    up.UserID,
+			Name:      u.Name,
+			NickName:  u.NickName,
+			AvatarUrl: u.AvatarUrl,
+			Points:    up.Points,
+			SongsHit:  up.Hits,
+		}
+		if i == 0 {
+			entry.Rank = rank
+			entry.Tied = rank <= position
+		} else if up.Points == points[i-1].Points {
+			entry.Rank = entries[i-1].Rank
+			entry.Tied = true
+			entries[i-1].Tied = true
+		}
+		entries = append(entries, entry)
+	}
+	if last := len(points) - 1; after != nil && after.Points == points[last].Points {
+		entries[last].Tied = true
+	}
+	return entries, nil
+}
+
+// GetLeaderboardPage returns a page of the countdown's leaderboard starting from the top, or from the cursor of the
+// page before it
+func GetLeaderboardPage(countdownID string, cursor string, limit int) (*GlobalLeaderboard, error) {
+	page, err := Store.GetLeaderboard(countdownID, cursor, limit)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", countdownID).Msg("Unable to get the leaderboard")
+		return nil, err
+	}
+
+	// the first user on a later page could be tied with users on the page before
+	rank := page.Offset + 1
+	if len(page.Points) > 0 && page.Offset > 0 {
+		ahead, err := countAhead(countdownID, page.Points[0].Points)
+		if err != nil {
+			return nil, err
+		}
+		rank = ahead + 1
+	}
+	entries, err := globalEntries(page.Points, page.Offset, rank, page.Following)
+	if err != nil {
+		return nil, err
+	}
+	return &GlobalLeaderboard{CountdownID: countdownID, Entries: entries, Next: page.Next}, nil
+}
+
+// GetLeaderboardAround returns the user's place on the countdown's leaderboard with up to limit users either side of
+// them. Users with the same points as them come after them.
+func GetLeaderboardAround(countdownID string, userID string, limit int) (*GlobalLeaderboard, error) {
+	me := userPoints{CountdownID: countdownID, UserID: userID}
+	mine, err := Store.GetUsersPoints(countdownID, []string{userID})
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", userID).Msg("Unable to get the user's points")
+		return nil, err
+	}
+	if len(mine) > 0 {
+		me = mine[0]
+	}
+	ahead, err := countAhead(countdownID, me.Points)
+	if err != nil {
+		return nil, err
+	}
+
+	// one more either side tells whether the users at the ends are tied, and the user is skipped from below
+	above, below, err := Store.GetLeaderboardAround(countdownID, me.Points, limit+2)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", countdownID).Msg("Unable to get the leaderboard")
+		return nil, err
+	}
+	var before *userPoints
+	if len(above) > limit {
+		before = &above[len(above)-limit-1]
+		above = above[len(above)-limit:]
+	}
+	var after *userPoints
+	others := make([]userPoints, 0, len(below))
+	for _, up := range below {
+		if up.UserID != userID {
+			others = append(others, up)
+		}
+	}
+	if len(others) > limit {
+		after = &others[limit]
+		others = others[:limit]
+	}
+	points := append(append(above, me), others...)
+
+	// the user is first of everyone with their points, so the users above them are the ones just ahead of them
+	position := ahead - len(above)
+	rank := position + 1
+	if before != nil && len(above) > 0 && before.Points == above[0].Points {
+		if rank, err = countAhead(countdownID, above[0].Points); err != nil {
+			return nil, err
+		}
+		rank++
+	}
+	entries, err := globalEntries(points, position, rank, after)
+	if err != nil {
+		return nil, err
+	}
+	return &GlobalLeaderboard{CountdownID: countdownID, Entries: entries}, nil
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+)
+
+// LegacyImport is what was copied into a countdown from before there were countdowns, or would be on a dry run
+type LegacyImport struct {
+	CountdownID string `json:"countdownID"`
+	DryRun      bool   `json:"dryRun"`
+	Votes       int    `json:"votes"`
+	Plays       int    `json:"plays"`
+	PlayCount   int    `json:"playCount"`
+}
+
+// ImportLegacy copies the votes, plays, play count and played list from before there were countdowns into the
+// countdown, with each play's position worked out by the countdown. The old items are left alone so it can be run
+// again, but not once the countdown has plays of its own. The points aren't copied, they're rebuilt from the votes
+// and plays by recomputing the countdown's scores.
+func (c *Countdown) ImportLegacy(dryRun bool) (*LegacyImport, error) {
+	votes, err := Store.GetLegacyVotes()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the legacy votes")
+		return nil, err
+	}
+	plays, err := Store.GetLegacyPlays()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the legacy plays")
+		return nil, err
+	}
+	playCount, err := Store.GetLegacyPlayCount()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the legacy play count")
+		return nil, err
+	}
+	playedSongIDs, err := Store.GetLegacyPlayedSongIDs()
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the legacy played list")
+		return nil, err
+	}
+
+	// only import into a countdown that hasn't been played yet, or has only had some of these plays imported
+	existing, err := Store.GetPlayedSongIDs(c.CountdownID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > len(playedSongIDs) {
+		return nil, fmt.Errorf("the countdown %s already has plays of its own", c.CountdownID)
+	}
+	for i, songID := range existing {
+		if playedSongIDs[i] != songID {
+			return nil, fmt.Errorf("the countdown %s already has plays of its own", c.CountdownID)
+		}
+	}
+
+	report := &LegacyImport{CountdownID: c.CountdownID, DryRun: dryRun, Votes: len(votes), Plays: len(plays), PlayCount: playCount}
+	if dryRun {
+		return report, nil
+	}
+
+	for i := range votes {
+		v := votes[i]
+		u := User{UserID: v.UserID}
+		v.PK, v.SK, v.CountdownID = u.PKVal(), voteSK(c.CountdownID, v.SongID), c.CountdownID
+		if err = Store.PutVote(&v); err != nil {
+			logger.Log.Error().Err(err).Str("userID", v.UserID).Str("songID", v.SongID).Msg("Unable to import the vote")
+			return nil, err
+		}
+	}
+	for i := range plays {
+		p := plays[i]
+		p.PK, p.SK, p.CountdownID = countdownPK(c.CountdownID), songPlaySK(p.SongID), c.CountdownID
+		p.Position = c.PositionOf(p.PlayOrder)
+		if err = Store.PutSongPlay(&p); err != nil {
+			logger.Log.Error().Err(err).Str("songID", p.SongID).Msg("Unable to import the play")
+			return nil, err
+		}
+	}
+	if playCount > 0 {
+		if err = Store.SetPlayCount(c.CountdownID, playCount); err != nil {
+			return nil, err
+		}
+	}
+	for _, songID := range playedSongIDs[len(existing):] {
+		if err = Store.AddPlayedSongID(c.CountdownID, songID); err != nil {
+			return nil, err
+		}
+	}
+	logger.Log.Info().Str("countdownID", c.CountdownID).Int("votes", report.Votes).Int("plays", report.Plays).Msg("Imported the legacy votes and plays")
+	return report, nil
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestImportLegacy(t *testing.T) {
+	m := UseTestStorage()
+	imported := Countdown{CountdownID: "hottest-100-2021", Name: "Hottest 100", Year: 2021, State: CountdownFinished}
+	imported.PK, imported.SK = imported.PKVal(), imported.SKVal()
+	assert.Nil(t, Store.PutCountdown(&imported))
+
+	// votes keyed by the song alone, and plays kept on the songs with their order as the points
+	m.legacy = legacyLayout{
+		votes: []songVote{
+			{PK: "USER#alex", SK: "SONG#first", UserID: "alex", SongID: "first", Rank: 1},
+			{PK: "USER#alex", SK: "SONG#second", UserID: "alex", SongID: "second", Rank: 2},
+			{PK: "USER#sam", SK: "SONG#second", UserID: "sam", SongID: "second", Rank: 1},
+		},
+		plays: []SongPlay{
+			{SongID: "first", PlayedAt: "2022-01-22T12:00:00+11:00", PlayOrder: 1},
+			{SongID: "second", PlayedAt: "2022-01-22T12:04:00+11:00", PlayOrder: 2},
+		},
+		playCount:     3,
+		playedSongIDs: []string{"first", "second"},
+	}
+
+	report, err := imported.ImportLegacy(true)
+	assert.Nil(t, err)
+	assert.Equal(t, LegacyImport{CountdownID: "hottest-100-2021", DryRun: true, Votes: 3, Plays: 2, PlayCount: 3}, *report)
+	votes, _ := Store.GetVotes("hottest-100-2021", "alex")
+	assert.Empty(t, votes)
+
+	_, err = imported.ImportLegacy(false)
+	assert.Nil(t, err)
+	votes, _ = Store.GetVotes("hottest-100-2021", "alex")
+	assert.Len(t, votes, 2)
+	count, _ := Store.GetPlayCount("hottest-100-2021")
+	assert.Equal(t, 3, count)
+	play, _ := Store.GetSongPlay("hottest-100-2021", "second")
+	if assert.NotNil(t, play) {
+		assert.Equal(t, 2, play.PlayOrder)
+		assert.Equal(t, 99, play.Position)
+	}
+
+	// running it again doesn't play anything twice
+	_, err = imported.ImportLegacy(false)
+	assert.Nil(t, err)
+	played, _ := Store.GetPlayedSongIDs("hottest-100-2021")
+	assert.Equal(t, []string{"first", "second"}, played)
+
+	// the points are rebuilt from the imported votes and plays, the same as they were scored before
+	changes, err := imported.RecomputeScores()
+	assert.Nil(t, err)
+	for _, change := range changes {
+		assert.Nil(t, imported.ApplyScoreChange(change))
+	}
+	points, _ := Store.GetUserPoints("hottest-100-2021", "alex")
+	assert.Equal(t, 3, points)
+	points, _ = Store.GetUserPoints("hottest-100-2021", "sam")
+	assert.Equal(t, 2, points)
+
+	// and it can't be imported into a countdown that's been played
+	assert.Nil(t, Store.AddPlayedSongID("hottest-200", "other"))
+	_, err = (&Countdown{CountdownID: "hottest-200"}).ImportLegacy(false)
+	assert.NotNil(t, err)
+}
@@ -1,10 +1,10 @@
 package types
 
 import (
-	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/golang-jwt/jwt"
-	"os"
+	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/config"
 )
 
 const (
@@ -17,22 +17,51 @@ const (
 	GroupCodeSortKey      = "#CODE"
 	GamePartitionKey      = "GROUP"
 	GameSortKey           = "GAME"
+	SnapshotSortKey       = "#SNAPSHOT"
 
 	SongPartitionKey = "SONG"
 	SongSortKey      = "#PROFILE"
+	SongAliasSortKey = "#ALIAS"
+
+	UserPartitionKey               = "USER"
+	UserSortKey                    = "#PROFILE"
+	UserAuthProviderPartitionKey   = "USER"
+	UserAuthProviderSortKey        = "#PROVIDER_ID"
+	EndpointSortKey                = "#ENDPOINT"
+	NotificationPreferencesSortKey = "#NOTIFICATIONS"
+	InboxSortKey                   = "#INBOX"
+	InboxUnreadSortKey             = "#UNREAD"
+
+	CountdownPartitionKey = "COUNTDOWN"
+	CountdownSortKey      = "#PROFILE"
+	CurrentCountdownKey   = "CURRENT"
+	PlayCountSortKey      = "#PLAYCOUNT"
+	PlayedSongsSortKey    = "#PLAYEDSONGS"
+	SongPlaySortKey       = "#PLAYED"
+	PlayReviewSortKey     = "#REVIEW"
+	UserPointsSortKey     = "#POINTS"
+	AwardSortKey          = "#AWARD"
+
+	// the play count and played list from before countdowns, which are only read to import them into one
+	LegacyPlayCountPartitionKey   = "PLAYCOUNT"
+	LegacyPlayedSongsPartitionKey = "PLAYEDSONGS"
+	LegacyCurrentSortKey          = "CURRENT"
+
+	LeaderboardPartitionKey = "LEADERBOARD"
 
-	UserPartitionKey             = "USER"
-	UserSortKey                  = "#PROFILE"
-	UserAuthProviderPartitionKey = "USER"
-	UserAuthProviderSortKey      = "#PROVIDER_ID"
-	EndpointSortKey              = "#ENDPOINT"
+	GSI = "GSI1"
+	// LeaderboardGSI sorts the points in each shard of a countdown's leaderboard
+	LeaderboardGSI = "GSI2"
 
-	PlayCountPartitionKey   = "PLAYCOUNT"
-	PlayCountSortKey        = "CURRENT"
-	PlayedSongsPartitionKey = "PLAYEDSONGS"
-	PlayedSongsSortKey      = "CURRENT"
+	CountdownDraft        = "draft"
+	CountdownVotingOpen   = "voting_open"
+	CountdownVotingClosed = "voting_closed"
+	CountdownLive         = "live"
+	CountdownFinished     = "finished"
 
-	GSI = "GSI1"
+	CountdownDescending    = "descending"
+	CountdownAscending     = "ascending"
+	DefaultCountdownLength = 100
 
 	AuthProviderGoogle    = "google"
 	AuthProviderGitHub    = "github"
@@ -41,12 +70,16 @@ const (
 	AuthProviderSpotify   = "spotify"
 	AuthProviderInternal  = "delegator"
 
+	AliasProviderSpotify = "spotify"
+	AliasProviderISRC    = "isrc"
+	AliasProviderArid    = "arid"
+	AliasProviderApple   = "apple"
+
 	SNSPlatformGoogle = "android"
 	SNSPlatformApple  = "ios"
 
 	GroupMembershipLimit = 10
 	VoteLimit            = 10
-	AppEnvVar            = "APP_ENV"
 
 	TestAuthProvider        = "delegator"
 	TestAuthProviderId      = "ryan.flett1@gmail.com"
@@ -57,10 +90,6 @@ const (
 )
 
 var (
-	JWTSigningSecret   = "jaypi-private-key-staging"
-	DynamoTable        = "jaypi-staging"
-	AssetsBucket       = "jaypi-assets-staging"
-	AssetsDomain       = "assets.staging.jaypi.online"
 	TestRequestContext = events.APIGatewayProxyRequestContext{
 		Authorizer: map[string]interface{}{
 			"AuthProvider":   TestAuthProvider,
@@ -69,15 +98,18 @@ var (
 			"UserID":         TestAuthProviderUserID,
 		},
 	}
+	TestAdminRequestContext = events.APIGatewayProxyRequestContext{
+		Authorizer: map[string]interface{}{
+			"AuthProvider":   TestAuthProvider,
+			"AuthProviderId": TestAuthProviderId,
+			"Name":           TestAuthProviderName,
+			"UserID":         TestAdminUserID,
+		},
+	}
 )
 
 func init() {
-	if v, ok := os.LookupEnv(AppEnvVar); ok {
-		DynamoTable = fmt.Sprintf("jaypi-%s", v)
-		JWTSigningSecret = fmt.Sprintf("jaypi-private-key-%s", v)
-		AssetsBucket = fmt.Sprintf("jaypi-assets-%s", v)
-		AssetsDomain = fmt.Sprintf("assets.%s.jaypi.online", v)
-	}
+	Store = NewDynamoStorage(clients.DynamoClient, config.Values.DynamoTable)
 }
 
 type PlayCount struct {
@@ -89,16 +121,24 @@ type ChuneRefreshBody struct {
 }
 
 type BeanCounterBody struct {
-	SongID string `json:"songID"`
+	CountdownID string `json:"countdownID"`
+	SongID      string `json:"songID"`
 }
 
 type ScoreTakerBody struct {
-	Points int    `json:"points"`
-	UserID string `json:"userID"`
+	CountdownID string `json:"countdownID"`
+	Points      int    `json:"points"`
+	UserID      string `json:"userID"`
+	Award       *Award `json:"award,omitempty"` // Award is saved for the user, Points can differ from it when an award is corrected
 }
 
+// CrierBody is a notification for a user, the song and what it scored them are there when it's about a play
 type CrierBody struct {
-	UserID string `json:"userID"`
+	UserID      string `json:"userID"`
+	CountdownID string `json:"countdownID,omitempty"`
+	SongID      string `json:"songID,omitempty"`
+	Position    int    `json:"position,omitempty"`
+	Points      int    `json:"points,omitempty"`
 	Notification
 }
 
@@ -117,6 +157,16 @@ type UserClaims struct {
 	jwt.StandardClaims
 }
 
+// AuthorizerContext returns the claims as the context the authorizer passes on to the handlers
+func (c *UserClaims) AuthorizerContext() map[string]interface{} {
+	return map[string]interface{}{
+		"AuthProvider":   c.AuthProvider,
+		"AuthProviderId": c.AuthProviderId,
+		"Name":           c.Name,
+		"UserID":         c.Subject,
+	}
+}
+
 // userAuthProvider represents a user and their AuthProviderId
 type userAuthProvider struct {
 	PK             string `json:"-" dynamodbav:"PK"`
@@ -126,15 +176,17 @@ type userAuthProvider struct {
 	AuthProvider   string `json:"authProvider"`
 }
 
-// songVote is a votes in a users top 10
+// songVote is a votes in a users top 10 for a countdown
 type songVote struct {
-	PK     string `json:"-" dynamodbav:"PK"`
-	SK     string `json:"-" dynamodbav:"SK"`
-	SongID string `json:"songID"`
-	UserID string `json:"userID"`
-	Rank   int    `json:"rank"`
+	PK          string `json:"-" dynamodbav:"PK"`
+	SK          string `json:"-" dynamodbav:"SK"`
+	CountdownID string `json:"countdownID"`
+	SongID      string `json:"songID"`
+	UserID      string `json:"userID"`
+	Rank        int    `json:"rank"`
 }
 
+// GetAsSong returns the song that was voted for, and when it was played in the vote's countdown
 func (s *songVote) GetAsSong() (Song, error) {
 	song := Song{
 		SongID: s.SongID,
@@ -142,6 +194,9 @@ func (s *songVote) GetAsSong() (Song, error) {
 	if err := song.Get(); err != nil {
 		return Song{}, err
 	}
+	if err := song.GetPlay(s.CountdownID); err != nil {
+		return Song{}, err
+	}
 	song.Rank = &s.Rank
 	return song, nil
 }
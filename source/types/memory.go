@@ -0,0 +1,1293 @@
+package types
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MemoryStorage is an in-memory Storage, useful for running locally or in tests without a DynamoDB table
+type MemoryStorage struct {
+	mu            sync.Mutex
+	users         map[string]User
+	authProviders map[string]string                // provider#providerID -> userID
+	votes         map[string]map[string]songVote   // userID -> vote sort key -> vote
+	points        map[string]map[string]userPoints // countdownID -> userID -> points
+	awards        map[string]map[string]Award      // userID -> award sort key -> award
+	countdowns    map[string]Countdown
+	current       string
+	groups        map[string]Group
+	codes         map[string]GroupCode           // code -> GroupCode
+	memberships   map[string]map[string]string   // groupID -> userID -> createdAt
+	games         map[string]map[string]Game     // groupID -> gameID -> game
+	snapshots     map[string]map[string]Snapshot // groupID -> snapshot sort key -> snapshot
+	songs         map[string]Song
+	aliases       map[string]SongAlias                   // alias sort key -> alias
+	plays         map[string]map[string]SongPlay         // countdownID -> songID -> play
+	reviews       map[string]map[string]PlayReview       // countdownID -> reviewID -> review
+	playCounts    map[string]int                         // countdownID -> play count
+	playedSongIDs map[string][]string                    // countdownID -> played list
+	endpoints     map[string]map[string]PlatformEndpoint // userID -> SK -> endpoint
+	preferences   map[string]NotificationPreferences
+	inbox         map[string]map[string]InboxItem // userID -> notificationID -> item
+	legacy        legacyLayout
+}
+
+// legacyLayout is the votes and plays from before countdowns
+type legacyLayout struct {
+	votes         []songVote
+	plays         []SongPlay
+	playCount     int
+	playedSongIDs []string
+}
+
+// NewMemoryStorage returns an empty MemoryStorage, the play count of each countdown starts at 1
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		users:         map[string]User{},
+		authProviders: map[string]string{},
+		votes:         map[string]map[string]songVote{},
+		points:        map[string]map[string]userPoints{},
+		awards:        map[string]map[string]Award{},
+		countdowns:    map[string]Countdown{},
+		groups:        map[string]Group{},
+		codes:         map[string]GroupCode{},
+		memberships:   map[string]map[string]string{},
+		games:         map[string]map[string]Game{},
+		snapshots:     map[string]map[string]Snapshot{},
+		songs:         map[string]Song{},
+		aliases:       map[string]SongAlias{},
+		plays:         map[string]map[string]SongPlay{},
+		reviews:       map[string]map[string]PlayReview{},
+		playCounts:    map[string]int{},
+		playedSongIDs: map[string][]string{},
+		endpoints:     map[string]map[string]PlatformEndpoint{},
+		preferences:   map[string]NotificationPreferences{},
+		inbox:         map[string]map[string]InboxItem{},
+	}
+}
+
+// sortedKeys returns the keys of a map in order so results are stable like a dynamo query on the sort key
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetUser gets a user by their ID
+func (m *MemoryStorage) GetUser(userID string) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &u, nil
+}
+
+// GetUsers gets users by their IDs, missing users are skipped
+func (m *MemoryStorage) GetUsers(userIDs []string) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var users []User
+	for _, userID := range userIDs {
+		if u, ok := m.users[userID]; ok {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+// PutUser puts the user
+func (m *MemoryStorage) PutUser(u *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *u
+	stored.Groups = nil
+	stored.Points = 0
+	m.users[u.UserID] = stored
+	return nil
+}
+
+// UpdateUser updates the user's nickname
+func (m *MemoryStorage) UpdateUser(u *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.users[u.UserID]
+	stored.UserID = u.UserID
+	stored.NickName = u.NickName
+	stored.UpdatedAt = u.UpdatedAt
+	m.users[u.UserID] = stored
+	return nil
+}
+
+// SetUserAvatarUrl sets the user's avatar url
+func (m *MemoryStorage) SetUserAvatarUrl(userID string, avatarUrl string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.users[userID]
+	stored.UserID = userID
+	stored.AvatarUrl = &avatarUrl
+	m.users[userID] = stored
+	return nil
+}
+
+// GetUserPoints returns the user's points in a countdown
+func (m *MemoryStorage) GetUserPoints(countdownID string, userID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.points[countdownID][userID].Points, nil
+}
+
+// GetUsersPoints returns the points of each of the users in a countdown, users without points are skipped
+func (m *MemoryStorage) GetUsersPoints(countdownID string, userIDs []string) ([]userPoints, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var points []userPoints
+	for _, userID := range userIDs {
+		if up, ok := m.points[countdownID][userID]; ok {
+			points = append(points, up)
+		}
+	}
+	return points, nil
+}
+
+// GetCountdownPoints returns the points of every user with points in a countdown
+func (m *MemoryStorage) GetCountdownPoints(countdownID string) (map[string]userPoints, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	points := map[string]userPoints{}
+	for userID, up := range m.points[countdownID] {
+		points[userID] = up
+	}
+	return points, nil
+}
+
+// leaderboard returns everyone's points in a countdown ordered by their points, then their userID
+func (m *MemoryStorage) leaderboard(countdownID string) []userPoints {
+	points := make([]userPoints, 0, len(m.points[countdownID]))
+	for _, up := range m.points[countdownID] {
+		points = append(points, up)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].Points != points[j].Points {
+			return points[i].Points > points[j].Points
+		}
+		return points[i].UserID < points[j].UserID
+	})
+	return points
+}
+
+// GetLeaderboard returns a page of the countdown's leaderboard, the cursor is the offset of the page
+func (m *MemoryStorage) GetLeaderboard(countdownID string, cursor string, limit int) (*leaderboardPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	page := &leaderboardPage{}
+	if cursor != "" {
+		offset, err := strconv.Atoi(cursor)
+		if err != nil || offset < 0 {
+			return nil, ErrInvalidCursor
+		}
+		page.Offset = offset
+	}
+
+	points := m.leaderboard(countdownID)
+	for i := page.Offset; i < len(points) && i < page.Offset+limit; i++ {
+		page.Points = append(page.Points, points[i])
+	}
+	if end := page.Offset + limit; end < len(points) {
+		page.Following = &points[end]
+		page.Next = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+// GetLeaderboardAround returns up to limit of the users with the fewest points more than the points, and the first
+// limit users with the points or less, both in order
+func (m *MemoryStorage) GetLeaderboardAround(countdownID string, points int, limit int) ([]userPoints, []userPoints, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var above, below []userPoints
+	for _, up := range m.leaderboard(countdownID) {
+		if up.Points > points {
+			above = append(above, up)
+		} else if len(below) < limit {
+			below = append(below, up)
+		}
+	}
+	if len(above) > limit {
+		above = above[len(above)-limit:]
+	}
+	return above, below, nil
+}
+
+// CountPointsAbove returns how many users have more than the points in a countdown
+func (m *MemoryStorage) CountPointsAbove(countdownID string, points int) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, up := range m.points[countdownID] {
+		if up.Points > points {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// addPoints adds to the user's points and songs hit in a countdown
+func (m *MemoryStorage) addPoints(countdownID string, userID string, points int, hits int) {
+	if _, ok := m.points[countdownID]; !ok {
+		m.points[countdownID] = map[string]userPoints{}
+	}
+	up := m.points[countdownID][userID]
+	up.CountdownID, up.UserID, up.LeaderboardPK = countdownID, userID, leaderboardPK(countdownID, userID)
+	up.Points += points
+	up.Hits += hits
+	m.points[countdownID][userID] = up
+}
+
+// GetUserIDByAuthProvider looks up a user ID by their auth provider and the ID they have with it
+func (m *MemoryStorage) GetUserIDByAuthProvider(provider string, providerID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.authProviders[provider+"#"+providerID], nil
+}
+
+// PutAuthProvider maps a user to their auth provider
+func (m *MemoryStorage) PutAuthProvider(userID string, provider string, providerID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.authProviders[provider+"#"+providerID] = userID
+	return nil
+}
+
+// GetVotes returns a user's votes in a countdown
+func (m *MemoryStorage) GetVotes(countdownID string, userID string) ([]songVote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefix := voteSK(countdownID, "")
+	var keys []string
+	for sk := range m.votes[userID] {
+		if strings.HasPrefix(sk, prefix) {
+			keys = append(keys, sk)
+		}
+	}
+	sort.Strings(keys)
+
+	var votes []songVote
+	for _, sk := range keys {
+		votes = append(votes, m.votes[userID][sk])
+	}
+	return votes, nil
+}
+
+// CountVotes returns the number of votes a user has in a countdown
+func (m *MemoryStorage) CountVotes(countdownID string, userID string) (int, error) {
+	votes, err := m.GetVotes(countdownID, userID)
+	return len(votes), err
+}
+
+// PutVote puts a user's vote for a song
+func (m *MemoryStorage) PutVote(vote *songVote) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.votes[vote.UserID]; !ok {
+		m.votes[vote.UserID] = map[string]songVote{}
+	}
+	m.votes[vote.UserID][voteSK(vote.CountdownID, vote.SongID)] = *vote
+	return nil
+}
+
+// DeleteVote removes a user's vote for a song in a countdown
+func (m *MemoryStorage) DeleteVote(countdownID string, userID string, songID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.votes[userID], voteSK(countdownID, songID))
+	return nil
+}
+
+// GetVoters returns the votes for a song in a countdown
+func (m *MemoryStorage) GetVoters(countdownID string, songID string) ([]songVote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var voters []songVote
+	for userID, votes := range m.votes {
+		if vote, ok := votes[voteSK(countdownID, songID)]; ok {
+			voters = append(voters, songVote{UserID: userID, SongID: vote.SongID, Rank: vote.Rank})
+		}
+	}
+	sort.Slice(voters, func(i, j int) bool {
+		return voters[i].UserID < voters[j].UserID
+	})
+	return voters, nil
+}
+
+// RecordAward saves the award and adds the points to the user's score together. The award has to be the next revision
+// of the stored one, or the first, and ErrConditionalCheckFailed is returned if it isn't.
+func (m *MemoryStorage) RecordAward(award *Award, points int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous, exists := m.awards[award.UserID][award.SKVal()]
+	if (award.Revision <= 1 && exists) || (award.Revision > 1 && (!exists || previous.Revision != award.Revision-1)) {
+		return ErrConditionalCheckFailed
+	}
+
+	if _, ok := m.awards[award.UserID]; !ok {
+		m.awards[award.UserID] = map[string]Award{}
+	}
+	m.awards[award.UserID][award.SKVal()] = *award
+	hits := 0
+	if !exists {
+		hits = 1
+	}
+	m.addPoints(award.CountdownID, award.UserID, points, hits)
+	return nil
+}
+
+// ResetUserScores replaces each user's points and songs hit in a countdown, saving their change's awards and deleting
+// its removed ones. Like a transaction, nothing is saved if any of the users' points have changed or the changes have
+// more items than DynamoDB takes in one.
+func (m *MemoryStorage) ResetUserScores(countdownID string, changes []ScoreChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	items := 0
+	for _, change := range changes {
+		items += change.items()
+		if m.points[countdownID][change.UserID].Points != change.Previous {
+			return ErrConditionalCheckFailed
+		}
+	}
+	if items > transactionLimit {
+		return ErrTransactionTooBig
+	}
+
+	for _, change := range changes {
+		if _, ok := m.awards[change.UserID]; !ok {
+			m.awards[change.UserID] = map[string]Award{}
+		}
+		for _, a := range change.Awards {
+			m.awards[change.UserID][a.SKVal()] = a
+		}
+		for _, a := range change.Removed {
+			delete(m.awards[change.UserID], a.SKVal())
+		}
+		if _, ok := m.points[countdownID]; !ok {
+			m.points[countdownID] = map[string]userPoints{}
+		}
+		m.points[countdownID][change.UserID] = userPoints{
+			CountdownID:   countdownID,
+			UserID:        change.UserID,
+			Points:        change.Points,
+			Hits:          change.Hits,
+			LeaderboardPK: leaderboardPK(countdownID, change.UserID),
+		}
+	}
+	return nil
+}
+
+// GetSongAwards returns the points each voter got for a song in a countdown, ordered by their userID
+func (m *MemoryStorage) GetSongAwards(countdownID string, songID string) ([]Award, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sk := (&Award{CountdownID: countdownID, SongID: songID}).SKVal()
+	var awards []Award
+	for _, userAwards := range m.awards {
+		if a, ok := userAwards[sk]; ok {
+			awards = append(awards, a)
+		}
+	}
+	sort.Slice(awards, func(i, j int) bool {
+		return awards[i].UserID < awards[j].UserID
+	})
+	return awards, nil
+}
+
+// GetAward returns the points a user got for a song in a countdown
+func (m *MemoryStorage) GetAward(countdownID string, userID string, songID string) (*Award, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := Award{CountdownID: countdownID, SongID: songID}
+	a, ok := m.awards[userID][key.SKVal()]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// GetAwards returns the points a user got for each song in a countdown
+func (m *MemoryStorage) GetAwards(countdownID string, userID string) ([]Award, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefix := Award{CountdownID: countdownID}
+	var awards []Award
+	for sk, a := range m.awards[userID] {
+		if strings.HasPrefix(sk, prefix.SKVal()) {
+			awards = append(awards, a)
+		}
+	}
+	sort.Slice(awards, func(i, j int) bool {
+		return awards[i].SongID < awards[j].SongID
+	})
+	return awards, nil
+}
+
+// GetCountdown gets a countdown by its ID
+func (m *MemoryStorage) GetCountdown(countdownID string) (*Countdown, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.countdowns[countdownID]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+// GetCountdowns returns every countdown
+func (m *MemoryStorage) GetCountdowns() ([]Countdown, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var countdowns []Countdown
+	for _, c := range m.countdowns {
+		countdowns = append(countdowns, c)
+	}
+	sort.Slice(countdowns, func(i, j int) bool {
+		return countdowns[i].CountdownID < countdowns[j].CountdownID
+	})
+	return countdowns, nil
+}
+
+// PutCountdown puts the countdown
+func (m *MemoryStorage) PutCountdown(c *Countdown) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *c
+	stored.Current = false
+	m.countdowns[c.CountdownID] = stored
+	return nil
+}
+
+// GetCurrentCountdownID returns the ID of the current countdown, or an empty string if there isn't one
+func (m *MemoryStorage) GetCurrentCountdownID() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.current, nil
+}
+
+// SetCurrentCountdownID makes the countdown the current one
+func (m *MemoryStorage) SetCurrentCountdownID(countdownID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.current = countdownID
+	return nil
+}
+
+// GetGroup gets a group by its ID
+func (m *MemoryStorage) GetGroup(groupID string) (*Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.groups[groupID]
+	if !ok {
+		return nil, nil
+	}
+	return &g, nil
+}
+
+// PutGroup puts the group
+func (m *MemoryStorage) PutGroup(g *Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *g
+	stored.Code = ""
+	m.groups[g.GroupID] = stored
+	return nil
+}
+
+// UpdateGroup updates the group's name, the group's OwnerID must match the stored owner
+func (m *MemoryStorage) UpdateGroup(g *Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.groups[g.GroupID]
+	if !ok || stored.OwnerID != g.OwnerID {
+		return ErrConditionalCheckFailed
+	}
+	stored.Name = g.Name
+	stored.UpdatedAt = g.UpdatedAt
+	m.groups[g.GroupID] = stored
+	return nil
+}
+
+// SetGroupOwner sets the owner of the group
+func (m *MemoryStorage) SetGroupOwner(groupID string, ownerID string, updatedAt string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := m.groups[groupID]
+	stored.GroupID = groupID
+	stored.OwnerID = ownerID
+	stored.UpdatedAt = &updatedAt
+	m.groups[groupID] = stored
+	return nil
+}
+
+// DeleteGroup deletes the group
+func (m *MemoryStorage) DeleteGroup(groupID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.groups, groupID)
+	return nil
+}
+
+// GetGroupCode returns the code for a group
+func (m *MemoryStorage) GetGroupCode(groupID string) (*GroupCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var codes []string
+	for code, gc := range m.codes {
+		if gc.GroupID == groupID {
+			codes = append(codes, code)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	sort.Strings(codes)
+	gc := m.codes[codes[0]]
+	return &gc, nil
+}
+
+// GetGroupCodeByCode looks up a group code by the code itself
+func (m *MemoryStorage) GetGroupCodeByCode(code string) (*GroupCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gc, ok := m.codes[code]
+	if !ok {
+		return nil, nil
+	}
+	return &gc, nil
+}
+
+// PutGroupCode puts the group code
+func (m *MemoryStorage) PutGroupCode(gc *GroupCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.codes[gc.Code] = *gc
+	return nil
+}
+
+// DeleteGroupCode deletes a group code
+func (m *MemoryStorage) DeleteGroupCode(groupID string, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if gc, ok := m.codes[code]; ok && gc.GroupID == groupID {
+		delete(m.codes, code)
+	}
+	return nil
+}
+
+// PutMembership adds a user to a group
+func (m *MemoryStorage) PutMembership(groupID string, userID string, createdAt string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.memberships[groupID]; !ok {
+		m.memberships[groupID] = map[string]string{}
+	}
+	m.memberships[groupID][userID] = createdAt
+	return nil
+}
+
+// DeleteMembership removes a user from a group
+func (m *MemoryStorage) DeleteMembership(groupID string, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.memberships[groupID], userID)
+	return nil
+}
+
+// IsMember returns whether a user is a member of a group
+func (m *MemoryStorage) IsMember(groupID string, userID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.memberships[groupID][userID]
+	return ok, nil
+}
+
+// GetGroupIDs returns the IDs of the groups a user is a member of
+func (m *MemoryStorage) GetGroupIDs(userID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var groupIDs []string
+	for groupID, members := range m.memberships {
+		if _, ok := members[userID]; ok {
+			groupIDs = append(groupIDs, groupID)
+		}
+	}
+	sort.Strings(groupIDs)
+	return groupIDs, nil
+}
+
+// GetMemberIDs returns the IDs of the users in a group
+func (m *MemoryStorage) GetMemberIDs(groupID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return sortedKeys(m.memberships[groupID]), nil
+}
+
+// GetGame gets a game in a group
+func (m *MemoryStorage) GetGame(groupID string, gameID string) (*Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.games[groupID][gameID]
+	if !ok {
+		return nil, nil
+	}
+	return &g, nil
+}
+
+// GetGames returns the games in a group
+func (m *MemoryStorage) GetGames(groupID string) ([]Game, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var gameIDs []string
+	for gameID := range m.games[groupID] {
+		gameIDs = append(gameIDs, gameID)
+	}
+	sort.Strings(gameIDs)
+
+	var games []Game
+	for _, gameID := range gameIDs {
+		games = append(games, m.games[groupID][gameID])
+	}
+	return games, nil
+}
+
+// PutGame puts the game
+func (m *MemoryStorage) PutGame(g *Game) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[g.GroupID]; !ok {
+		m.games[g.GroupID] = map[string]Game{}
+	}
+	m.games[g.GroupID][g.GameID] = *g
+	return nil
+}
+
+// UpdateGame updates the game's name and description
+func (m *MemoryStorage) UpdateGame(g *Game) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.games[g.GroupID]; !ok {
+		m.games[g.GroupID] = map[string]Game{}
+	}
+	stored := m.games[g.GroupID][g.GameID]
+	stored.GroupID = g.GroupID
+	stored.GameID = g.GameID
+	stored.Name = g.Name
+	stored.Description = g.Description
+	stored.UpdatedAt = g.UpdatedAt
+	m.games[g.GroupID][g.GameID] = stored
+	return nil
+}
+
+// DeleteGame deletes a game from a group
+func (m *MemoryStorage) DeleteGame(groupID string, gameID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.games[groupID], gameID)
+	return nil
+}
+
+// PutSnapshot puts the snapshot, replacing any taken after the same play
+func (m *MemoryStorage) PutSnapshot(s *Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.snapshots[s.GroupID]; !ok {
+		m.snapshots[s.GroupID] = map[string]Snapshot{}
+	}
+	stored := *s
+	stored.Standings = append([]Standing(nil), s.Standings...)
+	m.snapshots[s.GroupID][s.SKVal()] = stored
+	return nil
+}
+
+// GetSnapshots returns the group's snapshots in the countdown in the order of their plays
+func (m *MemoryStorage) GetSnapshots(groupID string, countdownID string) ([]Snapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sks []string
+	for sk := range m.snapshots[groupID] {
+		if strings.HasPrefix(sk, snapshotSKPrefix(countdownID)) {
+			sks = append(sks, sk)
+		}
+	}
+	sort.Strings(sks)
+
+	var snapshots []Snapshot
+	for _, sk := range sks {
+		snapshots = append(snapshots, m.snapshots[groupID][sk])
+	}
+	return snapshots, nil
+}
+
+// GetSong gets a song by its ID
+func (m *MemoryStorage) GetSong(songID string) (*Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.songs[songID]
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+// GetSongs gets songs by their IDs, missing songs are skipped
+func (m *MemoryStorage) GetSongs(songIDs []string) ([]Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var songs []Song
+	for _, songID := range songIDs {
+		if s, ok := m.songs[songID]; ok {
+			songs = append(songs, s)
+		}
+	}
+	return songs, nil
+}
+
+// ListSongs returns every song
+func (m *MemoryStorage) ListSongs() ([]Song, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var songIDs []string
+	for songID := range m.songs {
+		songIDs = append(songIDs, songID)
+	}
+	sort.Strings(songIDs)
+
+	var songs []Song
+	for _, songID := range songIDs {
+		songs = append(songs, m.songs[songID])
+	}
+	return songs, nil
+}
+
+// PutSong puts the song
+func (m *MemoryStorage) PutSong(s *Song) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *s
+	stored.Rank = nil
+	stored.Aliases = nil
+	stored.PlayedAt = nil
+	stored.PlayedPosition = nil
+	m.songs[s.SongID] = stored
+	return nil
+}
+
+// DeleteSong deletes a song
+func (m *MemoryStorage) DeleteSong(songID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.songs, songID)
+	return nil
+}
+
+// SetSongPlayed records when and where a song was played in a countdown, but only if it hasn't been played already
+func (m *MemoryStorage) SetSongPlayed(play *SongPlay) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.plays[play.CountdownID][play.SongID]; ok {
+		return ErrConditionalCheckFailed
+	}
+	if _, ok := m.plays[play.CountdownID]; !ok {
+		m.plays[play.CountdownID] = map[string]SongPlay{}
+	}
+	m.plays[play.CountdownID][play.SongID] = *play
+	return nil
+}
+
+// PutSongPlay saves a song's play in a countdown, replacing it if it exists
+func (m *MemoryStorage) PutSongPlay(play *SongPlay) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.plays[play.CountdownID]; !ok {
+		m.plays[play.CountdownID] = map[string]SongPlay{}
+	}
+	m.plays[play.CountdownID][play.SongID] = *play
+	return nil
+}
+
+// GetSongPlay returns when and where a song was played in a countdown
+func (m *MemoryStorage) GetSongPlay(countdownID string, songID string) (*SongPlay, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.plays[countdownID][songID]
+	if !ok {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// GetSongPlays returns every song that was played in a countdown
+func (m *MemoryStorage) GetSongPlays(countdownID string) ([]SongPlay, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var plays []SongPlay
+	for _, p := range m.plays[countdownID] {
+		plays = append(plays, p)
+	}
+	sort.Slice(plays, func(i, j int) bool {
+		return plays[i].SongID < plays[j].SongID
+	})
+	return plays, nil
+}
+
+// DeleteSongPlay removes a song's play from a countdown
+func (m *MemoryStorage) DeleteSongPlay(countdownID string, songID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.plays[countdownID], songID)
+	return nil
+}
+
+// GetPlayCount returns the current play count of a countdown
+func (m *MemoryStorage) GetPlayCount(countdownID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if count, ok := m.playCounts[countdownID]; ok {
+		return count, nil
+	}
+	return 1, nil
+}
+
+// IncrementPlayCount increments the current play count of a countdown by one
+func (m *MemoryStorage) IncrementPlayCount(countdownID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.playCounts[countdownID]; !ok {
+		m.playCounts[countdownID] = 1
+	}
+	m.playCounts[countdownID]++
+	return nil
+}
+
+// SetPlayCount sets the current play count of a countdown to a specific value
+func (m *MemoryStorage) SetPlayCount(countdownID string, count int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.playCounts[countdownID] = count
+	return nil
+}
+
+// GetPlayedSongIDs returns the IDs of the songs played in a countdown in the order they were played
+func (m *MemoryStorage) GetPlayedSongIDs(countdownID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.playedSongIDs[countdownID]...), nil
+}
+
+// AddPlayedSongID appends a song to the played list of a countdown
+func (m *MemoryStorage) AddPlayedSongID(countdownID string, songID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.playedSongIDs[countdownID] = append(m.playedSongIDs[countdownID], songID)
+	return nil
+}
+
+// ResetPlayedSongIDs empties the played list of a countdown
+func (m *MemoryStorage) ResetPlayedSongIDs(countdownID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.playedSongIDs, countdownID)
+	return nil
+}
+
+// GetLegacyVotes returns the votes from before countdowns
+func (m *MemoryStorage) GetLegacyVotes() ([]songVote, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]songVote(nil), m.legacy.votes...), nil
+}
+
+// GetLegacyPlays returns the plays from before countdowns
+func (m *MemoryStorage) GetLegacyPlays() ([]SongPlay, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SongPlay(nil), m.legacy.plays...), nil
+}
+
+// GetLegacyPlayCount returns the play count from before countdowns
+func (m *MemoryStorage) GetLegacyPlayCount() (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.legacy.playCount, nil
+}
+
+// GetLegacyPlayedSongIDs returns the played list from before countdowns
+func (m *MemoryStorage) GetLegacyPlayedSongIDs() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string(nil), m.legacy.playedSongIDs...), nil
+}
+
+// GetSongAlias looks up an alias by its provider and ID
+func (m *MemoryStorage) GetSongAlias(provider string, aliasID string) (*SongAlias, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.aliases[AliasSortKey(provider, aliasID)]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// GetSongAliases returns every alias of a song
+func (m *MemoryStorage) GetSongAliases(songID string) ([]SongAlias, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var aliases []SongAlias
+	for _, a := range m.aliases {
+		if a.SongID == songID {
+			aliases = append(aliases, a)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].SKVal() < aliases[j].SKVal()
+	})
+	return aliases, nil
+}
+
+// PutSongAlias puts the alias
+func (m *MemoryStorage) PutSongAlias(a *SongAlias) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.aliases[a.SKVal()] = *a
+	return nil
+}
+
+// DeleteSongAlias deletes the alias
+func (m *MemoryStorage) DeleteSongAlias(a *SongAlias) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.aliases[a.SKVal()]; ok && stored.SongID == a.SongID {
+		delete(m.aliases, a.SKVal())
+	}
+	return nil
+}
+
+// MergeSong moves the duplicate song's aliases to the canonical song and marks it as merged
+func (m *MemoryStorage) MergeSong(duplicateID string, canonicalID string, removed []SongAlias, added []SongAlias) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.songs[duplicateID]
+	if !ok || stored.MergedInto != nil {
+		return ErrConditionalCheckFailed
+	}
+	stored.MergedInto = &canonicalID
+	m.songs[duplicateID] = stored
+	for _, a := range removed {
+		if existing, ok := m.aliases[a.SKVal()]; ok && existing.SongID == a.SongID {
+			delete(m.aliases, a.SKVal())
+		}
+	}
+	for _, a := range added {
+		m.aliases[a.SKVal()] = a
+	}
+	return nil
+}
+
+// GetPlayReview gets a play in a countdown that's waiting for review
+func (m *MemoryStorage) GetPlayReview(countdownID string, reviewID string) (*PlayReview, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.reviews[countdownID][reviewID]
+	if !ok {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// GetPlayReviews returns every play in a countdown that's waiting for review
+func (m *MemoryStorage) GetPlayReviews(countdownID string) ([]PlayReview, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var reviews []PlayReview
+	for _, r := range m.reviews[countdownID] {
+		reviews = append(reviews, r)
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].SKVal() < reviews[j].SKVal()
+	})
+	return reviews, nil
+}
+
+// PutPlayReview puts the review if there isn't one for the play already
+func (m *MemoryStorage) PutPlayReview(r *PlayReview) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.reviews[r.CountdownID][r.ReviewID]; ok {
+		return ErrConditionalCheckFailed
+	}
+	if _, ok := m.reviews[r.CountdownID]; !ok {
+		m.reviews[r.CountdownID] = map[string]PlayReview{}
+	}
+	m.reviews[r.CountdownID][r.ReviewID] = *r
+	return nil
+}
+
+// DeletePlayReview removes a play from the review list of a countdown
+func (m *MemoryStorage) DeletePlayReview(countdownID string, reviewID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.reviews[countdownID], reviewID)
+	return nil
+}
+
+// GetEndpoints returns the device endpoints a user has
+func (m *MemoryStorage) GetEndpoints(userID string) ([]PlatformEndpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sks []string
+	for sk := range m.endpoints[userID] {
+		sks = append(sks, sk)
+	}
+	sort.Strings(sks)
+
+	var endpoints []PlatformEndpoint
+	for _, sk := range sks {
+		endpoints = append(endpoints, m.endpoints[userID][sk])
+	}
+	return endpoints, nil
+}
+
+// PutEndpoint puts the endpoint
+func (m *MemoryStorage) PutEndpoint(p *PlatformEndpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.endpoints[p.UserID]; !ok {
+		m.endpoints[p.UserID] = map[string]PlatformEndpoint{}
+	}
+	m.endpoints[p.UserID][p.SKVal()] = *p
+	return nil
+}
+
+// DeleteEndpoint deletes the endpoint
+func (m *MemoryStorage) DeleteEndpoint(p *PlatformEndpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.endpoints[p.UserID], p.SKVal())
+	return nil
+}
+
+// GetNotificationPreferences returns the user's notification preferences
+func (m *MemoryStorage) GetNotificationPreferences(userID string) (*NotificationPreferences, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.preferences[userID]
+	if !ok {
+		return nil, nil
+	}
+	p.Kinds = copyKinds(p.Kinds)
+	p.MutedGroups = append([]string{}, p.MutedGroups...)
+	return &p, nil
+}
+
+// PutNotificationPreferences puts the user's notification preferences
+func (m *MemoryStorage) PutNotificationPreferences(p *NotificationPreferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *p
+	stored.Kinds = copyKinds(p.Kinds)
+	stored.MutedGroups = append([]string{}, p.MutedGroups...)
+	m.preferences[p.UserID] = stored
+	return nil
+}
+
+// copyKinds copies the kinds so the stored preferences can't be changed through them
+func copyKinds(kinds map[string]bool) map[string]bool {
+	copied := make(map[string]bool, len(kinds))
+	for kind, enabled := range kinds {
+		copied[kind] = enabled
+	}
+	return copied
+}
+
+// AddInboxItem adds the item to the user's inbox
+func (m *MemoryStorage) AddInboxItem(item *InboxItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.inbox[item.UserID][item.NotificationID]; ok {
+		return ErrConditionalCheckFailed
+	}
+	if _, ok := m.inbox[item.UserID]; !ok {
+		m.inbox[item.UserID] = map[string]InboxItem{}
+	}
+	m.inbox[item.UserID][item.NotificationID] = *item
+	return nil
+}
+
+// GetInboxItem returns an item in the user's inbox
+func (m *MemoryStorage) GetInboxItem(userID string, notificationID string) (*InboxItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.inbox[userID][notificationID]
+	if !ok {
+		return nil, nil
+	}
+	return &item, nil
+}
+
+// GetInbox returns a page of the user's inbox, the cursor is the ID of the last item on the page before
+func (m *MemoryStorage) GetInbox(userID string, cursor string, limit int) (*inboxPage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	after, err := decodeInboxCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(m.inbox[userID]))
+	for id := range m.inbox[userID] {
+		if after == "" || id < after {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+
+	page := &inboxPage{}
+	for i := 0; i < len(ids) && i < limit; i++ {
+		page.Items = append(page.Items, m.inbox[userID][ids[i]])
+	}
+	if len(ids) > limit {
+		page.Next = encodeInboxCursor(ids[limit-1])
+	}
+	return page, nil
+}
+
+// GetUnreadInboxIDs returns the IDs of the unread items in the user's inbox
+func (m *MemoryStorage) GetUnreadInboxIDs(userID string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []string
+	for id, item := range m.inbox[userID] {
+		if !item.Read {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// MarkInboxItemRead marks the item in the user's inbox as read
+func (m *MemoryStorage) MarkInboxItemRead(userID string, notificationID string, readAt string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.inbox[userID][notificationID]
+	if !ok || item.Read {
+		return ErrConditionalCheckFailed
+	}
+	item.Read, item.ReadAt = true, &readAt
+	m.inbox[userID][notificationID] = item
+	return nil
+}
+
+// CountUnread returns how many items in the user's inbox are unread
+func (m *MemoryStorage) CountUnread(userID string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unread := 0
+	for _, item := range m.inbox[userID] {
+		if !item.Read {
+			unread++
+		}
+	}
+	return unread, nil
+}
@@ -1,43 +1,310 @@
 package types
 
 import (
-	"encoding/json"
+	"fmt"
+	"jjj.rflett.com/jjj-api/push"
+	"strconv"
+	"strings"
 )
 
+// the kinds of notification, the app uses them to decide how to show one
+const (
+	NotificationSongPlayed        = "song_played"
+	NotificationGroupPick         = "group_pick" // NotificationGroupPick is another member of their group's song being played
+	NotificationLeaderboardChange = "leaderboard_change"
+	NotificationGroupInvite       = "group_invite"
+	NotificationGameTriggered     = "game_triggered"
+)
+
+// notificationStyle is how a kind of notification is shown on each platform
+type notificationStyle struct {
+	channel  string // channel is the Android notification channel, the app creates one for each kind
+	category string // category is the APNs category, which picks the actions shown with it
+	sound    string
+}
+
+var notificationStyles = map[string]notificationStyle{
+	NotificationSongPlayed:        {channel: "songs", category: "SONG_PLAYED", sound: "default"},
+	NotificationGroupPick:         {channel: "picks", category: "GROUP_PICK", sound: "default"},
+	NotificationLeaderboardChange: {channel: "leaderboard", category: "LEADERBOARD_CHANGE"},
+	NotificationGroupInvite:       {channel: "groups", category: "GROUP_INVITE", sound: "default"},
+	NotificationGameTriggered:     {channel: "games", category: "GAME_TRIGGERED", sound: "default"},
+}
+
+// Notification is something a user is told about on their devices
 type Notification struct {
-	Title      string `json:"title"`
-	Message    string `json:"message"`
-	CustomData string `json:"customData"` // TODO this would be our notification data
+	Kind        string            `json:"kind"`
+	Title       string            `json:"title"`
+	Message     string            `json:"message"`
+	Route       string            `json:"route,omitempty"`       // Route is where the app goes when it's opened, like /songs/{songID}
+	ImageURL    string            `json:"imageURL,omitempty"`    // ImageURL is shown with it, like the song's artwork
+	CollapseKey string            `json:"collapseKey,omitempty"` // CollapseKey replaces an older notification with the same key
+	Thread      string            `json:"thread,omitempty"`      // Thread groups notifications together on iOS
+	Badge       *int              `json:"badge,omitempty"`
+	Data        map[string]string `json:"data,omitempty"`
 }
 
-func (n *Notification) AndroidPayload() string {
-	// Slightly less horrendous?
-	var messagePayload = map[string]map[string]map[string]interface{}{
-		"GCM": {
-			"data": {
-				"message":    n.Message,
-				"customData": n.CustomData,
-			},
+// pointsText is the points with the right plural
+func pointsText(points int) string {
+	if points == 1 {
+		return "1 point"
+	}
+	return fmt.Sprintf("%d points", points)
+}
+
+// namesText joins the names like "Ryan, Alice and Bob"
+func namesText(names []string) string {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return fmt.Sprintf("%s and %s", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+}
+
+// SongRoute is where the app shows a song
+func SongRoute(songID string) string {
+	return fmt.Sprintf("/songs/%s", songID)
+}
+
+// GroupLeaderboardRoute is where the app shows a group's leaderboard
+func GroupLeaderboardRoute(groupID string) string {
+	return fmt.Sprintf("/groups/%s/leaderboard", groupID)
+}
+
+// GroupJoinRoute is where the app joins a group with its code
+func GroupJoinRoute(code string) string {
+	return fmt.Sprintf("/groups/join/%s", code)
+}
+
+// GameRoute is where the app shows a group's game
+func GameRoute(groupID string, gameID string) string {
+	return fmt.Sprintf("/groups/%s/games/%s", groupID, gameID)
+}
+
+// artworkURL returns the url of the song's largest artwork, or an empty string when it doesn't have any
+func (s *Song) artworkURL() string {
+	if s.Artwork == nil {
+		return ""
+	}
+	url, width := "", 0
+	for _, size := range *s.Artwork {
+		if url == "" || size.Width > width {
+			url, width = size.Url, size.Width
+		}
+	}
+	return url
+}
+
+// SongPlayedNotification tells a voter their song was played and what it scored them
+func SongPlayedNotification(a *Award, s *Song) Notification {
+	return Notification{
+		Kind:     NotificationSongPlayed,
+		Title:    "Your song just played!",
+		Message:  fmt.Sprintf("%s by %s was your #%d and played at #%d, that's %s", s.Name, s.Artist, a.Rank, a.Position, pointsText(a.Points)),
+		Route:    SongRoute(s.SongID),
+		ImageURL: s.artworkURL(),
+		Thread:   a.CountdownID,
+		Data: map[string]string{
+			"countdownID": a.CountdownID,
+			"songID":      s.SongID,
+			"position":    strconv.Itoa(a.Position),
+			"points":      strconv.Itoa(a.Points),
 		},
 	}
-	marshalledMessage, _ := json.Marshal(messagePayload)
-	return string(marshalledMessage)
 }
 
-func (n *Notification) IosPayload() string {
-	// Slightly less horrendous? (Still pretty bad)
-	messagePayload := map[string]map[string]interface{}{
-		"APNS": {
-			"aps": map[string]map[string]interface{}{
-				"alert": {
-					"title": n.Title,
-					"body":  n.Message,
-				},
+// GroupPickNotification tells a member whose picks in their groups were just played
+func GroupPickNotification(names []string, s *Song, countdownID string) Notification {
+	title := fmt.Sprintf("%s's pick just played", namesText(names))
+	if len(names) > 1 {
+		title = fmt.Sprintf("%s's picks just played", namesText(names))
+	}
+	position := 0
+	if s.PlayedPosition != nil {
+		position = *s.PlayedPosition
+	}
+	return Notification{
+		Kind:     NotificationGroupPick,
+		Title:    title,
+		Message:  fmt.Sprintf("%s by %s just played at #%d", s.Name, s.Artist, position),
+		Route:    SongRoute(s.SongID),
+		ImageURL: s.artworkURL(),
+		Thread:   countdownID,
+		Data: map[string]string{
+			"countdownID": countdownID,
+			"songID":      s.SongID,
+			"position":    strconv.Itoa(position),
+		},
+	}
+}
+
+// LeaderboardChangeNotification tells a member where they are in their group after moving, only the latest one is kept
+func LeaderboardChangeNotification(g *Group, rank int, movement int) Notification {
+	direction := "up"
+	if movement < 0 {
+		direction, movement = "down", -movement
+	}
+	places := "places"
+	if movement == 1 {
+		places = "place"
+	}
+	return Notification{
+		Kind:        NotificationLeaderboardChange,
+		Title:       g.Name,
+		Message:     fmt.Sprintf("You've gone %s %d %s to #%d", direction, movement, places, rank),
+		Route:       GroupLeaderboardRoute(g.GroupID),
+		CollapseKey: fmt.Sprintf("leaderboard-%s", g.GroupID),
+		Thread:      g.GroupID,
+		Data: map[string]string{
+			"groupID": g.GroupID,
+			"rank":    strconv.Itoa(rank),
+		},
+	}
+}
+
+// GroupInviteNotification invites a user to join a group with its code
+func GroupInviteNotification(g *Group, inviter string, code string) Notification {
+	return Notification{
+		Kind:        NotificationGroupInvite,
+		Title:       "You've been invited to a group",
+		Message:     fmt.Sprintf("%s wants you to join %s", inviter, g.Name),
+		Route:       GroupJoinRoute(code),
+		CollapseKey: fmt.Sprintf("invite-%s", g.GroupID),
+		Thread:      "invites",
+		Data: map[string]string{
+			"groupID": g.GroupID,
+			"code":    code,
+		},
+	}
+}
+
+// GameTriggeredNotification tells a group's members it's time to play one of its games because of a song being played
+func GameTriggeredNotification(game *Game, s *Song, pickedBy string) Notification {
+	return Notification{
+		Kind:     NotificationGameTriggered,
+		Title:    fmt.Sprintf("Time for %s!", game.Name),
+		Message:  fmt.Sprintf("%s by %s was %s's pick", s.Name, s.Artist, pickedBy),
+		Route:    GameRoute(game.GroupID, game.GameID),
+		ImageURL: s.artworkURL(),
+		Thread:   game.GroupID,
+		Data: map[string]string{
+			"groupID": game.GroupID,
+			"gameID":  game.GameID,
+			"songID":  s.SongID,
+		},
+	}
+}
+
+// APNsAlert is the text of an APNs notification
+type APNsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// APS is the part of an APNs payload that iOS reads
+type APS struct {
+	Alert          APNsAlert `json:"alert"`
+	Badge          *int      `json:"badge,omitempty"`
+	Sound          string    `json:"sound,omitempty"`
+	Category       string    `json:"category,omitempty"`
+	ThreadID       string    `json:"thread-id,omitempty"`
+	MutableContent int       `json:"mutable-content,omitempty"` // MutableContent lets the app's extension download the image
+}
+
+// APNsPayload is the body of an APNs notification, everything beside aps is for the app
+type APNsPayload struct {
+	APS      APS               `json:"aps"`
+	Kind     string            `json:"kind"`
+	Route    string            `json:"route,omitempty"`
+	ImageURL string            `json:"imageURL,omitempty"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// FCMNotification is what Android shows for an FCM message
+type FCMNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Image string `json:"image,omitempty"`
+}
+
+// FCMAndroidNotification is how Android shows an FCM message
+type FCMAndroidNotification struct {
+	ChannelID         string `json:"channel_id"`
+	Sound             string `json:"sound,omitempty"`
+	Tag               string `json:"tag,omitempty"` // Tag replaces a notification with the same tag in the tray
+	NotificationCount *int   `json:"notification_count,omitempty"`
+}
+
+// FCMAndroid is the Android part of an FCM message
+type FCMAndroid struct {
+	CollapseKey  string                 `json:"collapse_key,omitempty"`
+	Notification FCMAndroidNotification `json:"notification"`
+}
+
+// FCMMessage is an FCM HTTP v1 message, Token is filled in when it's sent straight to a device
+type FCMMessage struct {
+	Token        string            `json:"token,omitempty"`
+	Notification FCMNotification   `json:"notification"`
+	Android      FCMAndroid        `json:"android"`
+	Data         map[string]string `json:"data"`
+}
+
+// APNs returns the notification's APNs payload
+func (n *Notification) APNs() APNsPayload {
+	style := notificationStyles[n.Kind]
+	p := APNsPayload{
+		APS: APS{
+			Alert:    APNsAlert{Title: n.Title, Body: n.Message},
+			Badge:    n.Badge,
+			Sound:    style.sound,
+			Category: style.category,
+			ThreadID: n.Thread,
+		},
+		Kind:     n.Kind,
+		Route:    n.Route,
+		ImageURL: n.ImageURL,
+		Data:     n.Data,
+	}
+	if n.ImageURL != "" {
+		p.APS.MutableContent = 1
+	}
+	return p
+}
+
+// FCM returns the notification's FCM message. FCM data can only be strings, so the kind and route are added to it.
+func (n *Notification) FCM() FCMMessage {
+	style := notificationStyles[n.Kind]
+	data := map[string]string{"kind": n.Kind}
+	if n.Route != "" {
+		data["route"] = n.Route
+	}
+	for k, v := range n.Data {
+		data[k] = v
+	}
+	return FCMMessage{
+		Notification: FCMNotification{Title: n.Title, Body: n.Message, Image: n.ImageURL},
+		Android: FCMAndroid{
+			CollapseKey: n.CollapseKey,
+			Notification: FCMAndroidNotification{
+				ChannelID:         style.channel,
+				Sound:             style.sound,
+				Tag:               n.CollapseKey,
+				NotificationCount: n.Badge,
 			},
-			"data": n.CustomData,
 		},
+		Data: data,
 	}
+}
 
-	marshalledMessage, _ := json.Marshal(messagePayload)
-	return string(marshalledMessage)
+// AndroidPayload returns the notification as an SNS message for an Android endpoint, it uses FCM's v1 message
+func (n *Notification) AndroidPayload() string {
+	message, _ := n.pushMessage()
+	payload, _ := push.SNSMessage(push.PlatformGoogle, message)
+	return payload
+}
+
+// IosPayload returns the notification as an SNS message for an iOS endpoint
+func (n *Notification) IosPayload() string {
+	message, _ := n.pushMessage()
+	payload, _ := push.SNSMessage(push.PlatformApple, message)
+	return payload
 }
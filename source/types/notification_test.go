@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/json"
+	"flag"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"path/filepath"
+	"testing"
+)
+
+// run the tests with -update to rewrite the golden files after changing a payload on purpose
+var update = flag.Bool("update", false, "rewrite the golden files")
+
+// assertGolden compares the value as indented JSON with the golden file in testdata/notifications
+func assertGolden(t *testing.T, name string, value interface{}) {
+	got, err := json.MarshalIndent(value, "", "  ")
+	assert.Nil(t, err)
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "notifications", name+".json")
+	if *update {
+		assert.Nil(t, ioutil.WriteFile(path, got, 0644))
+	}
+	want, err := ioutil.ReadFile(path)
+	if assert.Nil(t, err, "run go test ./types -update to create %s", path) {
+		assert.Equal(t, string(want), string(got), name)
+	}
+}
+
+func TestNotificationPayloads(t *testing.T) {
+	position := 4
+	song := Song{
+		SongID:         "6Qn5zhYkTa37e91HC1D7lb",
+		Name:           "Elephant",
+		Artist:         "Tame Impala",
+		PlayedPosition: &position,
+		Artwork: &[]jjj.ArtworkSize{
+			{Url: "https://example.com/elephant-100.jpg", Width: 100, Height: 100},
+			{Url: "https://example.com/elephant-640.jpg", Width: 640, Height: 640},
+		},
+	}
+	award := Award{CountdownID: TestCountdownID, UserID: TestAuthProviderUserID, SongID: song.SongID, Position: 4, Rank: 2, Points: 97}
+	group := Group{GroupID: TestAuthProviderGroupID, Name: "Test Group"}
+	game := Game{GameID: "b5c7d0a2-6d1e-4c3a-9f0e-3a1f2b4c5d6e", GroupID: TestAuthProviderGroupID, Name: "Test Game"}
+	badge := 3
+
+	leaderboard := LeaderboardChangeNotification(&group, 2, 1)
+	leaderboard.Badge = &badge
+	notifications := map[string]Notification{
+		"song_played":        SongPlayedNotification(&award, &song),
+		"group_pick":         GroupPickNotification([]string{"Alice", "Bob", "Ryan"}, &song, TestCountdownID),
+		"leaderboard_change": leaderboard,
+		"group_invite":       GroupInviteNotification(&group, "Ryan", "TESTER"),
+		"game_triggered":     GameTriggeredNotification(&game, &song, "Ryan"),
+	}
+	for name, n := range notifications {
+		assertGolden(t, name+".apns", n.APNs())
+		assertGolden(t, name+".fcm", n.FCM())
+	}
+}
+
+func TestNotificationSNSMessages(t *testing.T) {
+	n := GroupInviteNotification(&Group{GroupID: TestAuthProviderGroupID, Name: "Test Group"}, "Ryan", "TESTER")
+
+	// SNS wants each platform's payload as a JSON string
+	var ios map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(n.IosPayload()), &ios))
+	payload := APNsPayload{}
+	assert.Nil(t, json.Unmarshal([]byte(ios["APNS"]), &payload))
+	assert.Equal(t, n.APNs(), payload)
+
+	var android map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(n.AndroidPayload()), &android))
+	message := struct {
+		FCMV1Message struct {
+			Message FCMMessage `json:"message"`
+		} `json:"fcmV1Message"`
+	}{}
+	assert.Nil(t, json.Unmarshal([]byte(android["GCM"]), &message))
+	assert.Equal(t, n.FCM(), message.FCMV1Message.Message)
+}
+
+func TestNotificationText(t *testing.T) {
+	assert.Equal(t, "Ryan's pick just played", GroupPickNotification([]string{"Ryan"}, &Song{}, "").Title)
+	assert.Equal(t, "Alice and Ryan's picks just played", GroupPickNotification([]string{"Alice", "Ryan"}, &Song{}, "").Title)
+	assert.Equal(t, "You've gone down 1 place to #3", LeaderboardChangeNotification(&Group{}, 3, -1).Message)
+	assert.Equal(t, "You've gone up 2 places to #1", LeaderboardChangeNotification(&Group{}, 1, 2).Message)
+}
@@ -8,7 +8,7 @@ import (
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/instagram"
 	"golang.org/x/oauth2/spotify"
-	"os"
+	"jjj.rflett.com/jjj-api/config"
 	"strconv"
 )
 
@@ -35,9 +35,9 @@ var OauthProviders = map[string]*OauthProvider{
 
 var GoogleOauth = OauthProvider{
 	Config: oauth2.Config{
-		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-		ClientSecret: os.Getenv("GOOGLE_SECRET_ID"),
-		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", os.Getenv("OAUTH_CALLBACK_HOST"), AuthProviderGoogle),
+		ClientID:     config.Values.GoogleClientID,
+		ClientSecret: config.Values.GoogleSecretID,
+		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", config.Values.OauthCallbackHost, AuthProviderGoogle),
 		Scopes: []string{
 			"https://www.googleapis.com/auth/userinfo.email",
 			"https://www.googleapis.com/auth/userinfo.profile",
@@ -54,9 +54,9 @@ var GoogleOauth = OauthProvider{
 
 var FacebookOauth = OauthProvider{
 	Config: oauth2.Config{
-		ClientID:     os.Getenv("FACEBOOK_CLIENT_ID"),
-		ClientSecret: os.Getenv("FACEBOOK_SECRET_ID"),
-		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", os.Getenv("OAUTH_CALLBACK_HOST"), AuthProviderFacebook),
+		ClientID:     config.Values.FacebookClientID,
+		ClientSecret: config.Values.FacebookSecretID,
+		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", config.Values.OauthCallbackHost, AuthProviderFacebook),
 		Scopes: []string{
 			"public_profile",
 			"email",
@@ -74,9 +74,9 @@ var FacebookOauth = OauthProvider{
 
 var InstagramOauth = OauthProvider{
 	Config: oauth2.Config{
-		ClientID:     os.Getenv("FACEBOOK_CLIENT_ID"),
-		ClientSecret: os.Getenv("FACEBOOK_SECRET_ID"),
-		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", os.Getenv("OAUTH_CALLBACK_HOST"), AuthProviderInstagram),
+		ClientID:     config.Values.FacebookClientID,
+		ClientSecret: config.Values.FacebookSecretID,
+		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", config.Values.OauthCallbackHost, AuthProviderInstagram),
 		Scopes: []string{
 			"public_profile",
 			"email",
@@ -94,9 +94,9 @@ var InstagramOauth = OauthProvider{
 
 var SpotifyOauth = OauthProvider{
 	Config: oauth2.Config{
-		ClientID:     os.Getenv("SPOTIFY_CLIENT_ID"),
-		ClientSecret: os.Getenv("SPOTIFY_SECRET_ID"),
-		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", os.Getenv("OAUTH_CALLBACK_HOST"), AuthProviderSpotify),
+		ClientID:     config.Values.SpotifyClientID,
+		ClientSecret: config.Values.SpotifySecretID,
+		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", config.Values.OauthCallbackHost, AuthProviderSpotify),
 		Scopes: []string{
 			"user-read-private",
 			"user-read-email",
@@ -113,9 +113,9 @@ var SpotifyOauth = OauthProvider{
 
 var GithubOauth = OauthProvider{
 	Config: oauth2.Config{
-		ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
-		ClientSecret: os.Getenv("GITHUB_SECRET_ID"),
-		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", os.Getenv("OAUTH_CALLBACK_HOST"), AuthProviderGitHub),
+		ClientID:     config.Values.GitHubClientID,
+		ClientSecret: config.Values.GitHubSecretID,
+		RedirectURL:  fmt.Sprintf("%s/oauth/%s/redirect", config.Values.OauthCallbackHost, AuthProviderGitHub),
 		Scopes:       []string{},
 		Endpoint:     github.Endpoint,
 	},
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"net/http"
+	"time"
+)
+
+// quietHoursLayout is the layout of the start and end of quiet hours
+const quietHoursLayout = "15:04"
+
+// QuietHours is when a user doesn't want to be pushed, in their time zone. Start can be after End to go past midnight.
+type QuietHours struct {
+	Start    string `json:"start"` // Start and End are like 22:00
+	End      string `json:"end"`
+	TimeZone string `json:"timeZone"` // TimeZone is an IANA time zone like Australia/Sydney
+}
+
+// NotificationPreferences are what a user wants to be pushed about
+type NotificationPreferences struct {
+	PK          string          `json:"-" dynamodbav:"PK"`
+	SK          string          `json:"-" dynamodbav:"SK"`
+	UserID      string          `json:"userID"`
+	Enabled     bool            `json:"enabled"` // Enabled is the switch for every notification
+	Kinds       map[string]bool `json:"kinds"`   // Kinds turns each kind of notification on or off, a kind that's missing is on
+	MutedGroups []string        `json:"mutedGroups" dynamodbav:"MutedGroups,omitemptyelem"`
+	QuietHours  *QuietHours     `json:"quietHours"`
+	UpdatedAt   *string         `json:"updatedAt"`
+}
+
+// return the partition key value for notification preferences
+func (p *NotificationPreferences) PKVal() string {
+	return fmt.Sprintf("%s#%s", UserPartitionKey, p.UserID)
+}
+
+// return the sort key value for notification preferences
+func (p *NotificationPreferences) SKVal() string {
+	return NotificationPreferencesSortKey
+}
+
+// DefaultNotificationPreferences are a user's preferences until they change them, everything is on
+func DefaultNotificationPreferences(userID string) *NotificationPreferences {
+	return &NotificationPreferences{UserID: userID, Enabled: true, Kinds: map[string]bool{}, MutedGroups: []string{}}
+}
+
+// NotificationPreferences returns the user's notification preferences, with every kind listed
+func (u *User) NotificationPreferences() (*NotificationPreferences, error) {
+	p, err := Store.GetNotificationPreferences(u.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to get the user's notification preferences")
+		return nil, err
+	}
+	if p == nil {
+		p = DefaultNotificationPreferences(u.UserID)
+	}
+	if p.Kinds == nil {
+		p.Kinds = map[string]bool{}
+	}
+	if p.MutedGroups == nil {
+		p.MutedGroups = []string{}
+	}
+	for kind := range notificationStyles {
+		if _, ok := p.Kinds[kind]; !ok {
+			p.Kinds[kind] = true
+		}
+	}
+	return p, nil
+}
+
+// minutes parses the start or end of quiet hours into minutes past midnight
+func (q *QuietHours) minutes(value string) (int, error) {
+	t, err := time.Parse(quietHoursLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("%s isn't a time like 22:00", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validate checks the times and time zone
+func (q *QuietHours) validate() error {
+	start, err := q.minutes(q.Start)
+	if err != nil {
+		return err
+	}
+	end, err := q.minutes(q.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("quiet hours can't start and end at the same time")
+	}
+	if q.TimeZone == "" {
+		return errors.New("quiet hours need a time zone")
+	}
+	if _, err = time.LoadLocation(q.TimeZone); err != nil {
+		return fmt.Errorf("%s isn't a time zone", q.TimeZone)
+	}
+	return nil
+}
+
+// contains returns whether the time is during quiet hours in their time zone
+func (q *QuietHours) contains(at time.Time) bool {
+	location, err := time.LoadLocation(q.TimeZone)
+	if err != nil {
+		return false
+	}
+	start, _ := q.minutes(q.Start)
+	end, _ := q.minutes(q.End)
+	local := at.In(location)
+	now := local.Hour()*60 + local.Minute()
+	if start < end {
+		return now >= start && now < end
+	}
+	return now >= start || now < end
+}
+
+// GroupMuted returns whether the user has muted the group
+func (p *NotificationPreferences) GroupMuted(groupID string) bool {
+	for _, muted := range p.MutedGroups {
+		if muted == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// KindEnabled returns whether the user wants the kind of notification
+func (p *NotificationPreferences) KindEnabled(kind string) bool {
+	enabled, ok := p.Kinds[kind]
+	return p.Enabled && (enabled || !ok)
+}
+
+// Allows returns whether the notification should be pushed to the user at the time. Notifications about a group have
+// its groupID in their data.
+func (p *NotificationPreferences) Allows(n *Notification, at time.Time) bool {
+	if !p.KindEnabled(n.Kind) {
+		return false
+	}
+	if groupID, ok := n.Data["groupID"]; ok && p.GroupMuted(groupID) {
+		return false
+	}
+	return p.QuietHours == nil || !p.QuietHours.contains(at)
+}
+
+// Save validates the preferences and replaces the user's stored ones
+func (p *NotificationPreferences) Save() (status int, error error) {
+	for kind := range p.Kinds {
+		if _, ok := notificationStyles[kind]; !ok {
+			return http.StatusBadRequest, fmt.Errorf("%s isn't a kind of notification", kind)
+		}
+	}
+	if p.QuietHours != nil {
+		if err := p.QuietHours.validate(); err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	if p.Kinds == nil {
+		p.Kinds = map[string]bool{}
+	}
+	if p.MutedGroups == nil {
+		p.MutedGroups = []string{}
+	}
+
+	updatedAt := time.Now().UTC().Format(time.RFC3339)
+	p.UpdatedAt = &updatedAt
+	p.PK = p.PKVal()
+	p.SK = p.SKVal()
+	if err := Store.PutNotificationPreferences(p); err != nil {
+		logger.Log.Error().Err(err).Str("userID", p.UserID).Msg("Unable to save the user's notification preferences")
+		return http.StatusInternalServerError, err
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNotificationPreferencesAllows(t *testing.T) {
+	group := Group{GroupID: "group", Name: "The Group"}
+	played := Notification{Kind: NotificationSongPlayed}
+	leaderboard := LeaderboardChangeNotification(&group, 1, 2)
+	at := time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC) // 11pm in Sydney
+
+	p := DefaultNotificationPreferences("user")
+	assert.True(t, p.Allows(&played, at))
+	assert.True(t, p.Allows(&leaderboard, at))
+
+	p.Kinds[NotificationSongPlayed] = false
+	assert.False(t, p.Allows(&played, at))
+	assert.True(t, p.Allows(&leaderboard, at))
+
+	p.MutedGroups = []string{"group"}
+	assert.False(t, p.Allows(&leaderboard, at))
+
+	p = DefaultNotificationPreferences("user")
+	p.Enabled = false
+	assert.False(t, p.Allows(&played, at))
+	assert.False(t, p.Allows(&leaderboard, at))
+}
+
+func TestQuietHours(t *testing.T) {
+	overnight := QuietHours{Start: "22:00", End: "07:00", TimeZone: "Australia/Sydney"}
+	assert.Nil(t, overnight.validate())
+	// Sydney is UTC+11 in January
+	assert.True(t, overnight.contains(time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC)))  // 11pm
+	assert.True(t, overnight.contains(time.Date(2026, 1, 26, 19, 59, 0, 0, time.UTC))) // 6:59am
+	assert.False(t, overnight.contains(time.Date(2026, 1, 26, 20, 0, 0, 0, time.UTC))) // 7am
+	assert.False(t, overnight.contains(time.Date(2026, 1, 26, 1, 0, 0, 0, time.UTC)))  // noon
+
+	lunch := QuietHours{Start: "12:00", End: "13:00", TimeZone: "UTC"}
+	assert.True(t, lunch.contains(time.Date(2026, 1, 26, 12, 30, 0, 0, time.UTC)))
+	assert.False(t, lunch.contains(time.Date(2026, 1, 26, 13, 0, 0, 0, time.UTC)))
+
+	p := DefaultNotificationPreferences("user")
+	p.QuietHours = &overnight
+	played := Notification{Kind: NotificationSongPlayed}
+	assert.False(t, p.Allows(&played, time.Date(2026, 1, 26, 12, 0, 0, 0, time.UTC)))
+	assert.True(t, p.Allows(&played, time.Date(2026, 1, 26, 1, 0, 0, 0, time.UTC)))
+}
+
+func TestGroupAlertsSkipMutedGroups(t *testing.T) {
+	UseTestStorage()
+
+	// Alice is in the test group with the test user, who voted for the test song
+	alice := User{UserID: "alice", Name: "Alice"}
+	assert.Nil(t, Store.PutUser(&alice))
+	assert.Nil(t, Store.PutMembership(TestAuthProviderGroupID, alice.UserID, time.Now().UTC().Format(time.RFC3339)))
+
+	position := 1
+	song := Song{SongID: TestSongID, Name: "Song", Artist: "Artist", PlayedPosition: &position}
+	awards := []Award{{UserID: TestAuthProviderUserID, CountdownID: TestCountdownID, SongID: TestSongID}}
+	alerts, err := GroupAlerts(&song, awards)
+	assert.Nil(t, err)
+	assert.Len(t, alerts, 1)
+
+	muted := DefaultNotificationPreferences(alice.UserID)
+	muted.MutedGroups = []string{TestAuthProviderGroupID}
+	_, err = muted.Save()
+	assert.Nil(t, err)
+	alerts, err = GroupAlerts(&song, awards)
+	assert.Nil(t, err)
+	assert.Empty(t, alerts)
+}
+
+// readCountingStorage counts the reads GroupAlerts makes for each group and user
+type readCountingStorage struct {
+	Storage
+	memberReads     map[string]int
+	preferenceReads map[string]int
+}
+
+func (r *readCountingStorage) GetMemberIDs(groupID string) ([]string, error) {
+	r.memberReads[groupID]++
+	return r.Storage.GetMemberIDs(groupID)
+}
+
+func (r *readCountingStorage) GetNotificationPreferences(userID string) (*NotificationPreferences, error) {
+	r.preferenceReads[userID]++
+	return r.Storage.GetNotificationPreferences(userID)
+}
+
+func TestGroupAlertsReadEachGroupOnce(t *testing.T) {
+	memory := UseTestStorage()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	// three voters share the test group and another group with alice and bob
+	for _, userID := range []string{"sam", "kim", "alice", "bob"} {
+		u := User{UserID: userID, Name: userID}
+		assert.Nil(t, Store.PutUser(&u))
+		assert.Nil(t, Store.PutMembership(TestAuthProviderGroupID, userID, now))
+		assert.Nil(t, Store.PutMembership("other", userID, now))
+	}
+	assert.Nil(t, Store.PutMembership("other", TestAuthProviderUserID, now))
+
+	position := 1
+	song := Song{SongID: TestSongID, Name: "Song", Artist: "Artist", PlayedPosition: &position}
+	var awards []Award
+	for _, userID := range []string{TestAuthProviderUserID, "sam", "kim"} {
+		awards = append(awards, Award{UserID: userID, CountdownID: TestCountdownID, SongID: TestSongID})
+	}
+
+	counting := &readCountingStorage{Storage: memory, memberReads: map[string]int{}, preferenceReads: map[string]int{}}
+	Store = counting
+	defer func() { Store = memory }()
+	alerts, err := GroupAlerts(&song, awards)
+	assert.Nil(t, err)
+	if assert.Len(t, alerts, 2) {
+		assert.Contains(t, alerts[0].Notification.Title, "kim")
+	}
+	assert.Equal(t, map[string]int{TestAuthProviderGroupID: 1, "other": 1}, counting.memberReads)
+	assert.Equal(t, map[string]int{"alice": 1, "bob": 1}, counting.preferenceReads)
+}
+
+func TestGroupAlertsSkipGroupPicksTurnedOff(t *testing.T) {
+	UseTestStorage()
+	alice := User{UserID: "alice", Name: "Alice"}
+	assert.Nil(t, Store.PutUser(&alice))
+	assert.Nil(t, Store.PutMembership(TestAuthProviderGroupID, alice.UserID, time.Now().UTC().Format(time.RFC3339)))
+
+	// alice only wants to hear about her own songs
+	p := DefaultNotificationPreferences(alice.UserID)
+	p.Kinds[NotificationGroupPick] = false
+	_, err := p.Save()
+	assert.Nil(t, err)
+	assert.True(t, p.KindEnabled(NotificationSongPlayed))
+
+	position := 1
+	song := Song{SongID: TestSongID, Name: "Song", Artist: "Artist", PlayedPosition: &position}
+	awards := []Award{{UserID: TestAuthProviderUserID, CountdownID: TestCountdownID, SongID: TestSongID}}
+	alerts, err := GroupAlerts(&song, awards)
+	assert.Nil(t, err)
+	assert.Empty(t, alerts)
+}
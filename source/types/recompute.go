@@ -0,0 +1,194 @@
+package types
+
+import (
+	"jjj.rflett.com/jjj-api/logger"
+	"reflect"
+	"sort"
+)
+
+// ScoreChange is how a user's score in a countdown changes when it's recomputed from the plays and votes. Awards are
+// the ones that are new or have changed, Removed are the ones the user shouldn't have anymore.
+type ScoreChange struct {
+	UserID   string  `json:"userID"`
+	Previous int     `json:"previous"`
+	Points   int     `json:"points"`
+	Delta    int     `json:"delta"`
+	Hits     int     `json:"songsHit"`
+	Awards   []Award `json:"awards,omitempty"`
+	Removed  []Award `json:"removed,omitempty"`
+}
+
+// sameAward is whether the recomputed award gives the same points for the same reasons as the recorded one
+func sameAward(recomputed Award, recorded Award) bool {
+	return recomputed.Points == recorded.Points &&
+		recomputed.Position == recorded.Position &&
+		recomputed.Rank == recorded.Rank &&
+		reflect.DeepEqual(recomputed.Rules, recorded.Rules)
+}
+
+// playAwards scores each of the plays with the current votes and scoring rules, and returns the awards by user then
+// song. A merged song's voters are scored by the song it was merged into.
+func (c *Countdown) playAwards(plays []SongPlay) (map[string]map[string]Award, error) {
+	songIDs := make([]string, 0, len(plays))
+	for _, p := range plays {
+		songIDs = append(songIDs, p.SongID)
+	}
+	merged := map[string]bool{}
+	if len(songIDs) > 0 {
+		songs, err := Store.GetSongs(songIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range songs {
+			merged[s.SongID] = s.MergedInto != nil
+		}
+	}
+
+	awarded := map[string]map[string]Award{}
+	for i := range plays {
+		if merged[plays[i].SongID] {
+			continue
+		}
+		song := Song{SongID: plays[i].SongID}
+		song.setPlay(&plays[i])
+		awards, err := c.Awards(&song)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range awards {
+			a.Correction = plays[i].Corrections
+			if _, ok := awarded[a.UserID]; !ok {
+				awarded[a.UserID] = map[string]Award{}
+			}
+			awarded[a.UserID][a.SongID] = a
+		}
+	}
+	return awarded, nil
+}
+
+// RecomputeScores scores every play in the countdown again with the current votes and scoring rules, and returns the
+// users whose points or awards would change, ordered by their userID. Nothing is saved.
+func (c *Countdown) RecomputeScores() ([]ScoreChange, error) {
+	plays, err := Store.GetSongPlays(c.CountdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Unable to get the countdown's plays")
+		return nil, err
+	}
+	songIDs := make([]string, 0, len(plays))
+	for _, p := range plays {
+		songIDs = append(songIDs, p.SongID)
+	}
+	recomputed, err := c.playAwards(plays)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := Store.GetCountdownPoints(c.CountdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Unable to get the countdown's points")
+		return nil, err
+	}
+	userIDs := make([]string, 0, len(points))
+	for userID := range points {
+		userIDs = append(userIDs, userID)
+	}
+	for userID := range recomputed {
+		if _, ok := points[userID]; !ok {
+			userIDs = append(userIDs, userID)
+		}
+	}
+	sort.Strings(userIDs)
+
+	var changes []ScoreChange
+	for _, userID := range userIDs {
+		recorded, err := Store.GetAwards(c.CountdownID, userID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("userID", userID).Msg("Unable to get the user's awards")
+			return nil, err
+		}
+		change := ScoreChange{UserID: userID, Previous: points[userID].Points, Hits: len(recomputed[userID])}
+		for _, a := range recorded {
+			if _, ok := recomputed[userID][a.SongID]; !ok {
+				change.Removed = append(change.Removed, a)
+			}
+		}
+
+		previous := map[string]Award{}
+		for _, a := range recorded {
+			previous[a.SongID] = a
+		}
+		for _, songID := range songIDs {
+			a, ok := recomputed[userID][songID]
+			if !ok {
+				continue
+			}
+			change.Points += a.Points
+			if p, ok := previous[songID]; ok {
+				if sameAward(a, p) {
+					continue
+				}
+				a.Revision = p.Revision + 1
+			}
+			a.PK, a.SK = a.PKVal(), a.SKVal()
+			change.Awards = append(change.Awards, a)
+		}
+
+		change.Delta = change.Points - change.Previous
+		// points scored before the leaderboard need putting on it
+		up := points[userID]
+		onLeaderboard := up.LeaderboardPK == leaderboardPK(c.CountdownID, userID)
+		if change.Delta != 0 || change.Hits != up.Hits || !onLeaderboard || len(change.Awards) > 0 || len(change.Removed) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// items is how many items saving the change writes, its points and each of its awards
+func (s *ScoreChange) items() int {
+	return 1 + len(s.Awards) + len(s.Removed)
+}
+
+// ScoreChangeBatches splits the changes into batches of up to batchSize users that each fit in one transaction. A
+// change that doesn't fit in a transaction by itself can't be applied, those are returned separately.
+func ScoreChangeBatches(changes []ScoreChange, batchSize int) (batches [][]ScoreChange, tooBig []ScoreChange) {
+	var batch []ScoreChange
+	items := 0
+	for _, change := range changes {
+		if change.items() > transactionLimit {
+			tooBig = append(tooBig, change)
+			continue
+		}
+		if len(batch) > 0 && (len(batch) >= batchSize || items+change.items() > transactionLimit) {
+			batches = append(batches, batch)
+			batch, items = nil, 0
+		}
+		batch = append(batch, change)
+		items += change.items()
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches, tooBig
+}
+
+// ApplyScoreChanges saves the users' recomputed points and awards together in one transaction. It fails with
+// ErrConditionalCheckFailed if any of them have been scored since they were recomputed, and then none of them are saved.
+func (c *Countdown) ApplyScoreChanges(changes []ScoreChange) error {
+	err := Store.ResetUserScores(c.CountdownID, changes)
+	if err == ErrConditionalCheckFailed {
+		return err
+	}
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Int("users", len(changes)).Msg("Unable to apply the recomputed scores")
+		return err
+	}
+	logger.Log.Info().Str("countdownID", c.CountdownID).Int("users", len(changes)).Msg("Applied the recomputed scores")
+	return nil
+}
+
+// ApplyScoreChange saves the user's recomputed points and awards together. It fails with ErrConditionalCheckFailed if
+// the user has been scored since it was recomputed.
+func (c *Countdown) ApplyScoreChange(change ScoreChange) error {
+	return c.ApplyScoreChanges([]ScoreChange{change})
+}
@@ -0,0 +1,175 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/types/jjj"
+	"net/http"
+	"time"
+)
+
+// ReviewCandidate is a song that a play under review might be, and how confident the match was
+type ReviewCandidate struct {
+	SongID string  `json:"songID"`
+	Name   string  `json:"name"`
+	Artist string  `json:"artist"`
+	Album  string  `json:"album"`
+	Score  float64 `json:"score"`
+}
+
+// PlayReview is a play that couldn't be confidently matched to a song, it keeps the order it was played in until
+// an admin works out which song it was
+type PlayReview struct {
+	PK          string            `json:"-" dynamodbav:"PK"`
+	SK          string            `json:"-" dynamodbav:"SK"`
+	ReviewID    string            `json:"reviewID"` // ReviewID is the arid of the play
+	CountdownID string            `json:"countdownID"`
+	Play        jjj.Play          `json:"play"`
+	PlayOrder   int               `json:"playOrder"`
+	Candidates  []ReviewCandidate `json:"candidates"`
+	CreatedAt   string            `json:"createdAt"`
+}
+
+// return the partition key value for a review
+func (r *PlayReview) PKVal() string {
+	return countdownPK(r.CountdownID)
+}
+
+// return the sort key value for a review
+func (r *PlayReview) SKVal() string {
+	return fmt.Sprintf("%s#%s", PlayReviewSortKey, r.ReviewID)
+}
+
+// NewPlayReview returns a review for the play in the countdown with the candidates it might be
+func NewPlayReview(countdownID string, play *jjj.Play, candidates []ReviewCandidate) *PlayReview {
+	r := &PlayReview{
+		ReviewID:    play.Arid,
+		CountdownID: countdownID,
+		Play:        *play,
+		Candidates:  candidates,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	r.PK = r.PKVal()
+	r.SK = r.SKVal()
+	return r
+}
+
+// InReview returns whether the play is already waiting to be reviewed in the countdown
+func InReview(countdownID string, play *jjj.Play) (bool, error) {
+	r, err := Store.GetPlayReview(countdownID, play.Arid)
+	return r != nil, err
+}
+
+// Create adds the play to the review list and takes the next play order so the songs after it keep their places. A play
+// that's already in the list is left alone.
+func (r *PlayReview) Create() error {
+	playOrder, err := Store.GetPlayCount(r.CountdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to get the latest song position")
+		return err
+	}
+	r.PlayOrder = playOrder
+
+	if err = Store.PutPlayReview(r); err != nil {
+		if err == ErrConditionalCheckFailed {
+			logger.Log.Info().Str("reviewID", r.ReviewID).Msg("The play is already in the review list")
+			return nil
+		}
+		logger.Log.Error().Err(err).Str("reviewID", r.ReviewID).Msg("Unable to add the play to the review list")
+		return err
+	}
+
+	if err = Store.IncrementPlayCount(r.CountdownID); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to increment the latest song position")
+	}
+	logger.Log.Info().Str("reviewID", r.ReviewID).Int("playOrder", r.PlayOrder).Msg("Added the play to the review list")
+	return nil
+}
+
+// Get the review from the table
+func (r *PlayReview) Get() (int, error) {
+	result, err := Store.GetPlayReview(r.CountdownID, r.ReviewID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("reviewID", r.ReviewID).Msg("Error getting review from table")
+		return http.StatusInternalServerError, err
+	}
+	if result == nil {
+		return http.StatusNotFound, errors.New("unable to find the play in the review list")
+	}
+
+	*r = *result
+	return http.StatusOK, nil
+}
+
+// playedAt returns when the play was played, or when it was reviewed if JJJ didn't say
+func (r *PlayReview) playedAt() string {
+	playedTime, err := time.Parse(time.RFC3339, r.Play.PlayedTime)
+	if err != nil {
+		return r.CreatedAt
+	}
+	return playedTime.Format(time.RFC3339)
+}
+
+// Resolve matches the play to the song and marks the song as played at the play's original time and play order. The
+// song is created if it doesn't exist, with a new ID if it doesn't have one. The caller needs to queue the song for the
+// bean-counter.
+func (r *PlayReview) Resolve(s *Song) (int, error) {
+	if status, err := r.Get(); err != nil {
+		return status, err
+	}
+
+	// use the stored song if there is one
+	var existing *Song
+	if s.SongID != "" {
+		var err error
+		if existing, err = Store.GetSong(s.SongID); err != nil {
+			logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Error getting song from table")
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	if existing != nil {
+		*s = *existing
+		if err := s.GetPlay(r.CountdownID); err != nil {
+			return http.StatusInternalServerError, err
+		}
+		if s.MergedInto != nil {
+			return http.StatusBadRequest, errors.New("the song has been merged into another song")
+		}
+		if s.PlayOrder != nil {
+			return http.StatusConflict, errors.New("the song has already been played in the countdown")
+		}
+	} else {
+		if s.Name == "" || s.Artist == "" {
+			return http.StatusBadRequest, errors.New("a new song needs a name and artist")
+		}
+		if s.SongID == "" {
+			s.SongID = uuid.NewString()
+		}
+		if err := s.Create(); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	// the recording is this song from now on
+	s.AddAlias(AliasProviderArid, r.Play.Recording.Arid)
+	if err := s.SaveAliases(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	playedAt := r.playedAt()
+	s.PlayedAt = &playedAt
+	if err := s.PlayedInOrder(r.CountdownID, r.PlayOrder); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if err := Store.DeletePlayReview(r.CountdownID, r.ReviewID); err != nil {
+		logger.Log.Error().Err(err).Str("reviewID", r.ReviewID).Msg("Unable to remove the play from the review list")
+		return http.StatusInternalServerError, err
+	}
+
+	logger.Log.Info().Str("reviewID", r.ReviewID).Str("songID", s.SongID).Int("playOrder", r.PlayOrder).Msg("Resolved the play")
+	return http.StatusOK, nil
+}
@@ -0,0 +1,206 @@
+package types
+
+import (
+	"fmt"
+	"jjj.rflett.com/jjj-api/logger"
+	"sort"
+	"time"
+)
+
+// Standing is where a member of a group was after a play
+type Standing struct {
+	UserID   string `json:"userID"`
+	Rank     int    `json:"rank"`
+	Points   int    `json:"points"`
+	SongsHit int    `json:"songsHit"`
+}
+
+// Snapshot is a group's standings in a countdown straight after a play was scored. They're only taken for the groups
+// with a voter of the song in them, the standings at any other play are the ones from the snapshot before it.
+type Snapshot struct {
+	PK          string     `json:"-" dynamodbav:"PK"`
+	SK          string     `json:"-" dynamodbav:"SK"`
+	GroupID     string     `json:"groupID"`
+	CountdownID string     `json:"countdownID"`
+	PlayOrder   int        `json:"playOrder"`
+	SongID      string     `json:"songID"`
+	TakenAt     string     `json:"takenAt"`
+	Standings   []Standing `json:"standings"`
+}
+
+// GroupHistory is every snapshot of a group in a countdown, in the order of their plays
+type GroupHistory struct {
+	GroupID     string     `json:"groupID"`
+	CountdownID string     `json:"countdownID"`
+	Snapshots   []Snapshot `json:"snapshots"`
+}
+
+// return the partition key value for a snapshot
+func (s *Snapshot) PKVal() string {
+	return fmt.Sprintf("%s#%s", GroupPartitionKey, s.GroupID)
+}
+
+// return the sort key value for a snapshot, the play order is padded so they sort in the order of their plays
+func (s *Snapshot) SKVal() string {
+	return fmt.Sprintf("%s%04d", snapshotSKPrefix(s.CountdownID), s.PlayOrder)
+}
+
+// snapshotSKPrefix is the prefix of the sort key of every snapshot in a countdown
+func snapshotSKPrefix(countdownID string) string {
+	return fmt.Sprintf("%s#%s#", SnapshotSortKey, countdownID)
+}
+
+// rankStandings orders the standings by their points and fills in their dense ranks
+func rankStandings(standings []Standing) {
+	sort.Slice(standings, func(i, j int) bool {
+		if standings[i].Points != standings[j].Points {
+			return standings[i].Points > standings[j].Points
+		}
+		return standings[i].UserID < standings[j].UserID
+	})
+	points := make([]int, len(standings))
+	for i := range standings {
+		points[i] = standings[i].Points
+	}
+	for i, rank := range denseRanks(points) {
+		standings[i].Rank = rank
+	}
+}
+
+// SnapshotGroups takes a snapshot after the song's play for each group with one of its voters in it. The standings are
+// each member's recorded points plus what the song gave them, when the scorer hasn't recorded that yet. It only reads
+// the voters' groups, their members' points and the song's awards, so it costs the same at the end of the countdown as
+// at the start.
+func (c *Countdown) SnapshotGroups(s *Song, awards []Award) error {
+	if s.PlayOrder == nil || len(awards) == 0 {
+		return nil
+	}
+	playOrder := *s.PlayOrder
+
+	// the groups the voters are in
+	var groupIDs []string
+	seen := map[string]bool{}
+	for _, a := range awards {
+		ids, err := Store.GetGroupIDs(a.UserID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("userID", a.UserID).Msg("Unable to get the user's groups")
+			return err
+		}
+		for _, groupID := range ids {
+			if !seen[groupID] {
+				seen[groupID] = true
+				groupIDs = append(groupIDs, groupID)
+			}
+		}
+	}
+	sort.Strings(groupIDs)
+
+	members := map[string][]string{}
+	var userIDs []string
+	inGroups := map[string]bool{}
+	for _, groupID := range groupIDs {
+		memberIDs, err := Store.GetMemberIDs(groupID)
+		if err != nil {
+			logger.Log.Error().Err(err).Str("groupID", groupID).Msg("Unable to get the group's members")
+			return err
+		}
+		members[groupID] = memberIDs
+		for _, userID := range memberIDs {
+			if !inGroups[userID] {
+				inGroups[userID] = true
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+
+	// the points are read before the song's awards, so an award recorded in between is left out rather than counted twice
+	points, err := Store.GetUsersPoints(c.CountdownID, userIDs)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("countdownID", c.CountdownID).Msg("Unable to get the members' points")
+		return err
+	}
+	recorded, err := Store.GetSongAwards(c.CountdownID, s.SongID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Unable to get the song's awards")
+		return err
+	}
+	alreadyRecorded := map[string]bool{}
+	for _, a := range recorded {
+		alreadyRecorded[a.UserID] = true
+	}
+
+	// a user's standing is the same in each of their groups
+	standings := map[string]Standing{}
+	for _, up := range points {
+		standings[up.UserID] = Standing{UserID: up.UserID, Points: up.Points, SongsHit: up.Hits}
+	}
+	for _, a := range awards {
+		if alreadyRecorded[a.UserID] {
+			continue
+		}
+		st := standings[a.UserID]
+		st.UserID = a.UserID
+		st.Points += a.Points
+		st.SongsHit++
+		standings[a.UserID] = st
+	}
+
+	takenAt := time.Now().UTC().Format(time.RFC3339)
+	for _, groupID := range groupIDs {
+		snapshot := Snapshot{
+			GroupID:     groupID,
+			CountdownID: c.CountdownID,
+			PlayOrder:   playOrder,
+			SongID:      s.SongID,
+			TakenAt:     takenAt,
+			Standings:   make([]Standing, 0, len(members[groupID])),
+		}
+		for _, userID := range members[groupID] {
+			st, ok := standings[userID]
+			if !ok {
+				st = Standing{UserID: userID}
+			}
+			snapshot.Standings = append(snapshot.Standings, st)
+		}
+		rankStandings(snapshot.Standings)
+
+		snapshot.PK = snapshot.PKVal()
+		snapshot.SK = snapshot.SKVal()
+		if err = Store.PutSnapshot(&snapshot); err != nil {
+			logger.Log.Error().Err(err).Str("groupID", groupID).Int("playOrder", playOrder).Msg("Unable to save the group's snapshot")
+			return err
+		}
+	}
+	logger.Log.Info().Str("songID", s.SongID).Int("groups", len(groupIDs)).Msg("Took snapshots of the groups after the play")
+	return nil
+}
+
+// History returns the group's snapshots in the countdown
+func (g *Group) History(countdownID string) (*GroupHistory, error) {
+	snapshots, err := Store.GetSnapshots(g.GroupID, countdownID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("groupID", g.GroupID).Msg("Unable to get the group's snapshots")
+		return nil, err
+	}
+	if snapshots == nil {
+		snapshots = []Snapshot{}
+	}
+	return &GroupHistory{GroupID: g.GroupID, CountdownID: countdownID, Snapshots: snapshots}, nil
+}
+
+// StandingsAt returns the group's last snapshot in the countdown at or before the play. It's nil when nothing had been
+// scored in the group by then.
+func (g *Group) StandingsAt(countdownID string, playOrder int) (*Snapshot, error) {
+	history, err := g.History(countdownID)
+	if err != nil {
+		return nil, err
+	}
+	var at *Snapshot
+	for i := range history.Snapshots {
+		if history.Snapshots[i].PlayOrder > playOrder {
+			break
+		}
+		at = &history.Snapshots[i]
+	}
+	return at, nil
+}
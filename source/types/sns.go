@@ -2,15 +2,12 @@ package types
 
 import (
 	"context"
-	"errors"
+	"encoding/json"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/sns"
-	"github.com/aws/aws-sdk-go/aws"
 	"jjj.rflett.com/jjj-api/clients"
 	"jjj.rflett.com/jjj-api/logger"
+	"jjj.rflett.com/jjj-api/push"
 )
 
 type PlatformApp struct {
@@ -24,6 +21,17 @@ type PlatformEndpoint struct {
 	Arn      string `json:"-"`
 	UserID   string `json:"-"`
 	Platform string `json:"-"`
+	Token    string `json:"-"` // Token is the device's APNs or FCM token, it's needed to push without SNS
+}
+
+// PKVal returns the partition key value for a PlatformEndpoint
+func (p *PlatformEndpoint) PKVal() string {
+	return fmt.Sprintf("%s#%s", UserPartitionKey, p.UserID)
+}
+
+// SKVal returns the sort key value for a PlatformEndpoint
+func (p *PlatformEndpoint) SKVal() string {
+	return fmt.Sprintf("%s#%s#%s", EndpointSortKey, p.Platform, p.Arn)
 }
 
 // GetPlatformEndpointFromToken returns a PlatformEndpoint based on the device token
@@ -45,6 +53,7 @@ func (p *PlatformApp) GetPlatformEndpointFromToken(token *string) (platformEndpo
 					UserID:   endpoint.Attributes["CustomUserData"],
 					Arn:      *endpoint.EndpointArn,
 					Platform: p.Platform,
+					Token:    *token,
 				}
 				break
 			}
@@ -70,19 +79,14 @@ func (p *PlatformApp) CreatePlatformEndpoint(userID string, token *string) error
 
 	// create platform endpoint in table
 	pe := PlatformEndpoint{
-		PK:       fmt.Sprintf("%s#%s", UserPartitionKey, userID),
-		SK:       fmt.Sprintf("%s#%s#%s", EndpointSortKey, p.Platform, *endpoint.EndpointArn),
 		UserID:   userID,
 		Arn:      *endpoint.EndpointArn,
 		Platform: p.Platform,
+		Token:    *token,
 	}
-	av, _ := attributevalue.MarshalMap(pe)
-	input := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-	if _, err = clients.DynamoClient.PutItem(context.TODO(), input); err != nil {
+	pe.PK = pe.PKVal()
+	pe.SK = pe.SKVal()
+	if err = Store.PutEndpoint(&pe); err != nil {
 		logger.Log.Error().Err(err).Str("platformAppArn", p.Arn).Str("endpointArn", *endpoint.EndpointArn).Msg("Error adding endpoint arn to user")
 		return err
 	}
@@ -93,23 +97,17 @@ func (p *PlatformApp) CreatePlatformEndpoint(userID string, token *string) error
 
 // Delete a PlatformEndpoint from SNS and the user's endpoints in dynamo
 func (p *PlatformEndpoint) Delete() error {
-	// create the endpoint
-	snsInput := &sns.DeleteEndpointInput{EndpointArn: &p.Arn}
-	_, err := clients.SNSClient.DeleteEndpoint(context.TODO(), snsInput)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("endpointArn", p.Arn).Msg("Error deleting endpoint")
-		return err
+	// delete the endpoint, unless it was never in SNS
+	if p.Arn != "" {
+		snsInput := &sns.DeleteEndpointInput{EndpointArn: &p.Arn}
+		if _, err := clients.SNSClient.DeleteEndpoint(context.TODO(), snsInput); err != nil {
+			logger.Log.Error().Err(err).Str("endpointArn", p.Arn).Msg("Error deleting endpoint")
+			return err
+		}
 	}
 
 	// delete platform endpoint from table
-	input := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", UserPartitionKey, p.UserID)},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s#%s", EndpointSortKey, p.Platform, p.Arn)},
-		},
-		TableName: &DynamoTable,
-	}
-	if _, err = clients.DynamoClient.DeleteItem(context.TODO(), input); err != nil {
+	if err := Store.DeleteEndpoint(p); err != nil {
 		logger.Log.Error().Err(err).Str("endpointArn", p.Arn).Msg("Error deleting endpoint arn from user")
 		return err
 	}
@@ -118,63 +116,38 @@ func (p *PlatformEndpoint) Delete() error {
 	return nil
 }
 
-// SendAppleNotification sends a Notification to an ios PlatformEndpoint
-func (p *PlatformEndpoint) SendAppleNotification(notification *Notification) error {
-	input := &sns.PublishInput{
-		Message:          aws.String(notification.IosPayload()),
-		MessageStructure: aws.String("json"),
-		TargetArn:        &p.Arn,
-	}
-	message, err := clients.SNSClient.Publish(context.TODO(), input)
+// pushMessage builds the notification's payloads for each platform
+func (n *Notification) pushMessage() (push.Message, error) {
+	apns, err := json.Marshal(n.APNs())
 	if err != nil {
-		logger.Log.Error().Err(err).Str("endpointArn", p.Arn).Msg("Error publishing ios notification to SNS")
-		return p.Delete()
-	}
-
-	logger.Log.Info().Str("userID", p.UserID).Str("messageID", *message.MessageId).Msg("Successfully sent ios notification")
-	return nil
-}
-
-// SendAndroidNotification sends a Notification to an ios PlatformEndpoint
-func (p *PlatformEndpoint) SendAndroidNotification(notification *Notification) error {
-	input := &sns.PublishInput{
-		Message:          aws.String(notification.AndroidPayload()),
-		MessageStructure: aws.String("json"),
-		TargetArn:        &p.Arn,
+		return push.Message{}, err
 	}
-	message, err := clients.SNSClient.Publish(context.TODO(), input)
+	fcm, err := json.Marshal(n.FCM())
 	if err != nil {
-		logger.Log.Error().Err(err).Str("endpointArn", p.Arn).Msg("Error publishing android notification to SNS")
-		return p.Delete()
+		return push.Message{}, err
 	}
-
-	logger.Log.Info().Str("userID", p.UserID).Str("messageID", *message.MessageId).Msg("Successfully sent android notification")
-	return nil
+	return push.Message{APNs: apns, FCM: fcm, CollapseKey: n.CollapseKey}, nil
 }
 
-// SendNotification sends a notification to a PlatformEndpoint
+// SendNotification sends a notification to a PlatformEndpoint with push.Pusher. The endpoint is only deleted when the
+// push says its device has gone, other failures leave it for the next notification.
 func (p *PlatformEndpoint) SendNotification(n *Notification) error {
-	// generate message
-	var message string
-	switch p.Platform {
-	case SNSPlatformGoogle:
-		message = n.AndroidPayload()
-	case SNSPlatformApple:
-		message = n.IosPayload()
-	default:
-		return errors.New("unsupported platform")
+	message, err := n.pushMessage()
+	if err != nil {
+		logger.Log.Error().Err(err).Str("kind", n.Kind).Msg("Unable to build the notification's payloads")
+		return err
 	}
 
-	resp, err := clients.SNSClient.Publish(context.TODO(), &sns.PublishInput{
-		Message:          &message,
-		MessageStructure: aws.String("json"),
-		TargetArn:        &p.Arn,
-	})
-	if err != nil {
-		logger.Log.Error().Err(err).Str("endpointArn", p.Arn).Msg("Error publishing android notification to SNS")
+	id, err := push.Pusher.Push(context.TODO(), push.Device{Platform: p.Platform, Token: p.Token, Arn: p.Arn}, message)
+	if push.IsInvalidToken(err) {
+		logger.Log.Info().Err(err).Str("userID", p.UserID).Str("endpointArn", p.Arn).Msg("Device is no longer registered, deleting its endpoint")
 		return p.Delete()
 	}
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userID", p.UserID).Str("endpointArn", p.Arn).Str("platform", p.Platform).Msg("Error sending notification")
+		return err
+	}
 
-	logger.Log.Info().Str("userID", p.UserID).Str("messageID", *resp.MessageId).Msg("Successfully sent android notification")
+	logger.Log.Info().Str("userID", p.UserID).Str("messageID", id).Str("platform", p.Platform).Msg("Successfully sent notification")
 	return nil
 }
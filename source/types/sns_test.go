@@ -0,0 +1,41 @@
+package types
+
+import (
+	"github.com/stretchr/testify/assert"
+	"jjj.rflett.com/jjj-api/push"
+	"net/http"
+	"testing"
+)
+
+func TestSendNotificationOnlyDeletesGoneDevices(t *testing.T) {
+	UseTestStorage()
+	mock := push.NewMock()
+	mock.Start()
+	defer mock.Close()
+	pusher := push.Pusher
+	push.Pusher = mock.Direct()
+	defer func() { push.Pusher = pusher }()
+
+	iphone := PlatformEndpoint{UserID: TestAuthProviderUserID, Platform: SNSPlatformApple, Token: "iphone"}
+	pixel := PlatformEndpoint{UserID: TestAuthProviderUserID, Platform: SNSPlatformGoogle, Token: "pixel"}
+	assert.Nil(t, Store.PutEndpoint(&iphone))
+	assert.Nil(t, Store.PutEndpoint(&pixel))
+
+	n := Notification{Kind: NotificationSongPlayed, Title: "Your song just played!", Message: "Elephant", CollapseKey: "song"}
+	assert.Nil(t, iphone.SendNotification(&n))
+	assert.Nil(t, pixel.SendNotification(&n))
+	deliveries := mock.Deliveries()
+	assert.Len(t, deliveries, 2)
+	assert.Equal(t, "song", deliveries[0].Headers["apns-collapse-id"])
+
+	// FCM being down leaves the device for next time, but an uninstalled app removes it
+	mock.Fail("pixel", http.StatusServiceUnavailable, "UNAVAILABLE")
+	assert.NotNil(t, pixel.SendNotification(&n))
+	mock.Unregister("iphone")
+	assert.Nil(t, iphone.SendNotification(&n))
+
+	endpoints, err := Store.GetEndpoints(TestAuthProviderUserID)
+	assert.Nil(t, err)
+	assert.Len(t, endpoints, 1)
+	assert.Equal(t, "pixel", endpoints[0].Token)
+}
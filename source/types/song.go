@@ -1,19 +1,11 @@
 package types
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/aws/aws-sdk-go/aws"
-	"jjj.rflett.com/jjj-api/clients"
 	"jjj.rflett.com/jjj-api/logger"
 	"jjj.rflett.com/jjj-api/types/jjj"
 	"regexp"
-	"strconv"
 	"time"
 )
 
@@ -27,9 +19,12 @@ type Song struct {
 	Artist         string             `json:"artist"`
 	Artwork        *[]jjj.ArtworkSize `json:"artwork"`
 	Rank           *int               `json:"rank" dynamodbav:"-"`
-	PlayedPosition *int               `json:"playedPosition"`
-	PlayedAt       *string            `json:"playedAt"`
+	PlayOrder      *int               `json:"playOrder" dynamodbav:"-"`      // PlayOrder, PlayedPosition and PlayedAt are for a countdown, see GetPlay
+	PlayedPosition *int               `json:"playedPosition" dynamodbav:"-"` // PlayedPosition is nil when the song was played outside the countdown
+	PlayedAt       *string            `json:"playedAt" dynamodbav:"-"`
 	CreatedAt      *string            `json:"createdAt"`
+	MergedInto     *string            `json:"mergedInto,omitempty"`             // MergedInto is the song this one is a duplicate of
+	Aliases        []SongAlias        `json:"aliases,omitempty" dynamodbav:"-"` // Aliases are added to the song with SaveAliases
 }
 
 // return the partition key value for a song
@@ -44,24 +39,15 @@ func (s *Song) SKVal() string {
 
 // SearchString should be used in spotify requests to search for the song
 func (s *Song) SearchString() string {
-	// only allow letters, numbers and spaces in search string
-	reg, _ := regexp.Compile("[^a-zA-Z0-9 ]+")
+	// only allow letters, numbers and spaces in search string, including accented letters
+	reg, _ := regexp.Compile(`[^\p{L}\p{N} ]+`)
 	return reg.ReplaceAllString(fmt.Sprintf("%s %s", s.Name, s.Artist), "")
 }
 
 // Delete the song
 func (s *Song) Delete() error {
-	// input
-	input := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: s.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: s.SKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// delete from table
-	_, err := clients.DynamoClient.DeleteItem(context.TODO(), input)
+	err := Store.DeleteSong(s.SongID)
 
 	// handle errors
 	if err != nil {
@@ -79,18 +65,8 @@ func (s *Song) Create() error {
 	createdAt := time.Now().UTC().Format(time.RFC3339)
 	s.CreatedAt = &createdAt
 
-	// create item
-	av, _ := attributevalue.MarshalMap(s)
-
-	// create input
-	input := &dynamodb.PutItemInput{
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-		TableName:    &DynamoTable,
-	}
-
 	// add to table
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutSong(s)
 
 	// handle errors
 	if err != nil {
@@ -104,29 +80,8 @@ func (s *Song) Create() error {
 
 // Exists checks to see if the song exists in the table already and returns an error if it does
 func (s *Song) Exists() (bool, error) {
-	// input
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(s.PKVal()))
-	skCondition := expression.Key(SortKey).Equal(expression.Value(s.SKVal()))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("SongID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for song Exists func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	result, err := Store.GetSong(s.SongID)
 
 	// handle errors
 	if err != nil {
@@ -135,7 +90,7 @@ func (s *Song) Exists() (bool, error) {
 	}
 
 	// song doesn't exist
-	if len(result.Items) == 0 {
+	if result == nil {
 		logger.Log.Info().Str("songID", s.SongID).Msg("Song does not exist in table")
 		return false, nil
 	}
@@ -145,65 +100,94 @@ func (s *Song) Exists() (bool, error) {
 	return true, nil
 }
 
-// Played marks the song as played and records its play time and position
-func (s *Song) Played(currentPlayCount int) error {
-	// update query
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#PA": "PlayedAt",
-			"#PP": "PlayedPosition",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":pk": &dbTypes.AttributeValueMemberS{Value: s.PKVal()},
-			":sk": &dbTypes.AttributeValueMemberS{Value: s.SKVal()},
-			":pa": &dbTypes.AttributeValueMemberS{Value: *s.PlayedAt},
-			":pp": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(currentPlayCount)},
-			":n":  &dbTypes.AttributeValueMemberS{Value: "NULL"},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: s.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: s.SKVal()},
-		},
-		ReturnValues:        dbTypes.ReturnValueNone,
-		TableName:           &DynamoTable,
-		ConditionExpression: aws.String("PK = :pk and SK = :sk and attribute_type(#PP, :n)"),
-		UpdateExpression:    aws.String("SET #PA = :pa, #PP = :pp"),
+// Played marks the song as played in the countdown and records its play time, order and position
+func (s *Song) Played(countdownID string, currentPlayCount int) error {
+	played, err := s.markPlayed(countdownID, currentPlayCount)
+	if err != nil || !played {
+		return err
+	}
+
+	// increment the played count
+	if err = Store.IncrementPlayCount(countdownID); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to increment the latest song position")
+	}
+	return nil
+}
+
+// PlayedInOrder marks the song as played in a play order that was already taken, like one kept by a PlayReview, so the
+// play count isn't incremented
+func (s *Song) PlayedInOrder(countdownID string, playOrder int) error {
+	_, err := s.markPlayed(countdownID, playOrder)
+	return err
+}
+
+// markPlayed records the song's play time, order and position and adds it to the played list, it returns false if the
+// song had already been played
+func (s *Song) markPlayed(countdownID string, playOrder int) (bool, error) {
+	countdown := Countdown{CountdownID: countdownID}
+	if _, err := countdown.Get(); err != nil {
+		return false, err
+	}
+	play := &SongPlay{
+		PK:          countdownPK(countdownID),
+		SK:          songPlaySK(s.SongID),
+		CountdownID: countdownID,
+		SongID:      s.SongID,
+		PlayedAt:    *s.PlayedAt,
+		PlayOrder:   playOrder,
+		Position:    countdown.PositionOf(playOrder),
 	}
 
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+	// in the lead up to the day songs will get played twice - we don't want to mark them as played twice, only the first time
+	err := Store.SetSongPlayed(play)
 
 	if err != nil {
-		// in the lead up to the day songs will get played twice - we don't want to mark them as played twice, only the first time
-		// the condition will fail if the PlayedPosition attribute is not NULL, see line 171
-		var crf *dbTypes.ConditionalCheckFailedException
-		if errors.As(err, &crf) {
-			logger.Log.Info().Str("songID", s.SongID).Msg("The song wasn't updated because it has already been played.")
-			return nil
+		if errors.Is(err, ErrConditionalCheckFailed) {
+			logger.Log.Info().Str("songID", s.SongID).Str("countdownID", countdownID).Msg("The song wasn't updated because it has already been played.")
+			return false, nil
 		}
 
 		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("Error updating song")
+		return false, err
+	}
+
+	// add it to the playlist
+	if err = Store.AddPlayedSongID(countdownID, s.SongID); err != nil {
+		logger.Log.Error().Err(err).Msg("Unable to add songID to played list")
+	}
+	s.setPlay(play)
+	return true, nil
+}
+
+// setPlay fills in when and where the song was played from the play, or clears them if it's nil
+func (s *Song) setPlay(play *SongPlay) {
+	s.PlayedAt, s.PlayOrder, s.PlayedPosition = nil, nil, nil
+	if play == nil {
+		return
+	}
+	s.PlayedAt = &play.PlayedAt
+	s.PlayOrder = &play.PlayOrder
+	if play.Position > 0 {
+		s.PlayedPosition = &play.Position
+	}
+}
+
+// GetPlay fills in when and where the song was played in the countdown, they're left nil if it hasn't been played
+func (s *Song) GetPlay(countdownID string) error {
+	play, err := Store.GetSongPlay(countdownID, s.SongID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("songID", s.SongID).Str("countdownID", countdownID).Msg("error getting song play from table")
 		return err
 	}
 
-	// add it to the playlist and then increment the played count
-	s.addToPlayedList()
-	incrementPlayCount()
+	s.setPlay(play)
 	return nil
 }
 
 // Get the song from the table
 func (s *Song) Get() error {
-	// get query
-	input := &dynamodb.GetItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: s.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: s.SKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// getItem
-	result, err := clients.DynamoClient.GetItem(context.TODO(), input)
+	result, err := Store.GetSong(s.SongID)
 
 	// handle errors
 	if err != nil {
@@ -211,62 +195,12 @@ func (s *Song) Get() error {
 		return err
 	}
 
-	if len(result.Item) == 0 {
+	if result == nil {
 		return errors.New("unable to find song in table")
 	}
 
-	// unmarshal item into struct
-	err = attributevalue.UnmarshalMap(result.Item, &s)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("songID", s.SongID).Msg("failed to unmarshal dynamo item to group")
-	}
+	// the rank isn't stored so keep it
+	result.Rank = s.Rank
+	*s = *result
 	return nil
 }
-
-// incrementPlayCount increments the current playCount value
-func incrementPlayCount() {
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#V": "value",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":inc": &dbTypes.AttributeValueMemberN{Value: "1"},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: PlayCountPartitionKey},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: PlayCountSortKey},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("ADD #V :inc"),
-	}
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to increment the latest song position")
-	}
-}
-
-// addToPlayedList adds the song to the list of played songs
-func (s *Song) addToPlayedList() {
-	av := &dbTypes.AttributeValueMemberS{Value: s.SongID}
-
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#S": "SongIDs",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":s": &dbTypes.AttributeValueMemberL{Value: []dbTypes.AttributeValue{av}},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: PlayedSongsPartitionKey},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: PlayedSongsSortKey},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("SET #S = list_append(#S, :s)"),
-	}
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("Unable to add songID to played list")
-	}
-}
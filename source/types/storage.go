@@ -0,0 +1,140 @@
+package types
+
+import "errors"
+
+// ErrConditionalCheckFailed is returned by a Storage when a conditional write doesn't meet its condition
+var ErrConditionalCheckFailed = errors.New("the conditional request failed")
+
+// ErrInvalidCursor is returned by a Storage when a page's cursor can't be read
+var ErrInvalidCursor = errors.New("the cursor isn't valid")
+
+// ErrTransactionTooBig is returned by a Storage when writes that have to be made together don't fit in one transaction
+var ErrTransactionTooBig = errors.New("the writes don't fit in one transaction")
+
+// Store is the Storage used by the types package, it defaults to the DynamoDB table and can be swapped out with
+// something like a MemoryStorage for running locally or in tests
+var Store Storage
+
+// Storage is the persistence layer behind the types package. Get methods return a nil item when it doesn't exist.
+type Storage interface {
+	// users
+	GetUser(userID string) (*User, error)
+	GetUsers(userIDs []string) ([]User, error)
+	PutUser(u *User) error
+	UpdateUser(u *User) error
+	SetUserAvatarUrl(userID string, avatarUrl string) error
+	GetUserPoints(countdownID string, userID string) (int, error)
+	GetUsersPoints(countdownID string, userIDs []string) ([]userPoints, error)
+	GetCountdownPoints(countdownID string) (map[string]userPoints, error)
+	ResetUserScores(countdownID string, changes []ScoreChange) error // ResetUserScores fails with ErrConditionalCheckFailed if any user's points aren't their change's previous points, and ErrTransactionTooBig if the changes don't fit in one transaction
+	GetUserIDByAuthProvider(provider string, providerID string) (string, error)
+	PutAuthProvider(userID string, provider string, providerID string) error
+
+	// a countdown's leaderboard, ordered by points
+	GetLeaderboard(countdownID string, cursor string, limit int) (*leaderboardPage, error)
+	GetLeaderboardAround(countdownID string, points int, limit int) (above []userPoints, below []userPoints, err error) // GetLeaderboardAround returns the closest users with more points, and the users with the same points or less
+	CountPointsAbove(countdownID string, points int) (int, error)
+
+	// votes in a countdown
+	GetVotes(countdownID string, userID string) ([]songVote, error)
+	CountVotes(countdownID string, userID string) (int, error)
+	PutVote(vote *songVote) error
+	DeleteVote(countdownID string, userID string, songID string) error
+	GetVoters(countdownID string, songID string) ([]songVote, error) // GetVoters only fills in the UserID, SongID and Rank of the votes
+
+	// countdowns
+	GetCountdown(countdownID string) (*Countdown, error)
+	GetCountdowns() ([]Countdown, error)
+	PutCountdown(c *Countdown) error
+	GetCurrentCountdownID() (string, error)
+	SetCurrentCountdownID(countdownID string) error
+
+	// groups and their codes
+	GetGroup(groupID string) (*Group, error)
+	PutGroup(g *Group) error
+	UpdateGroup(g *Group) error
+	SetGroupOwner(groupID string, ownerID string, updatedAt string) error
+	DeleteGroup(groupID string) error
+	GetGroupCode(groupID string) (*GroupCode, error)
+	GetGroupCodeByCode(code string) (*GroupCode, error)
+	PutGroupCode(gc *GroupCode) error
+	DeleteGroupCode(groupID string, code string) error
+
+	// group memberships
+	PutMembership(groupID string, userID string, createdAt string) error
+	DeleteMembership(groupID string, userID string) error
+	IsMember(groupID string, userID string) (bool, error)
+	GetGroupIDs(userID string) ([]string, error)
+	GetMemberIDs(groupID string) ([]string, error)
+
+	// games
+	GetGame(groupID string, gameID string) (*Game, error)
+	GetGames(groupID string) ([]Game, error)
+	PutGame(g *Game) error
+	UpdateGame(g *Game) error
+	DeleteGame(groupID string, gameID string) error
+
+	// snapshots of a group's standings after each play
+	PutSnapshot(s *Snapshot) error
+	GetSnapshots(groupID string, countdownID string) ([]Snapshot, error) // GetSnapshots returns them in the order of their plays
+
+	// songs
+	GetSong(songID string) (*Song, error)
+	GetSongs(songIDs []string) ([]Song, error)
+	ListSongs() ([]Song, error)
+	PutSong(s *Song) error
+	DeleteSong(songID string) error
+
+	// a countdown's plays, its play count and its played list
+	RecordAward(award *Award, points int) error // RecordAward fails with ErrConditionalCheckFailed if the award isn't the next revision
+	GetAward(countdownID string, userID string, songID string) (*Award, error)
+	GetAwards(countdownID string, userID string) ([]Award, error)
+	GetSongAwards(countdownID string, songID string) ([]Award, error)
+	SetSongPlayed(play *SongPlay) error // SetSongPlayed fails with ErrConditionalCheckFailed if the song has been played
+	PutSongPlay(play *SongPlay) error
+	GetSongPlay(countdownID string, songID string) (*SongPlay, error)
+	GetSongPlays(countdownID string) ([]SongPlay, error)
+	DeleteSongPlay(countdownID string, songID string) error
+	GetPlayCount(countdownID string) (int, error)
+	IncrementPlayCount(countdownID string) error
+	SetPlayCount(countdownID string, count int) error
+	GetPlayedSongIDs(countdownID string) ([]string, error)
+	AddPlayedSongID(countdownID string, songID string) error
+	ResetPlayedSongIDs(countdownID string) error
+
+	// the votes and plays from before countdowns, which are only read to import them into one
+	GetLegacyVotes() ([]songVote, error) // GetLegacyVotes leaves out the CountdownID
+	GetLegacyPlays() ([]SongPlay, error) // GetLegacyPlays only fills in the SongID, PlayedAt and PlayOrder
+	GetLegacyPlayCount() (int, error)
+	GetLegacyPlayedSongIDs() ([]string, error)
+
+	// song aliases
+	GetSongAlias(provider string, aliasID string) (*SongAlias, error)
+	GetSongAliases(songID string) ([]SongAlias, error)
+	PutSongAlias(a *SongAlias) error
+	DeleteSongAlias(a *SongAlias) error
+	MergeSong(duplicateID string, canonicalID string, removed []SongAlias, added []SongAlias) error // MergeSong fails with ErrConditionalCheckFailed if the duplicate has been merged
+
+	// plays in a countdown waiting for review
+	GetPlayReview(countdownID string, reviewID string) (*PlayReview, error)
+	GetPlayReviews(countdownID string) ([]PlayReview, error)
+	PutPlayReview(r *PlayReview) error // PutPlayReview fails with ErrConditionalCheckFailed if the review exists
+	DeletePlayReview(countdownID string, reviewID string) error
+
+	// device endpoints
+	GetEndpoints(userID string) ([]PlatformEndpoint, error)
+	PutEndpoint(p *PlatformEndpoint) error
+	DeleteEndpoint(p *PlatformEndpoint) error
+
+	// notification preferences
+	GetNotificationPreferences(userID string) (*NotificationPreferences, error) // nil when the user hasn't set any
+	PutNotificationPreferences(p *NotificationPreferences) error
+
+	// notification inbox
+	AddInboxItem(item *InboxItem) error // AddInboxItem fails with ErrConditionalCheckFailed if the item exists
+	GetInboxItem(userID string, notificationID string) (*InboxItem, error)
+	GetInbox(userID string, cursor string, limit int) (*inboxPage, error) // GetInbox is newest first
+	GetUnreadInboxIDs(userID string) ([]string, error)
+	MarkInboxItemRead(userID string, notificationID string, readAt string) error // fails with ErrConditionalCheckFailed if it's already read
+	CountUnread(userID string) (int, error)
+}
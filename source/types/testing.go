@@ -0,0 +1,97 @@
+package types
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"golang.org/x/crypto/bcrypt"
+	"jjj.rflett.com/jjj-api/config"
+	"time"
+)
+
+// TestSongID is the song that the test user has voted for in the test storage
+const TestSongID = "0d1e2ab3c4d5e6f7a8b9c0"
+
+// TestAdminUserID is one of the administrators in IsAdmin
+const TestAdminUserID = "2ef05ca2-aef4-40aa-8e5f-d69c7795e543"
+
+// TestCountdownID is the current countdown in the test storage
+const TestCountdownID = "7c1f9e4a-2b3d-4e5f-8a6b-9c0d1e2f3a4b"
+
+// UseTestStorage swaps the Store for a MemoryStorage seeded with the current countdown, the test user, their group, a
+// game and a vote, and generates a JWTSigningKey so the handlers can be tested without AWS
+func UseTestStorage() *MemoryStorage {
+	m := NewMemoryStorage()
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	// the countdown everything happens in
+	countdown := Countdown{CountdownID: TestCountdownID, Name: "Hottest 100", Year: 2022, State: CountdownLive, CreatedAt: now}
+	countdown.PK = countdown.PKVal()
+	countdown.SK = countdown.SKVal()
+	_ = m.PutCountdown(&countdown)
+	_ = m.SetCurrentCountdownID(TestCountdownID)
+
+	// the test user, who signs in with an empty password
+	hashed, _ := bcrypt.GenerateFromPassword([]byte(TestAuthProviderPass), bcrypt.MinCost)
+	password := string(hashed)
+	provider := TestAuthProvider
+	providerID := TestAuthProviderId
+	user := User{
+		UserID:         TestAuthProviderUserID,
+		Name:           TestAuthProviderName,
+		Email:          TestAuthProviderId,
+		CreatedAt:      now,
+		AuthProvider:   &provider,
+		AuthProviderId: &providerID,
+		Password:       &password,
+	}
+	user.PK = user.PKVal()
+	user.SK = user.SKVal()
+	_ = m.PutUser(&user)
+	_ = m.PutAuthProvider(TestAuthProviderUserID, TestAuthProvider, TestAuthProviderId)
+
+	// their group, which they own
+	group := Group{GroupID: TestAuthProviderGroupID, OwnerID: TestAuthProviderUserID, Name: "Test Group", CreatedAt: now}
+	group.PK = group.PKVal()
+	group.SK = group.SKVal()
+	_ = m.PutGroup(&group)
+	_ = m.PutMembership(TestAuthProviderGroupID, TestAuthProviderUserID, now)
+
+	code := GroupCode{PK: group.PKVal(), SK: GroupCodeSortKey + "#TESTER", GroupID: TestAuthProviderGroupID, Code: "TESTER"}
+	_ = m.PutGroupCode(&code)
+
+	game := Game{GameID: "b5c7d0a2-6d1e-4c3a-9f0e-3a1f2b4c5d6e", GroupID: TestAuthProviderGroupID, Name: "Test Game", CreatedAt: now}
+	game.PK = game.PKVal()
+	game.SK = game.SKVal()
+	_ = m.PutGame(&game)
+
+	// a song they've voted for
+	song := Song{SongID: TestSongID, Name: "Test Song", Album: "Test Album", Artist: "Test Artist", CreatedAt: &now}
+	song.PK = song.PKVal()
+	song.SK = song.SKVal()
+	_ = m.PutSong(&song)
+	_ = m.PutVote(&songVote{
+		PK:          user.PKVal(),
+		SK:          voteSK(TestCountdownID, TestSongID),
+		CountdownID: TestCountdownID,
+		SongID:      TestSongID,
+		UserID:      TestAuthProviderUserID,
+		Rank:        1,
+	})
+
+	Store = m
+	if config.Values.JWTSigningKey == "" {
+		config.Values.JWTSigningKey = newTestSigningKey()
+	}
+	return m
+}
+
+// newTestSigningKey generates a PEM encoded RSA private key for signing tokens in tests
+func newTestSigningKey() string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
@@ -4,19 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	dbTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
-	"github.com/aws/aws-sdk-go/aws"
 	sentryGo "github.com/getsentry/sentry-go"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"jjj.rflett.com/jjj-api/clients"
+	"jjj.rflett.com/jjj-api/config"
 	"jjj.rflett.com/jjj-api/logger"
 	"net/http"
-	"strconv"
 	"time"
 )
 
@@ -27,7 +22,7 @@ type User struct {
 	UserID         string   `json:"userID"`
 	Name           string   `json:"name"`
 	Email          string   `json:"email"`
-	Points         int      `json:"points"`
+	Points         int      `json:"points" dynamodbav:"-"` // Points are for a countdown, see GetPoints
 	CreatedAt      string   `json:"createdAt"`
 	Groups         *[]Group `json:"groups" dynamodbav:"-"`
 	NickName       *string  `json:"nickName"`
@@ -49,61 +44,22 @@ func (u *User) SKVal() string {
 	return fmt.Sprintf("%s#%s", UserSortKey, u.UserID)
 }
 
-// voteCount returns the number of votes a user already has
-func (u *User) voteCount() (count int, error error) {
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(u.PKVal()))
-	skCondition := expression.Key(SortKey).BeginsWith(fmt.Sprintf("%s#", SongPartitionKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("SongID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
+// voteCount returns the number of votes a user already has in the countdown
+func (u *User) voteCount(countdownID string) (count int, error error) {
+	count, err := Store.CountVotes(countdownID, u.UserID)
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for voteCount func")
-	}
-
-	// input
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
-	queryResult, queryErr := clients.DynamoClient.Query(context.TODO(), input)
-	if queryErr != nil {
-		logger.Log.Error().Err(queryErr).Str("userId", u.UserID).Msg("error querying user voteCount")
-		return 0, queryErr
+		logger.Log.Error().Err(err).Str("userId", u.UserID).Msg("error querying user voteCount")
+		return 0, err
 	}
-
-	return int(queryResult.Count), nil
+	return count, nil
 }
 
 // GenerateAvatarUrl generates a new avatar UUID and sets it on the user
 func (u *User) GenerateAvatarUrl() (avatarUuid string, error error) {
 	avatarUuid = uuid.NewString()
-	avatarUrl := fmt.Sprintf("https://%s/user/avatar/%s.jpg", AssetsDomain, avatarUuid)
+	avatarUrl := fmt.Sprintf("https://%s/user/avatar/%s.jpg", config.Values.AssetsDomain, avatarUuid)
 
-	// update query
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#A": "AvatarUrl",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":a": &dbTypes.AttributeValueMemberS{Value: avatarUrl},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: u.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: u.SKVal()},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("SET #A = :a"),
-	}
-
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+	err := Store.SetUserAvatarUrl(u.UserID, avatarUrl)
 
 	// handle errors
 	if err != nil {
@@ -122,18 +78,8 @@ func (u *User) Create() (status int, error error) {
 	u.SK = u.SKVal()
 	u.CreatedAt = time.Now().UTC().Format(time.RFC3339)
 
-	// create item
-	av, _ := attributevalue.MarshalMap(u)
-
-	// create input
-	input := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-
 	// add to table
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutUser(u)
 
 	// handle errors
 	if err != nil {
@@ -167,26 +113,8 @@ func (u *User) Update() (status int, error error) {
 	updatedAt := time.Now().UTC().Format(time.RFC3339)
 	u.UpdatedAt = &updatedAt
 
-	// update query
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#NN": "NickName",
-			"#UA": "UpdatedAt",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":nn": &dbTypes.AttributeValueMemberS{Value: *u.NickName},
-			":ua": &dbTypes.AttributeValueMemberS{Value: *u.UpdatedAt},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: u.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: u.SKVal()},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("SET #NN = :nn, #UA = :ua"),
-	}
-
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+	// update the item
+	err := Store.UpdateUser(u)
 
 	// handle errors
 	if err != nil {
@@ -197,8 +125,8 @@ func (u *User) Update() (status int, error error) {
 	return http.StatusNoContent, nil
 }
 
-// AddVote adds a song as a votes for the user
-func (u *User) AddVote(s *Song) (status int, error error) {
+// AddVote adds a song as a votes for the user in the countdown
+func (u *User) AddVote(countdownID string, s *Song) (status int, error error) {
 	// check if song exists and add it if it doesn't
 	exists, existsErr := s.Exists()
 	if existsErr != nil {
@@ -213,7 +141,7 @@ func (u *User) AddVote(s *Song) (status int, error error) {
 	}
 
 	// don't allow more than 10 votes
-	vc, vcErr := u.voteCount()
+	vc, vcErr := u.voteCount(countdownID)
 	if vcErr != nil {
 		return http.StatusInternalServerError, vcErr
 	}
@@ -223,23 +151,15 @@ func (u *User) AddVote(s *Song) (status int, error error) {
 		return http.StatusBadRequest, tooManyCountsErr
 	}
 
-	// create item
-	av, _ := attributevalue.MarshalMap(songVote{
-		PK:     u.PKVal(),
-		SK:     s.PKVal(),
-		SongID: s.SongID,
-		UserID: u.UserID,
-		Rank:   *s.Rank,
-	})
-
-	input := &dynamodb.PutItemInput{
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-		TableName:    &DynamoTable,
-	}
-
 	// add to table
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutVote(&songVote{
+		PK:          u.PKVal(),
+		SK:          voteSK(countdownID, s.SongID),
+		CountdownID: countdownID,
+		SongID:      s.SongID,
+		UserID:      u.UserID,
+		Rank:        *s.Rank,
+	})
 
 	// handle errors
 	if err != nil {
@@ -251,23 +171,10 @@ func (u *User) AddVote(s *Song) (status int, error error) {
 	return http.StatusNoContent, nil
 }
 
-// RemoveVote removes a song as a users vote
-func (u *User) RemoveVote(songID *string) (status int, error error) {
-	// delete query
-	input := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{
-				Value: u.PKVal(),
-			},
-			SortKey: &dbTypes.AttributeValueMemberS{
-				Value: fmt.Sprintf("%s#%s", SongPartitionKey, *songID),
-			},
-		},
-		TableName: &DynamoTable,
-	}
-
+// RemoveVote removes a song as a users vote in the countdown
+func (u *User) RemoveVote(countdownID string, songID *string) (status int, error error) {
 	// delete from table
-	_, err := clients.DynamoClient.DeleteItem(context.TODO(), input)
+	err := Store.DeleteVote(countdownID, u.UserID, *songID)
 
 	// handle errors
 	if err != nil {
@@ -279,42 +186,17 @@ func (u *User) RemoveVote(songID *string) (status int, error error) {
 	return http.StatusNoContent, nil
 }
 
-// GetVotes returns a users votes
-func (u *User) GetVotes() ([]Song, error) {
+// GetVotes returns a users votes in the countdown
+func (u *User) GetVotes(countdownID string) ([]Song, error) {
 	// get the users votes
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(u.PKVal()))
-	skCondition := expression.Key(SortKey).BeginsWith(fmt.Sprintf("%s#", SongPartitionKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("error building GetVotes expression")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-	}
-
-	userVotes, err := clients.DynamoClient.Query(context.TODO(), input)
+	userVotes, err := Store.GetVotes(countdownID, u.UserID)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("error getting users votes")
 		return []Song{}, err
 	}
 
 	var votes []Song = nil
-	for _, vote := range userVotes.Items {
-
-		// unmarshall the vote
-		songVote := songVote{}
-		if err = attributevalue.UnmarshalMap(vote, &songVote); err != nil {
-			logger.Log.Error().Err(err).Msg("Unable to unmarshal vote to songVote")
-			continue
-		}
-
+	for _, songVote := range userVotes {
 		// convert the vote to a full song record
 		song := Song{}
 		song, err = songVote.GetAsSong()
@@ -331,41 +213,16 @@ func (u *User) GetVotes() ([]Song, error) {
 
 // GetGroups returns the groups a user is a member of
 func (u *User) GetGroups() ([]Group, error) {
-	// get the users in the group
-	pkCondition := expression.Key(PartitionKey).BeginsWith(fmt.Sprintf("%s#", GroupPartitionKey))
-	skCondition := expression.Key(SortKey).Equal(expression.Value(u.PKVal()))
-	keyCondition := expression.KeyAnd(skCondition, pkCondition)
-
-	projExpr := expression.NamesList(expression.Name("GroupID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("error building GetGroups expression")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		IndexName:                 aws.String(GSI),
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
-	groupMemberships, err := clients.DynamoClient.Query(context.TODO(), input)
+	// get the groups the user is a member of
+	groupIDs, err := Store.GetGroupIDs(u.UserID)
 	if err != nil {
 		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("error querying users groups")
 		return []Group{}, err
 	}
 
 	var groups []Group = nil
-	for _, membership := range groupMemberships.Items {
-		group := Group{}
-		if err = attributevalue.UnmarshalMap(membership, &group); err != nil {
-			logger.Log.Error().Err(err).Msg("Unable to unmarshal group to Group")
-			continue
-		}
+	for _, groupID := range groupIDs {
+		group := Group{GroupID: groupID}
 		_, _ = group.Get()
 		groups = append(groups, group)
 	}
@@ -374,17 +231,8 @@ func (u *User) GetGroups() ([]Group, error) {
 
 // GetByUserID the user from the table
 func (u *User) GetByUserID() (status int, error error) {
-	// get query
-	input := &dynamodb.GetItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: u.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: u.SKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// getItem
-	result, err := clients.DynamoClient.GetItem(context.TODO(), input)
+	result, err := Store.GetUser(u.UserID)
 
 	// handle errors
 	if err != nil {
@@ -392,47 +240,21 @@ func (u *User) GetByUserID() (status int, error error) {
 		return http.StatusInternalServerError, err
 	}
 
-	if len(result.Item) == 0 {
+	if result == nil {
 		return http.StatusNotFound, errors.New("user not found")
 	}
 
-	// unmarshal item into struct
-	err = attributevalue.UnmarshalMap(result.Item, &u)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("failed to unmarshal dynamo item map to user")
-	}
+	// keep anything that isn't stored on the item
+	result.Groups = u.Groups
+	*u = *result
 
 	return http.StatusOK, nil
 }
 
 // GetByUserID the user from the table by their oauth id
 func (u *User) GetByAuthProviderId() (status int, error error) {
-	// input
-	pkCondition := expression.Key(PartitionKey).BeginsWith(fmt.Sprintf("%s#", UserAuthProviderPartitionKey))
-	skCondition := expression.Key(SortKey).Equal(
-		expression.Value(fmt.Sprintf("%s#%s#%s", UserAuthProviderSortKey, *u.AuthProvider, *u.AuthProviderId)),
-	)
-	keyCondition := expression.KeyAnd(skCondition, pkCondition)
-
-	projExpr := expression.NamesList(expression.Name("UserID"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("error building GetByAuthProviderId expression")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		IndexName:                 aws.String(GSI),
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
 	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
+	userID, err := Store.GetUserIDByAuthProvider(*u.AuthProvider, *u.AuthProviderId)
 
 	// handle errors
 	if err != nil {
@@ -440,15 +262,10 @@ func (u *User) GetByAuthProviderId() (status int, error error) {
 		return http.StatusInternalServerError, err
 	}
 
-	if len(result.Items) == 0 {
+	if userID == "" {
 		return http.StatusNotFound, nil
 	}
-
-	// unmarshal item into user
-	err = attributevalue.UnmarshalMap(result.Items[0], &u)
-	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("failed to unmarshal dynamo item map to user")
-	}
+	u.UserID = userID
 
 	// fill the user out
 	getUserStatus, _ := u.GetByUserID()
@@ -457,46 +274,22 @@ func (u *User) GetByAuthProviderId() (status int, error error) {
 
 // Exists checks to see if a user exists. You can lookup via UserID or AuthProviderId.
 func (u *User) Exists(lookup string) (bool, error) {
-	var pkCondition, skCondition expression.KeyConditionBuilder
-	var idx *string
+	var exists bool
+	var err error
 
 	switch lookup {
 	case "UserID":
-		pkCondition = expression.Key(PartitionKey).Equal(expression.Value(u.PKVal()))
-		skCondition = expression.Key(SortKey).Equal(expression.Value(u.SKVal()))
+		var user *User
+		user, err = Store.GetUser(u.UserID)
+		exists = user != nil
 	case "AuthProviderId":
-		idx = aws.String(GSI)
-		pkCondition = expression.Key(PartitionKey).BeginsWith(fmt.Sprintf("%s#", UserAuthProviderPartitionKey))
-		skCondition = expression.Key(SortKey).Equal(
-			expression.Value(
-				fmt.Sprintf("%s#%s#%s", UserAuthProviderSortKey, *u.AuthProvider, *u.AuthProviderId),
-			),
-		)
+		var userID string
+		userID, err = Store.GetUserIDByAuthProvider(*u.AuthProvider, *u.AuthProviderId)
+		exists = userID != ""
 	default:
 		return false, errors.New("unsupported lookup, must be one of UserID, AuthProviderId")
 	}
 
-	projExpr := expression.NamesList(expression.Name("UserID"))
-	keyCondition := expression.KeyAnd(skCondition, pkCondition)
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("error building user Exists expression")
-	}
-
-	// create input
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		IndexName:                 idx,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
-	}
-
-	// query
-	result, err := clients.DynamoClient.Query(context.TODO(), input)
-
 	// handle errors
 	if err != nil {
 		logger.Log.Error().Err(err).Str("lookup", lookup).Msg("Error checking if user exists in table")
@@ -504,7 +297,7 @@ func (u *User) Exists(lookup string) (bool, error) {
 	}
 
 	// user doesn't exist
-	if len(result.Items) == 0 {
+	if !exists {
 		logger.Log.Info().Str("lookup", lookup).Msg("User does not exist in table")
 		return false, nil
 	}
@@ -516,22 +309,8 @@ func (u *User) Exists(lookup string) (bool, error) {
 
 // NewAuthProvider creates a new mapping of a user to their auth provider
 func (u *User) NewAuthProvider() error {
-	uap := userAuthProvider{
-		PK:             fmt.Sprintf("%s#%s", UserAuthProviderPartitionKey, u.UserID),
-		SK:             fmt.Sprintf("%s#%s#%s", UserAuthProviderSortKey, *u.AuthProvider, *u.AuthProviderId),
-		UserID:         u.UserID,
-		AuthProviderId: *u.AuthProviderId,
-		AuthProvider:   *u.AuthProvider,
-	}
-
 	// add the user auth provider to the table
-	av, _ := attributevalue.MarshalMap(uap)
-	input := &dynamodb.PutItemInput{
-		TableName:    &DynamoTable,
-		Item:         av,
-		ReturnValues: dbTypes.ReturnValueNone,
-	}
-	_, err := clients.DynamoClient.PutItem(context.TODO(), input)
+	err := Store.PutAuthProvider(u.UserID, *u.AuthProvider, *u.AuthProviderId)
 
 	// handle errors
 	if err != nil {
@@ -544,43 +323,21 @@ func (u *User) NewAuthProvider() error {
 	return nil
 }
 
-// UpdatePoints adds the points to the users score
-func (u *User) UpdatePoints(points int) error {
-	input := &dynamodb.UpdateItemInput{
-		ExpressionAttributeNames: map[string]string{
-			"#P": "Points",
-		},
-		ExpressionAttributeValues: map[string]dbTypes.AttributeValue{
-			":p": &dbTypes.AttributeValueMemberN{Value: strconv.Itoa(points)},
-		},
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: u.PKVal()},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: u.SKVal()},
-		},
-		ReturnValues:     dbTypes.ReturnValueNone,
-		TableName:        &DynamoTable,
-		UpdateExpression: aws.String("ADD #P :p"),
-	}
-	_, err := clients.DynamoClient.UpdateItem(context.TODO(), input)
+// GetPoints fills in the user's points in the countdown
+func (u *User) GetPoints(countdownID string) error {
+	points, err := Store.GetUserPoints(countdownID, u.UserID)
 	if err != nil {
-		logger.Log.Error().Err(err).Str("userID", u.UserID).Msg("Unable to update the users points")
+		logger.Log.Error().Err(err).Str("userID", u.UserID).Str("countdownID", countdownID).Msg("Unable to get the users points")
+		return err
 	}
+	u.Points = points
 	return nil
 }
 
 // LeaveGroup removes the User from a Group
 func (u *User) LeaveGroup(groupID string) (status int, error error) {
-	// delete query
-	input := &dynamodb.DeleteItemInput{
-		Key: map[string]dbTypes.AttributeValue{
-			PartitionKey: &dbTypes.AttributeValueMemberS{Value: fmt.Sprintf("%s#%s", GroupPartitionKey, groupID)},
-			SortKey:      &dbTypes.AttributeValueMemberS{Value: u.PKVal()},
-		},
-		TableName: &DynamoTable,
-	}
-
 	// delete membership from table
-	_, err := clients.DynamoClient.DeleteItem(context.TODO(), input)
+	err := Store.DeleteMembership(groupID, u.UserID)
 
 	// handle errors
 	if err != nil {
@@ -613,15 +370,13 @@ func (u *User) CreateToken() (string, error) {
 	}
 
 	// get the signing key
-	input := &secretsmanager.GetSecretValueInput{SecretId: &JWTSigningSecret}
-	secret, err := clients.SecretsClient.GetSecretValue(context.TODO(), input)
+	signingKey, err := getSigningKey()
 	if err != nil {
-		logger.Log.Error().Err(err).Msg("unable to get signing key from secretsmanager")
 		return "", err
 	}
 
 	// parse the key, sign the token and return it
-	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(*secret.SecretString))
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(signingKey))
 	if err != nil {
 		logger.Log.Error().Err(err).Msg("unable to parse signing private key")
 		return "", err
@@ -629,43 +384,27 @@ func (u *User) CreateToken() (string, error) {
 	return token.SignedString(key)
 }
 
-// GetEndpoints returns all of the device endpoints that a user has
-func (u *User) GetEndpoints() (*[]PlatformEndpoint, error) {
-	// input
-	pkCondition := expression.Key(PartitionKey).Equal(expression.Value(u.PKVal()))
-	skCondition := expression.Key(SortKey).BeginsWith(fmt.Sprintf("%s#", EndpointSortKey))
-	keyCondition := expression.KeyAnd(pkCondition, skCondition)
-
-	projExpr := expression.NamesList(expression.Name("Arn"), expression.Name("Platform"))
-
-	expr, err := expression.NewBuilder().WithKeyCondition(keyCondition).WithProjection(projExpr).Build()
-
-	if err != nil {
-		logger.Log.Error().Err(err).Msg("error building expression for GetEndpoints func")
-	}
-
-	input := &dynamodb.QueryInput{
-		TableName:                 &DynamoTable,
-		KeyConditionExpression:    expr.KeyCondition(),
-		ExpressionAttributeNames:  expr.Names(),
-		ExpressionAttributeValues: expr.Values(),
-		ProjectionExpression:      expr.Projection(),
+// getSigningKey returns the JWTSigningKey or fetches it from secretsmanager if it isn't set
+func getSigningKey() (string, error) {
+	if config.Values.JWTSigningKey != "" {
+		return config.Values.JWTSigningKey, nil
 	}
 
-	queryResult, queryErr := clients.DynamoClient.Query(context.TODO(), input)
-	if queryErr != nil {
-		logger.Log.Error().Err(queryErr).Str("userId", u.UserID).Msg("error getting users endpoints")
-		return &[]PlatformEndpoint{}, queryErr
+	input := &secretsmanager.GetSecretValueInput{SecretId: &config.Values.JWTSigningSecret}
+	secret, err := clients.SecretsClient.GetSecretValue(context.TODO(), input)
+	if err != nil {
+		logger.Log.Error().Err(err).Msg("unable to get signing key from secretsmanager")
+		return "", err
 	}
+	return *secret.SecretString, nil
+}
 
-	var endpoints []PlatformEndpoint
-	for _, item := range queryResult.Items {
-		endpoint := PlatformEndpoint{}
-		if err := attributevalue.UnmarshalMap(item, &endpoint); err != nil {
-			logger.Log.Error().Err(err).Msg("Unable to unmarshal item to PlatformEndpoint")
-			continue
-		}
-		endpoints = append(endpoints, endpoint)
+// GetEndpoints returns all of the device endpoints that a user has
+func (u *User) GetEndpoints() (*[]PlatformEndpoint, error) {
+	endpoints, err := Store.GetEndpoints(u.UserID)
+	if err != nil {
+		logger.Log.Error().Err(err).Str("userId", u.UserID).Msg("error getting users endpoints")
+		return &[]PlatformEndpoint{}, err
 	}
 
 	return &endpoints, nil
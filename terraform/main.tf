@@ -41,6 +41,16 @@ resource "aws_dynamodb_table" "jaypi" {
     type = "S"
   }
 
+  attribute {
+    name = "LeaderboardPK"
+    type = "S"
+  }
+
+  attribute {
+    name = "Points"
+    type = "N"
+  }
+
   global_secondary_index {
     name            = "GSI1"
     hash_key        = "SK"
@@ -50,6 +60,17 @@ resource "aws_dynamodb_table" "jaypi" {
     read_capacity   = 5
   }
 
+  # only points items have a LeaderboardPK, it's one of a few shards of a countdown's leaderboard
+  global_secondary_index {
+    name               = "GSI2"
+    hash_key           = "LeaderboardPK"
+    range_key          = "Points"
+    projection_type    = "INCLUDE"
+    non_key_attributes = ["CountdownID", "UserID", "Hits"]
+    write_capacity     = 5
+    read_capacity      = 5
+  }
+
   tags = {
     Environment = var.environment
   }